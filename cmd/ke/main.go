@@ -4,37 +4,286 @@ package main // import "kego.io/cmd/ke"
 // ke: {"package": {"notest": true}}
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 
 	"context"
 
 	"github.com/davelondon/kerr"
-	"kego.io/context/cmdctx"
 	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/context/vosctx"
 	"kego.io/context/wgctx"
 	"kego.io/editor/server"
 	"kego.io/process"
+	"kego.io/process/diff"
+	"kego.io/process/doc"
+	"kego.io/process/graph"
+	"kego.io/process/lint"
+	"kego.io/process/migrate"
+	"kego.io/process/packages"
+	"kego.io/process/parser"
+	"kego.io/process/query"
 	"kego.io/process/validate"
 	_ "kego.io/system"
 )
 
+// The flags and arguments that only one command uses.
+var (
+	queryFlags  = &process.QueryFlags{}
+	diffFlags   = &process.DiffFlags{}
+	renameFlags = &process.RenameFlags{}
+	graphFlags  = &process.GraphFlags{}
+)
+
+var commands = []*process.Command{
+	{
+		Name:  "generate",
+		Usage: "[flags] [package]",
+		Short: "generate the Go types for a package and the packages it imports",
+		Long: `Generate parses the type files in the package and the packages it imports, and
+writes generated.go in each package where the types have changed. If the package
+is omitted, the package in the current directory is used.
+
+With -check, nothing is written. The packages whose generated.go is missing, was
+generated from different types, or differs from the source that would be
+generated are printed, and the exit status is 1 if there are any.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.BoolVar(&o.Check, "check", false, "Check: list the packages that need to be generated, without changing them")
+		},
+		Run: runGenerate,
+	},
+	{
+		Name:  "validate",
+		Usage: "[flags] [package]",
+		Short: "validate the data files in a package",
+		Long: `Validate checks every data file in the package against the rules in its types.
+Validation errors are printed and the exit status is 4. The built-in rules are
+enforced directly from the type files. If the package or its aliased packages
+have custom Go Enforce or Validate methods, a validate command is compiled
+instead, so the generated Go types must be up to date: if they have changed, run
+"ke generate" first. If the package is omitted, the package in the current
+directory is used.
+
+With -format, a report is written instead: text, json, junit (JUnit XML) or
+sarif (SARIF 2.1.0). Each entry has the file, line, column, node path, error code,
+rule type and message.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.StringVar(&o.Format, "format", "", "Format: write a validation report in this format: text, json, junit or sarif")
+			fs.StringVar(&o.Output, "o", "", "Output: write the validation report to this file. Default: stdout")
+		},
+		Run: runValidate,
+	},
+	{
+		Name:  "watch",
+		Usage: "[flags] [package]",
+		Short: "regenerate and revalidate a package when its files change",
+		Long: `Watch generates the Go types and validates the package, then waits for a data
+file to change in the package, its sub-directories (if the package is recursive)
+or its aliased packages, and runs again. After each run the new validation errors
+are printed with a "+" prefix, and the fixed errors with a "-" prefix. Press
+Ctrl+C to stop. If the package is omitted, the package in the current directory
+is used.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.DurationVar(&o.Interval, "i", process.DefaultWatchInterval, "Interval: the time between checks for changed files")
+		},
+		Run: runWatch,
+	},
+	{
+		Name:  "fmt",
+		Usage: "[flags] [package]",
+		Short: "rewrite the data files in a package in canonical form",
+		Long: `Fmt rewrites every json and yaml file in the package in canonical form. The
+fields of objects are in the order of the type's fields, with type and id first.
+Map keys are sorted, references are written in their shortest form (e.g. @string
+instead of system:@string in the system package) and indentation is consistent.
+The names of the changed files are printed. If the package is omitted, the
+package in the current directory is used.
+
+With -check, the files are not written. The names of the files that would change
+are printed, and the exit status is 1 if there are any.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.BoolVar(&o.Check, "check", false, "Check: list the files that are not formatted, without changing them")
+		},
+		Run: runFmt,
+	},
+	{
+		Name:  "lint",
+		Usage: "[flags] [package]",
+		Short: "report likely mistakes in the types and data of a package",
+		Long: `Lint runs checks over the data files in the package, and prints the problems
+with their position, severity, check and node path. The checks are:
+
+	default-enum      a field default that isn't in the enum (error)
+	empty-selector    a rule selector that doesn't match any nodes (warning)
+	type-description  a type with no description (warning)
+	unused-alias      an alias in system:package that isn't used (warning)
+	unused-type       a type that isn't used in the package (info)
+
+The severity of a check can be changed, or the check turned off, with a
+system:lint-config global in the package, e.g. checks: {unused-type: "off"}. A
+package can only have one system:lint-config. A node tagged lint-ignore, or
+lint-ignore:<check>, and its descendants aren't checked. The exit status is 1 if
+there are any errors. If the package is omitted, the package in the current
+directory is used.`,
+		Flags: process.CommonFlags,
+		Run:   runLint,
+	},
+	{
+		Name:  "query",
+		Usage: "[flags] <selector> [package]",
+		Short: "find the nodes in a package that match a selector",
+		Long: `Query loads every data file in the package, and prints the nodes that match the
+selector, with the file, position and path of each. The selector syntax is the
+same as the selector in rules, e.g. {kego.io/demo/site:hero} matches every
+node of the hero type, and .image matches the image fields. If the package is
+omitted, the package in the current directory is used.
+
+With -format, the matches are written as plain values (the default), json or
+yaml.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.StringVar(&o.Format, "format", "", "Format: write the matches in this format: plain, json or yaml")
+		},
+		Args: queryFlags.Args,
+		Run:  runQuery,
+	},
+	{
+		Name:  "diff",
+		Usage: "[flags] <rev-or-dir-a> [dir-b]",
+		Short: "compare the data in a package with a git revision or another directory",
+		Long: `Diff compares the data files in the package directory dir-b with the data files
+in dir-a, or with the package directory at a git revision. The globals and fields
+that have been added, removed or changed are printed by node path, with a "+",
+"-" or "~" prefix. Formatting, the order of map keys and the alias used in
+references are ignored, and a missing field is the same as its default value. If
+dir-b is omitted, the current directory is used.`,
+		Flags: process.CommonFlags,
+		Args:  diffFlags.Args,
+		Run:   runDiff,
+	},
+	{
+		Name:  "migrate",
+		Usage: "[flags] [package]",
+		Short: "apply the pending migrations in a package",
+		Long: `Migrate applies the pending system:migration objects in the package to every
+other global, in order of id. The operations in a migration rename, move, wrap or
+set fields of objects of a type, or change the type of objects. Each object that
+is changed is printed with its file, path, migration and operation. The changed
+files are written in canonical form, and the migrations are marked as applied so
+they aren't applied again. If the package is omitted, the package in the current
+directory is used.
+
+With -check, the files are not written.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.BoolVar(&o.Check, "check", false, "Check: list the changes, without changing the files")
+		},
+		Run: runMigrate,
+	},
+	{
+		Name:  "rename",
+		Usage: "[flags] <type-or-global> <new-name> [package]",
+		Short: "rename a type or global and change every reference to it",
+		Long: `Rename renames a type or global, e.g. ke rename images:photo picture. The type
+or global is a reference in the form used in the package, and the new name is in
+the same package. Every type field, @rule type, embed entry and reference value
+that points at it is changed in the package that defines it and in the packages
+that alias it: the ones the package imports, and the others in its Go module, or
+outside a module in the dirs under the package, so run it from the top-level
+package. Comments in yaml files are kept. If the file is named
+after the type or global, it is renamed too. The changed files are printed and
+written in canonical form, then the Go types are generated for the changed
+packages. Go code that uses the old Go type name must be changed by hand. If the
+package is omitted, the package in the current directory is used.`,
+		Flags: process.CommonFlags,
+		Args:  renameFlags.Args,
+		Run:   runRename,
+	},
+	{
+		Name:  "graph",
+		Usage: "[flags] [package]",
+		Short: "print the graph of the types and globals in a package and its imports",
+		Long: `Graph prints the relationships between the types and globals in the package and
+the packages it imports: types embedded by types, the types of fields, interface
+types implemented by types, packages imported with an alias, the types of
+globals and globals that refer to other globals. Types and globals with no edges
+are included, so unused types are easy to spot. Interface implementations are
+only found for packages that are compiled into ke, e.g. system. If the package
+is omitted, the package in the current directory is used.
+
+With -format, the graph is written in Graphviz dot format (the default) or json.
+With -packages, only the edges from the types and globals in these packages are
+shown (default: all packages apart from system). With -types, only the edges to
+or from these types are shown.`,
+		Flags: graphFlags.Flags,
+		Run:   runGraph,
+	},
+	{
+		Name:  "doc",
+		Usage: "[flags] [package]",
+		Short: "write the documentation for the types in a package",
+		Long: `Doc writes a static site documenting the types in the package: an index page,
+and a page for each type with its description, Go name, native json type,
+embedded types, the types that implement it if it's an interface, the fields
+with their types, defaults and restrictions, the fields that can be used in its
+rules, and up to three example globals from the package data. The names of the
+files are printed. If the package is omitted, the package in the current
+directory is used.
+
+With -format, the site is written as html (the default) or markdown. With -o,
+the site is written to this directory (default: the doc directory in the
+package directory).`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.StringVar(&o.Format, "format", "", "Format: write the documentation in this format: html or markdown")
+			fs.StringVar(&o.Output, "o", "", "Output: write the documentation to this directory. Default: the doc directory in the package directory")
+		},
+		Run: runDoc,
+	},
+	{
+		Name:  "edit",
+		Usage: "[flags] [package]",
+		Short: "generate the Go types and open the editor",
+		Long: `Edit generates the Go types for the package, then starts the editor server and
+opens the editor in a browser. If the package is omitted, the package in the
+current directory is used.`,
+		Flags: func(fs *flag.FlagSet, o *process.Options) {
+			process.CommonFlags(fs, o)
+			fs.IntVar(&o.Port, "p", 0, "Port: use a specific port for the editor server. Default: use a random port")
+			fs.BoolVar(&o.Debug, "d", false, "Debug: don't close the server when the connection is closed")
+		},
+		Run: runEdit,
+	},
+}
+
 func main() {
 	// This turns off focus reporting mode that will print “^[[O” and “^[[I”
 	// when the terminal window gets / loses focus
 	// http://superuser.com/questions/931873/o-and-i-appearing-on-iterm2-when-focus-lost
 	fmt.Print("\033[?1004l")
 
-	ctx, cancel, err := process.Initialise(context.Background(), nil)
+	command, options, err := process.ParseCommand(commands, os.Args[1:], os.Stderr)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2) // Exit code 2: usage error
 	}
 
-	env := envctx.FromContext(ctx)
-	cmd := cmdctx.FromContext(ctx)
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = wgctx.NewContext(ctx)
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -44,34 +293,288 @@ func main() {
 		cancel()
 	}()
 
-	if err := process.GenerateAll(ctx, env.Path, map[string]bool{}); err != nil {
+	if err := command.Run(ctx, options); err != nil {
+		if _, ok := kerr.Source(err).(validate.ValidationCommandError); ok {
+			// The validation errors have already been written by the command.
+			wgctx.WaitAndExit(ctx, 4) // Exit code 4: validation error
+		}
 		fmt.Println(err.Error())
 		wgctx.WaitAndExit(ctx, 1)
 	}
 
-	if cmd.Validate {
-		err := process.RunValidateCommand(ctx)
+	wgctx.WaitAndExit(ctx, 0)
+
+}
+
+func runGenerate(ctx context.Context, options *process.Options) error {
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("NPCWQQNIKX", err)
+	}
+	env := envctx.FromContext(ctx)
+	if options.Check {
+		stale, err := process.CheckGenerated(ctx, env.Path, map[string]bool{})
 		if err != nil {
-			if !cmd.Log {
-				// in log mode, we have already written the output of the exec'ed ke command,
-				// so we don't need to duplicate the error message.
-				if v, ok := kerr.Source(err).(validate.ValidationCommandError); ok {
-					fmt.Println(v.Description)
-					wgctx.WaitAndExit(ctx, 4) // Exit code 4: validation error
-				}
-				fmt.Println(err.Error())
-			}
-			wgctx.WaitAndExit(ctx, 1)
+			return kerr.Wrap("VSYWDLMKOA", err)
+		}
+		for _, s := range stale {
+			fmt.Printf("%s: %s\n", s.Path, strings.Join(s.Reasons, ", "))
 		}
+		if len(stale) > 0 {
+			return kerr.New("EHRQOJBXTC", "%d packages need to be generated", len(stale))
+		}
+		return nil
+	}
+	if err := process.GenerateAll(ctx, env.Path, map[string]bool{}); err != nil {
+		return kerr.Wrap("JRYBHPQDKM", err)
 	}
+	return nil
+}
 
-	if cmd.Edit {
-		if err = server.Start(ctx, cancel); err != nil {
-			fmt.Println(err.Error())
-			wgctx.WaitAndExit(ctx, 1)
+func runValidate(ctx context.Context, options *process.Options) error {
+	format := validate.ReportFormat(options.Format)
+	if format != "" {
+		// Check the format before doing any work
+		if err := validate.WriteReport(ioutil.Discard, format, "", nil); err != nil {
+			return kerr.Wrap("BXQKOYJVRC", err)
 		}
 	}
+	options.Validate = true
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("EOGDGCXSSJ", err)
+	}
+	err = process.Validate(ctx)
+	v, failed := kerr.Source(err).(validate.ValidationCommandError)
+	if err != nil && !failed {
+		return kerr.Wrap("WNIXGMQKUA", err)
+	}
+	if format == "" {
+		if failed && !options.Log {
+			// in log mode, we have already written the output of the exec'ed ke command,
+			// so we don't need to duplicate the error message.
+			fmt.Println(v.Description)
+		}
+		return err
+	}
+	out := io.Writer(os.Stdout)
+	if options.Output != "" {
+		f, err := os.Create(options.Output)
+		if err != nil {
+			return kerr.Wrap("NCFQUCXLAR", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := validate.WriteReport(out, format, envctx.FromContext(ctx).Path, v.Entries); err != nil {
+		return kerr.Wrap("GLYNMGRHVS", err)
+	}
+	return err
+}
 
-	wgctx.WaitAndExit(ctx, 0)
+func runWatch(ctx context.Context, options *process.Options) error {
+	if err := process.Watch(ctx, options, os.Stdout); err != nil {
+		return kerr.Wrap("DQLUPFTMXK", err)
+	}
+	return nil
+}
 
+func runFmt(ctx context.Context, options *process.Options) error {
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("XVNEJQHCSW", err)
+	}
+	changed, err := process.Format(ctx, options.Check)
+	if err != nil {
+		return kerr.Wrap("UKDYBPTGAO", err)
+	}
+	for _, file := range changed {
+		fmt.Println(file)
+	}
+	if options.Check && len(changed) > 0 {
+		return kerr.New("HQWFSMLYCB", "%d files are not formatted", len(changed))
+	}
+	return nil
+}
+
+func runLint(ctx context.Context, options *process.Options) error {
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("WOKXNDCRGF", err)
+	}
+	problems, err := lint.Lint(ctx)
+	if err != nil {
+		return kerr.Wrap("PUYJQEHSAB", err)
+	}
+	if err := lint.Write(os.Stdout, problems); err != nil {
+		return kerr.Wrap("CEVMGUXLTK", err)
+	}
+	if lint.Failed(problems) {
+		return kerr.New("IYNSWFAPDH", "Lint found errors")
+	}
+	return nil
+}
+
+func runMigrate(ctx context.Context, options *process.Options) error {
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("GUTXEMBOWP", err)
+	}
+	changes, err := process.Migrate(ctx, options.Check)
+	if err != nil {
+		return kerr.Wrap("LNVCQIHRSY", err)
+	}
+	if err := migrate.Write(os.Stdout, changes); err != nil {
+		return kerr.Wrap("TOBJPYXFKN", err)
+	}
+	return nil
+}
+
+func runRename(ctx context.Context, options *process.Options) error {
+	rctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("YRWFKOCNIH", err)
+	}
+	changed, packages, err := process.Rename(rctx, renameFlags.Target, renameFlags.Name)
+	if err != nil {
+		return kerr.Wrap("DAQMOLJXWT", err)
+	}
+	for _, file := range changed {
+		fmt.Println(file)
+	}
+	// The types have changed, so the packages are parsed again before the Go types are
+	// generated. The changed packages that the package doesn't import are parsed too.
+	gctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("TKGAWBHUEP", err)
+	}
+	env := envctx.FromContext(gctx)
+	done := map[string]bool{}
+	if err := process.GenerateAll(gctx, env.Path, done); err != nil {
+		return kerr.Wrap("NXUVCIRSJF", err)
+	}
+	scache := sysctx.FromContext(gctx)
+	for _, path := range packages {
+		if _, ok := scache.Get(path); !ok {
+			if _, err := parser.Parse(gctx, path); err != nil {
+				return kerr.Wrap("LUWDBQXHEA", err)
+			}
+		}
+		if err := process.GenerateAll(gctx, path, done); err != nil {
+			return kerr.Wrap("GTRYKCOPJN", err)
+		}
+	}
+	return nil
+}
+
+func runGraph(ctx context.Context, options *process.Options) error {
+	format := graph.Format(options.Format)
+	// Check the format before doing any work
+	if err := graph.Write(ioutil.Discard, format, &graph.Graph{}); err != nil {
+		return kerr.Wrap("UHOXRWCQMF", err)
+	}
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("PEJTYKVNAB", err)
+	}
+	filter := graph.Filter{}
+	if graphFlags.Packages != "" {
+		filter.Packages = strings.Split(graphFlags.Packages, ",")
+	}
+	if graphFlags.Types != "" {
+		filter.Types = strings.Split(graphFlags.Types, ",")
+	}
+	g, err := graph.Build(ctx, filter)
+	if err != nil {
+		return kerr.Wrap("FYLQWAIDCO", err)
+	}
+	if err := graph.Write(os.Stdout, format, g); err != nil {
+		return kerr.Wrap("XMBEKUPSTW", err)
+	}
+	return nil
+}
+
+func runDoc(ctx context.Context, options *process.Options) error {
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("QCXFLJTRWO", err)
+	}
+	dir := options.Output
+	if dir == "" {
+		dir = filepath.Join(envctx.FromContext(ctx).Dir, "doc")
+	}
+	written, err := process.Doc(ctx, doc.Format(options.Format), dir)
+	if err != nil {
+		return kerr.Wrap("HAUSKVMYPE", err)
+	}
+	for _, file := range written {
+		fmt.Println(file)
+	}
+	return nil
+}
+
+func runQuery(ctx context.Context, options *process.Options) error {
+	format := query.Format(options.Format)
+	// Check the format before doing any work
+	if err := query.Write(ctx, ioutil.Discard, format, nil); err != nil {
+		return kerr.Wrap("OWXKGHNPYA", err)
+	}
+	ctx, _, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("KSUEBVQMDI", err)
+	}
+	matches, err := query.Query(ctx, queryFlags.Selector)
+	if err != nil {
+		return kerr.Wrap("AHPVTNLYCO", err)
+	}
+	if err := query.Write(ctx, os.Stdout, format, matches); err != nil {
+		return kerr.Wrap("EYMVQRJTBD", err)
+	}
+	return nil
+}
+
+func runDiff(ctx context.Context, options *process.Options) error {
+	dir := diffFlags.Dir
+	if dir == "" {
+		wd, err := vosctx.FromContext(ctx).Getwd()
+		if err != nil {
+			return kerr.Wrap("RLWUGQNMYB", err)
+		}
+		dir = wd
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return kerr.Wrap("VYDCSFTRWB", err)
+	}
+	if options.Path, err = packages.GetPackageFromDir(ctx, dir); err != nil {
+		return kerr.Wrap("GUWRFNXPMA", err)
+	}
+	ctx, _, err = process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("DXKNEQBOPU", err)
+	}
+	changes, err := process.Diff(ctx, diffFlags.Base)
+	if err != nil {
+		return kerr.Wrap("WJYDSMKOQF", err)
+	}
+	if err := diff.Write(os.Stdout, changes); err != nil {
+		return kerr.Wrap("ELRQGUHVNC", err)
+	}
+	return nil
+}
+
+func runEdit(ctx context.Context, options *process.Options) error {
+	options.Edit = true
+	ctx, cancel, err := process.Initialise(ctx, options)
+	if err != nil {
+		return kerr.Wrap("TOSXNTNDAT", err)
+	}
+	env := envctx.FromContext(ctx)
+	if err := process.GenerateAll(ctx, env.Path, map[string]bool{}); err != nil {
+		return kerr.Wrap("LKCGKUDDTM", err)
+	}
+	if err := server.Start(ctx, cancel); err != nil {
+		return kerr.Wrap("WXRVSDVEQW", err)
+	}
+	return nil
 }
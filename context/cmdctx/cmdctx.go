@@ -18,6 +18,7 @@ type Cmd struct {
 	Log      bool
 	Debug    bool
 	Port     int
+	Workers  int // Workers is the number of packages parsed or generated at the same time. Default: the number of CPUs
 }
 
 // key is an unexported type for keys defined in this package.
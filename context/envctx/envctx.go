@@ -15,6 +15,10 @@ type Env struct {
 	Recursive bool
 	Hash      uint64
 	Dir       string
+	// Include and Exclude are the glob patterns from the package object that select the data
+	// files in the package.
+	Include []string
+	Exclude []string
 }
 
 // key is an unexported type for keys defined in this package.
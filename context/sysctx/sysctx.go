@@ -36,6 +36,11 @@ type SysTypeInfo struct {
 	Name string
 	File string
 	Type interface{}
+	// once and load are set for types that are loaded the first time they're used. err is the
+	// error returned by load.
+	once *sync.Once
+	load func() (interface{}, error)
+	err  error
 }
 
 type SysFiles struct {
@@ -56,6 +61,8 @@ type SysGlobals struct {
 type SysGlobalInfo struct {
 	Name string
 	File string
+	// Type is the type of the global, in the form [package path]:[name].
+	Type string
 }
 
 func (c *SysCache) Len() int {
@@ -79,16 +86,25 @@ func (c *SysCache) SetEnv(env *envctx.Env) *SysPackageInfo {
 	return p
 }
 
-func (c *SysCache) GetType(path string, name string) (interface{}, bool) {
+// Delete removes the package, so it's parsed again the next time it's imported.
+func (c *SysCache) Delete(path string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.m, path)
+}
+
+// GetType returns a type from the cache. If the type was found and failed to load, the error is
+// returned.
+func (c *SysCache) GetType(path string, name string) (t interface{}, found bool, err error) {
 	p, ok := c.Get(path)
 	if !ok {
-		return nil, false
+		return nil, false, nil
 	}
-	t, ok := p.Types.Get(name)
-	if !ok {
-		return nil, false
+	ti, found, err := p.Types.GetErr(name)
+	if !found || err != nil {
+		return nil, found, err
 	}
-	return t.Type, true
+	return ti.Type, true, nil
 }
 
 func (c *SysCache) Get(path string) (*SysPackageInfo, bool) {
@@ -119,11 +135,47 @@ func (c *SysTypes) Set(id string, filename string, t interface{}) {
 	}
 }
 
+// SetLazy adds a type that is loaded by load the first time it's got. If load returns an error,
+// Get doesn't find the type, and GetErr returns the error.
+func (c *SysTypes) SetLazy(id string, filename string, load func() (interface{}, error)) {
+	c.Lock()
+	defer c.Unlock()
+	c.types[id] = &SysTypeInfo{
+		Name: id,
+		File: filename,
+		once: &sync.Once{},
+		load: load,
+	}
+}
+
+// Get returns a type. A type that fails to load isn't found, so GetErr should be used wherever
+// the error can be reported.
 func (c *SysTypes) Get(id string) (*SysTypeInfo, bool) {
+	ti, found, err := c.GetErr(id)
+	if !found || err != nil {
+		return nil, false
+	}
+	return ti, true
+}
+
+// GetErr is like Get, but if the type was found and failed to load, the error is returned.
+func (c *SysTypes) GetErr(id string) (ti *SysTypeInfo, found bool, err error) {
 	c.RLock()
-	defer c.RUnlock()
 	ti, ok := c.types[id]
-	return ti, ok
+	c.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+	if ti.once != nil {
+		// The lock isn't held while the type is loaded, because loading may get other types.
+		ti.once.Do(func() {
+			ti.Type, ti.err = ti.load()
+		})
+		if ti.err != nil {
+			return nil, true, kerr.Wrap("WGYPNTSMKO", ti.err)
+		}
+	}
+	return ti, true, nil
 }
 
 func (c *SysTypes) Len() int {
@@ -143,12 +195,13 @@ func (c *SysTypes) Keys() []string {
 	return out
 }
 
-func (c *SysGlobals) Set(id string, filename string) {
+func (c *SysGlobals) Set(id string, filename string, typ string) {
 	c.Lock()
 	defer c.Unlock()
 	c.globals[id] = &SysGlobalInfo{
 		Name: id,
 		File: filename,
+		Type: typ,
 	}
 }
 
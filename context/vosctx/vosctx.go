@@ -3,6 +3,7 @@ package vosctx // import "kego.io/context/vosctx"
 // ke: {"package": {"notest": true}}
 
 import (
+	"io/ioutil"
 	"os"
 
 	"context"
@@ -12,6 +13,8 @@ type Vos interface {
 	Getenv(key string) string
 	Getwd() (string, error)
 	Environ() []string
+	ReadFile(filename string) ([]byte, error)
+	Stat(name string) (os.FileInfo, error)
 }
 
 type key int
@@ -54,3 +57,13 @@ func (*realOs) Getwd() (string, error) {
 func (*realOs) Environ() []string {
 	return os.Environ()
 }
+
+// ReadFile reads the file named by filename and returns the contents.
+func (*realOs) ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+// Stat returns a FileInfo describing the named file.
+func (*realOs) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
@@ -1,16 +1,14 @@
-// info:{"Path":"kego.io/demo/common/images","Hash":7237742861799384387}
+// info:{"Path":"kego.io/demo/common/images","Hash":10075298537753017310}
 package images
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/demo/common/units"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for icon
@@ -64,7 +62,7 @@ func (o *Photo) GetPhoto(ctx context.Context) *Photo {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/common/images", 7237742861799384387)
+	pkg := jsonctx.InitPackage("kego.io/demo/common/images", 10075298537753017310)
 	pkg.InitType("icon", reflect.TypeOf((*Icon)(nil)), reflect.TypeOf((*IconRule)(nil)), reflect.TypeOf((*IconInterface)(nil)).Elem())
 	pkg.InitType("image", reflect.TypeOf((*Image)(nil)).Elem(), reflect.TypeOf((*ImageRule)(nil)), nil)
 	pkg.InitType("photo", reflect.TypeOf((*Photo)(nil)), reflect.TypeOf((*PhotoRule)(nil)), reflect.TypeOf((*PhotoInterface)(nil)).Elem())
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/demo/common/units","Hash":10988868301072060435}
+// info:{"Path":"kego.io/demo/common/units","Hash":3753229096090249451}
 package units
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for rectangle
@@ -30,6 +28,6 @@ func (o *Rectangle) GetRectangle(ctx context.Context) *Rectangle {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/common/units", 10988868301072060435)
+	pkg := jsonctx.InitPackage("kego.io/demo/common/units", 3753229096090249451)
 	pkg.InitType("rectangle", reflect.TypeOf((*Rectangle)(nil)), reflect.TypeOf((*RectangleRule)(nil)), reflect.TypeOf((*RectangleInterface)(nil)).Elem())
 }
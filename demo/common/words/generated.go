@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/demo/common/words","Hash":15839668451341961644}
+// info:{"Path":"kego.io/demo/common/words","Hash":8280551714772366646}
 package words
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for localizer
@@ -57,7 +55,7 @@ func (o *Translation) GetTranslation(ctx context.Context) *Translation {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/common/words", 15839668451341961644)
+	pkg := jsonctx.InitPackage("kego.io/demo/common/words", 8280551714772366646)
 	pkg.InitType("localizer", reflect.TypeOf((*Localizer)(nil)).Elem(), reflect.TypeOf((*LocalizerRule)(nil)), nil)
 	pkg.InitType("simple", reflect.TypeOf((*Simple)(nil)), reflect.TypeOf((*SimpleRule)(nil)), reflect.TypeOf((*SimpleInterface)(nil)).Elem())
 	pkg.InitType("translation", reflect.TypeOf((*Translation)(nil)), reflect.TypeOf((*TranslationRule)(nil)), reflect.TypeOf((*TranslationInterface)(nil)).Elem())
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/demo/demo1","Hash":10713598547770441995}
+// info:{"Path":"kego.io/demo/demo1","Hash":2939246493314683254}
 package demo1
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for page
@@ -30,6 +28,6 @@ func (o *Page) GetPage(ctx context.Context) *Page {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo1", 10713598547770441995)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo1", 2939246493314683254)
 	pkg.InitType("page", reflect.TypeOf((*Page)(nil)), reflect.TypeOf((*PageRule)(nil)), reflect.TypeOf((*PageInterface)(nil)).Elem())
 }
@@ -1,14 +1,13 @@
-// info:{"Path":"kego.io/demo/demo3/images","Hash":16432779313430741732}
+// info:{"Path":"kego.io/demo/demo3/images","Hash":934708012246808407}
 package images
 
 // ke: {"file": {"notest": true}}
 
 import (
 	"context"
-	"reflect"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for photo
@@ -28,6 +27,6 @@ func (o *Photo) GetPhoto(ctx context.Context) *Photo {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo3/images", 16432779313430741732)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo3/images", 934708012246808407)
 	pkg.InitType("photo", reflect.TypeOf((*Photo)(nil)), reflect.TypeOf((*PhotoRule)(nil)), reflect.TypeOf((*PhotoInterface)(nil)).Elem())
 }
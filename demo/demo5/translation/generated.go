@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/demo/demo5/translation","Hash":13545408849668201489}
+// info:{"Path":"kego.io/demo/demo5/translation","Hash":16145273603733615898}
 package translation
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for localized
@@ -54,7 +52,7 @@ func (o *Smartling) GetSmartling(ctx context.Context) *Smartling {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo5/translation", 13545408849668201489)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo5/translation", 16145273603733615898)
 	pkg.InitType("localized", reflect.TypeOf((*Localized)(nil)).Elem(), reflect.TypeOf((*LocalizedRule)(nil)), nil)
 	pkg.InitType("simple", reflect.TypeOf((*Simple)(nil)), reflect.TypeOf((*SimpleRule)(nil)), reflect.TypeOf((*SimpleInterface)(nil)).Elem())
 	pkg.InitType("smartling", reflect.TypeOf((*Smartling)(nil)), reflect.TypeOf((*SmartlingRule)(nil)), reflect.TypeOf((*SmartlingInterface)(nil)).Elem())
@@ -1,14 +1,13 @@
-// info:{"Path":"kego.io/demo/demo6","Hash":7465480149826331644}
+// info:{"Path":"kego.io/demo/demo6","Hash":17892050218958279538}
 package demo6
 
 // ke: {"file": {"notest": true}}
 
 import (
 	"context"
-	"reflect"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for page
@@ -48,7 +47,7 @@ func (o *Person) GetPerson(ctx context.Context) *Person {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo6", 7465480149826331644)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo6", 17892050218958279538)
 	pkg.InitType("page", reflect.TypeOf((*Page)(nil)), reflect.TypeOf((*PageRule)(nil)), reflect.TypeOf((*PageInterface)(nil)).Elem())
 	pkg.InitType("person", reflect.TypeOf((*Person)(nil)), reflect.TypeOf((*PersonRule)(nil)), reflect.TypeOf((*PersonInterface)(nil)).Elem())
 }
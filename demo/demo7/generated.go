@@ -1,16 +1,14 @@
-// info:{"Path":"kego.io/demo/demo7","Hash":16224128938049598805}
+// info:{"Path":"kego.io/demo/demo7","Hash":17896766084679843089}
 package demo7
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/demo/demo7/images"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for page
@@ -30,6 +28,6 @@ func (o *Page) GetPage(ctx context.Context) *Page {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo7", 16224128938049598805)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo7", 17896766084679843089)
 	pkg.InitType("page", reflect.TypeOf((*Page)(nil)), reflect.TypeOf((*PageRule)(nil)), reflect.TypeOf((*PageInterface)(nil)).Elem())
 }
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/demo/demo7/images","Hash":14109639835138036999}
+// info:{"Path":"kego.io/demo/demo7/images","Hash":17163751933941906963}
 package images
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for photo
@@ -31,6 +29,6 @@ func (o *Photo) GetPhoto(ctx context.Context) *Photo {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo7/images", 14109639835138036999)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo7/images", 17163751933941906963)
 	pkg.InitType("photo", reflect.TypeOf((*Photo)(nil)), reflect.TypeOf((*PhotoRule)(nil)), reflect.TypeOf((*PhotoInterface)(nil)).Elem())
 }
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/demo/demo8/images","Hash":6005185369334051609}
+// info:{"Path":"kego.io/demo/demo8/images","Hash":4709534625849040871}
 package images
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 type PhotoRule struct {
@@ -31,6 +29,6 @@ func (o *Photo) GetPhoto(ctx context.Context) *Photo {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/demo8/images", 6005185369334051609)
+	pkg := jsonctx.InitPackage("kego.io/demo/demo8/images", 4709534625849040871)
 	pkg.InitType("photo", reflect.TypeOf((*Photo)(nil)), reflect.TypeOf((*PhotoRule)(nil)), reflect.TypeOf((*PhotoInterface)(nil)).Elem())
 }
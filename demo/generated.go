@@ -1,4 +1,4 @@
-// info:{"Path":"kego.io/demo","Hash":2321424767551062013}
+// info:{"Path":"kego.io/demo","Hash":3868638915900537689}
 package demo
 
 // ke: {"file": {"notest": true}}
@@ -1,15 +1,14 @@
-// info:{"Path":"kego.io/demo/site","Hash":3726678967244446909}
+// info:{"Path":"kego.io/demo/site","Hash":6587368446099117334}
 package site
 
 // ke: {"file": {"notest": true}}
 
 import (
 	"context"
-	"reflect"
-
 	"kego.io/context/jsonctx"
 	"kego.io/demo/common/images"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for body
@@ -94,7 +93,7 @@ func (o *Page) GetPage(ctx context.Context) *Page {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/demo/site", 3726678967244446909)
+	pkg := jsonctx.InitPackage("kego.io/demo/site", 6587368446099117334)
 	pkg.InitType("body", reflect.TypeOf((*Body)(nil)), reflect.TypeOf((*BodyRule)(nil)), reflect.TypeOf((*BodyInterface)(nil)).Elem())
 	pkg.InitType("columns", reflect.TypeOf((*Columns)(nil)), reflect.TypeOf((*ColumnsRule)(nil)), reflect.TypeOf((*ColumnsInterface)(nil)).Elem())
 	pkg.InitType("hero", reflect.TypeOf((*Hero)(nil)), reflect.TypeOf((*HeroRule)(nil)), reflect.TypeOf((*HeroInterface)(nil)).Elem())
@@ -108,7 +108,30 @@ func (v *StringEditorView) Render() vecty.Component {
 		}),
 	}
 
-	if sr, ok := v.model.Node.Rule.Interface.(*system.StringRule); ok && sr.Long {
+	if options := v.options(); len(options) > 0 {
+		// Enum types and strings with the enum restriction are shown as a dropdown.
+		var items vecty.List
+		for _, o := range options {
+			items = append(items, elem.Option(
+				prop.Value(o),
+				vecty.Text(o),
+			))
+		}
+		v.input = elem.Select(
+			prop.Class("form-control"),
+			prop.Value(v.model.Node.ValueString),
+			event.Change(func(e *vecty.Event) {
+				v.App.Dispatch(&actions.Modify{
+					Undoer:    &actions.Undoer{},
+					Editor:    v.model,
+					Before:    v.model.Node.NativeValue(),
+					After:     e.Target.Get("value").String(),
+					Immediate: true,
+				})
+			}),
+			items,
+		)
+	} else if sr, ok := v.model.Node.Rule.Interface.(*system.StringRule); ok && sr.Long {
 		v.input = elem.TextArea(
 			contents,
 		)
@@ -123,3 +146,18 @@ func (v *StringEditorView) Render() vecty.Component {
 		v.input,
 	)
 }
+
+// options returns the values that can be selected: the members of an enum type, or the values in
+// the enum restriction of the rule.
+func (v *StringEditorView) options() []string {
+	n := v.model.Node
+	if n.Type != nil && n.Type.IsEnum() {
+		return n.Type.SortedMembers()
+	}
+	if n.Rule != nil {
+		if sr, ok := n.Rule.Interface.(*system.StringRule); ok {
+			return sr.Enum
+		}
+	}
+	return nil
+}
@@ -15,7 +15,7 @@ func TestAddMutationRoot(t *testing.T) {
 	cb, _ := data.Setup(t)
 	var a *node.Node
 	a = node.NewNode()
-	ty, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "multi")
+	ty, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "multi")
 	require.True(t, ok)
 	require.NoError(t, mutateAddNode(cb.Ctx(), a, nil, "", 2, ty, "z"))
 	require.Equal(t, "z", a.Value.(*data.Multi).Id.Name)
@@ -30,7 +30,7 @@ func TestAddMutationRedo(t *testing.T) {
 		a = node.NewNode()
 		p = n.Map["am"]
 		b = node.NewNode()
-		ty, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "multi")
+		ty, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "multi")
 		require.True(t, ok)
 		require.NoError(t, mutateAddNode(cb.Ctx(), a, p, "", 2, ty, ""))
 		require.Equal(t, `[{"type":"multi","js":"amjs0"},{"type":"multi","js":"amjs1"},{"type":"multi"}]`, p.Print(cb.Ctx()))
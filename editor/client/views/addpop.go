@@ -211,7 +211,11 @@ func (v *AddPopupView) save() {
 			v.App.Fail <- kerr.Wrap("SEMCIELKRN", err)
 			return
 		}
-		ty, ok := system.GetTypeFromCache(v.Ctx, r.Package, r.Name)
+		ty, ok, err := system.GetTypeFromCache(v.Ctx, r.Package, r.Name)
+		if err != nil {
+			v.App.Fail <- kerr.Wrap("UXBGTSFPAN", err)
+			return
+		}
 		if !ok {
 			v.App.Fail <- kerr.New("RWHSCOFNQM", "Type %s not found in cache", r.Value())
 			return
@@ -107,7 +107,7 @@ func loadBranch(ctx context.Context, app *stores.App, b *models.BranchModel, wai
 			return
 		}
 
-		n, err := node.Unmarshal(ctx, response.Data)
+		n, err := node.UnmarshalPositions(ctx, response.Data, response.Positions)
 		if err != nil {
 			app.Fail <- kerr.Wrap("IOOQWKIEGC", err)
 			return
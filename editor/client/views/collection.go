@@ -39,7 +39,11 @@ func addCollectionItem(app *stores.App, parent *node.Node) {
 		app.Fail <- kerr.Wrap("EWYOMNAQMU", err)
 	}
 
-	types := rw.PermittedTypes()
+	types, err := rw.PermittedTypes()
+	if err != nil {
+		app.Fail <- kerr.Wrap("OIMXSAWTEQ", err)
+		return
+	}
 
 	if len(types) == 1 && parent.Type.IsNativeArray() {
 		// if only one type is compatible and adding to an array, don't show the popup, just
@@ -2,6 +2,7 @@ package views
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/davelondon/vecty"
 	"github.com/davelondon/vecty/elem"
@@ -12,6 +13,7 @@ import (
 	"kego.io/editor/client/models"
 	"kego.io/editor/client/stores"
 	"kego.io/flux"
+	"kego.io/process/validate"
 	"kego.io/system"
 	"kego.io/system/node"
 )
@@ -171,10 +173,19 @@ func (v *EditorView) errorBlock() vecty.Markup {
 
 	errors := vecty.List{}
 	for _, e := range v.node.Errors {
-		errors = append(errors, elem.ListItem(vecty.Text(e.Description)))
+		errors = append(errors, elem.ListItem(vecty.Text(errorText(e))))
 	}
 	return elem.Paragraph(
 		prop.Class("help-block text-danger"),
 		elem.UnorderedList(errors),
 	)
 }
+
+// errorText prefixes the error message with the position of the node in the source file, if it's
+// known.
+func errorText(e validate.ValidationError) string {
+	if e.Source == nil || e.Source.Position.IsZero() {
+		return e.Description
+	}
+	return fmt.Sprintf("%s: %s", e.Source.Position, e.Description)
+}
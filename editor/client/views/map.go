@@ -129,7 +129,7 @@ func (v *MapView) errorBlock() vecty.Markup {
 
 	errors := vecty.List{}
 	for _, e := range v.node.Errors {
-		errors = append(errors, elem.ListItem(vecty.Text(e.Description)))
+		errors = append(errors, elem.ListItem(vecty.Text(errorText(e))))
 	}
 	return elem.Div(
 		prop.Class("has-error"),
@@ -111,7 +111,11 @@ func addNewFile(ctx context.Context, app *stores.App, all bool) {
 	var types []*system.Type
 	if all {
 		rt := reflect.TypeOf((*system.ObjectInterface)(nil)).Elem()
-		typesAll := system.GetAllTypesThatImplementReflectInterface(ctx, rt)
+		typesAll, err := system.GetAllTypesThatImplementReflectInterface(ctx, rt)
+		if err != nil {
+			app.Fail <- kerr.Wrap("HWGXRTMOUN", err)
+			return
+		}
 
 		// TODO: Work out a more elegant way of doing this!
 		rule := reflect.TypeOf((*system.RuleInterface)(nil)).Elem()
@@ -129,7 +133,11 @@ func addNewFile(ctx context.Context, app *stores.App, all bool) {
 
 	} else {
 		syscache := sysctx.FromContext(ctx)
-		t, ok := syscache.GetType("kego.io/system", "type")
+		t, ok, err := syscache.GetType("kego.io/system", "type")
+		if err != nil {
+			app.Fail <- kerr.Wrap("RLCYKEPVDI", err)
+			return
+		}
 		if !ok {
 			panic(kerr.New("NNFSJEXNKF", "Can't find system:type in sys ctx").Error())
 		}
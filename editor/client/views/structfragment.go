@@ -30,7 +30,12 @@ func NewStructFragmentView(ctx context.Context, node *node.Node, origin *system.
 	v.View = New(ctx, v)
 	v.model = v.App.Editors.Get(node)
 	v.origin = origin
-	if ti, ok := sysctx.FromContext(ctx).GetType(origin.Package, origin.Name); ok {
+	ti, ok, err := sysctx.FromContext(ctx).GetType(origin.Package, origin.Name)
+	if err != nil {
+		v.App.Fail <- kerr.Wrap("QKWBJRNEMT", err)
+		return nil
+	}
+	if ok {
 		v.originType = ti.(*system.Type)
 	} else {
 		v.App.Fail <- kerr.New("DNMXCJXNVU", "Type %s not found in system context", origin.String())
@@ -106,7 +111,11 @@ func (v *StructFragmentView) Render() vecty.Component {
 
 func nullEditor(ctx context.Context, n *node.Node, app *stores.App) *EditorView {
 	add := func(e *vecty.Event) {
-		types := n.Rule.PermittedTypes()
+		types, err := n.Rule.PermittedTypes()
+		if err != nil {
+			app.Fail <- kerr.Wrap("CVJOHSLDWA", err)
+			return
+		}
 		if len(types) == 1 {
 			// if only one type is compatible, don't show the
 			// popup, just add it.
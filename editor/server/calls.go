@@ -37,8 +37,11 @@ func (s *Server) Save(request *shared.SaveRequest, response *shared.SaveResponse
 	localContext := envctx.NewContext(s.ctx, pkg.Env)
 	for _, info := range request.Files {
 
+		// An object in a file that holds several objects has the index after the filename.
+		filename, index := scanner.SplitDocumentName(info.File)
+
 		// Check we only have yml, yaml or json extension.
-		ext := filepath.Ext(info.File)
+		ext := filepath.Ext(filename)
 		if ext != ".json" && ext != ".yml" && ext != ".yaml" {
 			return kerr.New("NDTPTCDOET", "Unsupported extension %s in %s", ext, info.File)
 		}
@@ -72,6 +75,15 @@ func (s *Server) Save(request *shared.SaveRequest, response *shared.SaveResponse
 		var full string
 
 		file := pkg.File(info.File)
+		if index >= 0 {
+			// Only the object is replaced, so the other objects in the file are kept.
+			if file == nil {
+				return kerr.New("FNQXWYBGOA", "Object %s not found", info.File)
+			}
+			if output, err = scanner.ReplaceDocument(file.AbsoluteFilepath, index, info.Bytes); err != nil {
+				return kerr.Wrap("LUHVBKGYSD", err)
+			}
+		}
 		if file != nil {
 			// The file already exists, so we should use the existing filemode
 			full = file.AbsoluteFilepath
@@ -112,12 +124,13 @@ func (s *Server) Data(request *shared.DataRequest, response *shared.DataResponse
 		return kerr.Wrap("PNAGGKHDYL", err)
 	}
 
-	full, err := pkghelp.Check(env.Dir, request.File, env.Recursive)
+	filename, index := scanner.SplitDocumentName(request.File)
+	full, err := pkghelp.Check(env.Dir, filename, env.Recursive)
 	if err != nil {
 		return kerr.Wrap("JEYTFWKMYF", err)
 	}
 
-	bytes, err := scanner.ProcessFile(full)
+	bytes, positions, err := scanner.ProcessDocument(scanner.DocumentName(full, index, index >= 0))
 	if err != nil {
 		return kerr.Wrap("HQXMIMWXFY", err)
 	}
@@ -140,5 +153,6 @@ func (s *Server) Data(request *shared.DataRequest, response *shared.DataResponse
 	response.Name = request.Name
 	response.Found = true
 	response.Data = bytes
+	response.Positions = positions
 	return nil
 }
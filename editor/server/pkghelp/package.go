@@ -28,7 +28,7 @@ type File struct {
 	Filename         string            // Filename e.g. baz.yaml
 	Extension        string            // Extension including dot e.g. .yaml
 	Yaml             bool              // Is the extension yml or yaml?
-	RelativeFilepath string            // Filename relative to the path root e.g. bar/baz.yaml
+	RelativeFilepath string            // Filename relative to the path root e.g. bar/baz.yaml, or bar/baz.yaml:2 in a file with several objects
 	AbsoluteFilepath string            // Absolute filepath e.g. /Users/foo/go/src/github.com/foo/bar/baz.yaml
 	Directory        string            // Absolute directory path e.g. /Users/foo/go/src/github.com/foo/bar/
 	IsInRoot         bool              // True if the file in the root of the package. False if it's in a subdir.
@@ -43,7 +43,7 @@ func Scan(ctx context.Context, path string) (*Package, error) {
 		return nil, kerr.Wrap("SFPSTLPKMX", err)
 	}
 	p.Env = env
-	files := scanner.ScanDirToFiles(ctx, env.Dir, env.Recursive)
+	files := scanner.ScanPackageToFiles(ctx, env)
 	bytes := scanner.ScanFilesToBytes(ctx, files)
 	localContext := envctx.NewContext(ctx, env)
 	for b := range bytes {
@@ -69,7 +69,7 @@ func Scan(ctx context.Context, path string) (*Package, error) {
 		f.AbsoluteFilepath = b.File
 		f.Type = o.Type
 		f.Id = o.Id
-		if f.RelativeFilepath, err = filepath.Rel(env.Dir, b.File); err != nil {
+		if f.RelativeFilepath, err = b.Name(env.Dir); err != nil {
 			return nil, kerr.Wrap("QDAEGOWTWP", err)
 		}
 		f.Directory, f.Filename = filepath.Split(b.File)
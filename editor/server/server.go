@@ -7,7 +7,6 @@ import (
 
 	"golang.org/x/net/websocket"
 
-	"github.com/davelondon/gopackages"
 	"github.com/davelondon/kerr"
 
 	"fmt"
@@ -36,6 +35,7 @@ import (
 	"kego.io/ke"
 	"kego.io/process"
 	"kego.io/process/generate"
+	"kego.io/process/packages"
 	"kego.io/process/parser"
 	"kego.io/system"
 )
@@ -215,7 +215,7 @@ func root(ctx context.Context, w http.ResponseWriter, req *http.Request, auth au
 
 	env, err := parser.ScanForEnv(ctx, path)
 	if err != nil {
-		if _, ok := kerr.Source(err).(gopackages.NotFoundError); ok {
+		if _, ok := kerr.Source(err).(packages.NotFoundError); ok {
 			w.WriteHeader(404)
 			return nil
 		}
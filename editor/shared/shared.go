@@ -2,6 +2,8 @@ package shared // import "kego.io/editor/shared"
 
 // ke: {"package": {"jstest": true}}
 
+import "kego.io/json"
+
 type Info struct {
 	Path            string                // Package path
 	Aliases         map[string]string     // Map of alias:path
@@ -64,8 +66,9 @@ type DataRequest struct {
 }
 
 type DataResponse struct {
-	Data    []byte
-	Found   bool
-	Name    string
-	Package string
+	Data      []byte
+	Positions *json.Positions // Source positions of the values in Data
+	Found     bool
+	Name      string
+	Package   string
 }
@@ -92,8 +92,7 @@ func UnmarshalUntyped(ctx context.Context, data []byte, v interface{}) error {
 	var d decodeState
 	err := checkValid(data, &d.scan)
 	if err != nil {
-		// ke: {"block": {"notest": true}}
-		return kerr.Wrap("BACKEQJSYF", err)
+		return kerr.Wrap("BACKEQJSYF", newLineIndex("", data).syntaxError(err))
 	}
 	d.init(ctx, data, false)
 	err = d.unmarshal(v)
@@ -119,6 +118,9 @@ func Unmarshal(ctx context.Context, data []byte, v *interface{}) error {
 	// before discovering a JSON syntax error.
 	var d decodeState
 	err := checkValid(data, &d.scan)
+	if err != nil {
+		err = newLineIndex("", data).syntaxError(err)
+	}
 	err = d.getError(err)
 	if err != nil {
 		// ke: {"block": {"notest": true}}
@@ -1143,6 +1143,8 @@ func (x byIndex) Less(i, j int) bool {
 
 type KegoTag struct {
 	Default *KegoDefault `json:"default,omitempty"`
+	// OneOf is the list of types that the field (or the items of a collection field) can have.
+	OneOf []string `json:"one-of,omitempty"`
 }
 type KegoDefault struct {
 	Type    string            `json:"type,omitempty"`
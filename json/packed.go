@@ -19,12 +19,19 @@ type packed struct {
 	v interface{}
 	a []Packed
 	m map[string]Packed
+	p *Positions
 }
 
 func Pack(v interface{}) *packed {
 	return &packed{v: v}
 }
 
+// PackPositions packs v with the source positions of the values in p. The packed values
+// implement Positioned.
+func PackPositions(v interface{}, p *Positions) *packed {
+	return &packed{v: v, p: p}
+}
+
 func PackString(s string) *packed {
 	var v interface{}
 	if err := json.Unmarshal([]byte(s), &v); err != nil {
@@ -34,6 +41,7 @@ func PackString(s string) *packed {
 }
 
 var _ Packed = (*packed)(nil)
+var _ Positioned = (*packed)(nil)
 
 func (j *packed) Type() Type {
 	if j == nil {
@@ -79,8 +87,8 @@ func (j *packed) Array() []Packed {
 		out := []Packed{}
 		in, ok := j.v.([]interface{})
 		if ok {
-			for _, v := range in {
-				out = append(out, Pack(v))
+			for i, v := range in {
+				out = append(out, PackPositions(v, j.p.Item(i)))
 			}
 		}
 		j.a = out
@@ -93,7 +101,7 @@ func (j *packed) Map() map[string]Packed {
 		in, ok := j.v.(map[string]interface{})
 		if ok {
 			for n, v := range in {
-				out[n] = Pack(v)
+				out[n] = PackPositions(v, j.p.Key(n))
 			}
 		}
 		j.m = out
@@ -103,3 +111,9 @@ func (j *packed) Map() map[string]Packed {
 func (j *packed) Interface() interface{} {
 	return j.v
 }
+func (j *packed) Position() Position {
+	if j == nil {
+		return Position{}
+	}
+	return j.p.Get()
+}
@@ -0,0 +1,268 @@
+package json
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/davelondon/kerr"
+)
+
+// Position is the location of a value in a source file. Line and Column start at 1. If Line is
+// zero the position is unknown.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsZero is true if the position is unknown.
+func (p Position) IsZero() bool {
+	return p.Line == 0
+}
+
+// String formats the position as file:line:col. Unknown parts are omitted.
+func (p Position) String() string {
+	switch {
+	case p.Line == 0 && p.File == "":
+		return "-"
+	case p.Line == 0:
+		return p.File
+	case p.File == "":
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Positions is a tree of source positions with the same shape as the json value it describes.
+// Array has an item for each array item, and Map has an item for each object or map key.
+type Positions struct {
+	Position
+	Array []*Positions
+	Map   map[string]*Positions
+}
+
+// Item returns the positions of the array item at index i, or nil if it's not known.
+func (p *Positions) Item(i int) *Positions {
+	if p == nil || i < 0 || i >= len(p.Array) {
+		return nil
+	}
+	return p.Array[i]
+}
+
+// Key returns the positions of the object or map value at key, or nil if it's not known.
+func (p *Positions) Key(key string) *Positions {
+	if p == nil {
+		return nil
+	}
+	return p.Map[key]
+}
+
+// Get returns the position of the value, or the zero position if p is nil.
+func (p *Positions) Get() Position {
+	if p == nil {
+		return Position{}
+	}
+	return p.Position
+}
+
+// Positioned is implemented by packed values that know their position in the source file.
+type Positioned interface {
+	Position() Position
+}
+
+// PositionError is returned when a value can't be decoded. It wraps the original error, and the
+// message is prefixed with the position of the value.
+type PositionError struct {
+	kerr.Struct
+	Position Position
+}
+
+// NewPositionError wraps err in a PositionError at position p.
+func NewPositionError(id string, p Position, err error) PositionError {
+	return PositionError{
+		Struct:   kerr.Wrap(id, err),
+		Position: p,
+	}
+}
+
+func (e PositionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Struct.Error())
+}
+
+// AsPositionError returns the first PositionError in the chain of wrapped errors.
+func AsPositionError(err error) (PositionError, bool) {
+	for err != nil {
+		switch e := err.(type) {
+		case PositionError:
+			return e, true
+		case kerr.Struct:
+			err = e.Inner
+		default:
+			return PositionError{}, false
+		}
+	}
+	return PositionError{}, false
+}
+
+// ScanPositions returns the position of every value in the json encoded data. If the data is
+// not valid json, a PositionError with the location of the syntax error is returned.
+func ScanPositions(file string, data []byte) (positions *Positions, err error) {
+	var d decodeState
+	lines := newLineIndex(file, data)
+	if err := checkValid(data, &d.scan); err != nil {
+		return nil, kerr.Wrap("UOKTBGXCMS", lines.syntaxError(err))
+	}
+	d.init(nil, data, false)
+	d.scan.reset()
+	defer func() {
+		if r := recover(); r != nil {
+			// ke: {"block": {"notest": true}}
+			e, ok := r.(error)
+			if !ok {
+				panic(r)
+			}
+			err = kerr.Wrap("XQCXFMBBNT", e)
+		}
+	}()
+	return d.valuePositions(lines), nil
+}
+
+// valuePositions is like valueInterface but returns the positions of the values.
+func (d *decodeState) valuePositions(lines *lineIndex) *Positions {
+	switch d.scanWhile(scanSkipSpace) {
+	default:
+		// ke: {"block": {"notest": true}}
+		d.error(errPhase)
+		panic("unreachable")
+	case scanBeginArray:
+		p := &Positions{Position: lines.position(d.off - 1), Array: []*Positions{}}
+		d.arrayPositions(p, lines)
+		return p
+	case scanBeginObject:
+		p := &Positions{Position: lines.position(d.off - 1), Map: map[string]*Positions{}}
+		d.objectPositions(p, lines)
+		return p
+	case scanBeginLiteral:
+		p := &Positions{Position: lines.position(d.off - 1)}
+		op := d.scanWhile(scanContinue)
+		// Scan read one byte too far; back up.
+		d.off--
+		d.scan.undo(op)
+		return p
+	}
+}
+
+// arrayPositions is like arrayInterface but adds the positions of the items to p.
+func (d *decodeState) arrayPositions(p *Positions, lines *lineIndex) {
+	for {
+		// Look ahead for ] - can only happen on first iteration.
+		op := d.scanWhile(scanSkipSpace)
+		if op == scanEndArray {
+			break
+		}
+
+		// Back up so d.valuePositions can have the byte we just read.
+		d.off--
+		d.scan.undo(op)
+
+		p.Array = append(p.Array, d.valuePositions(lines))
+
+		// Next token must be , or ].
+		op = d.scanWhile(scanSkipSpace)
+		if op == scanEndArray {
+			break
+		}
+		if op != scanArrayValue {
+			// ke: {"block": {"notest": true}}
+			d.error(errPhase)
+		}
+	}
+}
+
+// objectPositions is like objectInterface but adds the positions of the values to p.
+func (d *decodeState) objectPositions(p *Positions, lines *lineIndex) {
+	for {
+		// Read opening " of string key or closing }.
+		op := d.scanWhile(scanSkipSpace)
+		if op == scanEndObject {
+			// closing } - can only happen on first iteration.
+			break
+		}
+		if op != scanBeginLiteral {
+			// ke: {"block": {"notest": true}}
+			d.error(errPhase)
+		}
+
+		// Read string key.
+		start := d.off - 1
+		op = d.scanWhile(scanContinue)
+		item := d.data[start : d.off-1]
+		key, ok := unquote(item)
+		if !ok {
+			// ke: {"block": {"notest": true}}
+			d.error(errPhase)
+		}
+
+		// Read : before value.
+		if op == scanSkipSpace {
+			op = d.scanWhile(scanSkipSpace)
+		}
+		if op != scanObjectKey {
+			// ke: {"block": {"notest": true}}
+			d.error(errPhase)
+		}
+
+		// Read value.
+		p.Map[key] = d.valuePositions(lines)
+
+		// Next token must be , or }.
+		op = d.scanWhile(scanSkipSpace)
+		if op == scanEndObject {
+			break
+		}
+		if op != scanObjectValue {
+			// ke: {"block": {"notest": true}}
+			d.error(errPhase)
+		}
+	}
+}
+
+// lineIndex converts byte offsets to line and column positions.
+type lineIndex struct {
+	file  string
+	data  []byte
+	lines []int // offset of the start of each line
+}
+
+func newLineIndex(file string, data []byte) *lineIndex {
+	l := &lineIndex{file: file, data: data, lines: []int{0}}
+	for i, c := range data {
+		if c == '\n' {
+			l.lines = append(l.lines, i+1)
+		}
+	}
+	return l
+}
+
+// syntaxError adds the position of a syntax error to the description.
+func (l *lineIndex) syntaxError(err error) error {
+	if se, ok := err.(*SyntaxError); ok {
+		return NewPositionError("DNWJYQLKAW", l.position(int(se.Offset)-1), err)
+	}
+	// ke: {"block": {"notest": true}}
+	return err
+}
+
+func (l *lineIndex) position(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(l.data) {
+		offset = len(l.data)
+	}
+	// line is the index of the last line that starts at or before offset
+	line := sort.Search(len(l.lines), func(i int) bool { return l.lines[i] > offset }) - 1
+	column := utf8.RuneCount(l.data[l.lines[line]:offset]) + 1
+	return Position{File: l.file, Line: line + 1, Column: column}
+}
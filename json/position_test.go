@@ -0,0 +1,67 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+)
+
+func TestPositionString(t *testing.T) {
+	assert.Equal(t, "-", Position{}.String())
+	assert.Equal(t, "a.json", Position{File: "a.json"}.String())
+	assert.Equal(t, "2:3", Position{Line: 2, Column: 3}.String())
+	assert.Equal(t, "a.json:2:3", Position{File: "a.json", Line: 2, Column: 3}.String())
+	assert.True(t, Position{File: "a.json"}.IsZero())
+	assert.False(t, Position{Line: 1}.IsZero())
+}
+
+func TestScanPositions(t *testing.T) {
+	p, err := ScanPositions("a.json", []byte(`{
+	"a": "b",
+	"c": [1, {"d": true}],
+	"é": null
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, Position{File: "a.json", Line: 1, Column: 1}, p.Position)
+	assert.Equal(t, Position{File: "a.json", Line: 2, Column: 7}, p.Key("a").Get())
+	assert.Equal(t, Position{File: "a.json", Line: 3, Column: 7}, p.Key("c").Get())
+	assert.Equal(t, Position{File: "a.json", Line: 3, Column: 8}, p.Key("c").Item(0).Get())
+	assert.Equal(t, Position{File: "a.json", Line: 3, Column: 17}, p.Key("c").Item(1).Key("d").Get())
+	assert.Equal(t, Position{File: "a.json", Line: 4, Column: 7}, p.Key("é").Get())
+	assert.Nil(t, p.Key("e"))
+	assert.Nil(t, p.Key("c").Item(2))
+	assert.Equal(t, Position{}, p.Key("e").Get())
+
+	p, err = ScanPositions("", []byte(` "a" `))
+	require.NoError(t, err)
+	assert.Equal(t, Position{Line: 1, Column: 2}, p.Position)
+
+	_, err = ScanPositions("a.json", []byte("{\n\t\"a\": \"b\"\n\t\"c\": 1\n}"))
+	assert.HasError(t, err, "DNWJYQLKAW")
+	pe, ok := AsPositionError(err)
+	require.True(t, ok)
+	assert.Equal(t, Position{File: "a.json", Line: 3, Column: 2}, pe.Position)
+}
+
+func TestPackPositions(t *testing.T) {
+	var v interface{}
+	require.NoError(t, UnmarshalPlain([]byte(`{"a": ["b"]}`), &v))
+	p, err := ScanPositions("", []byte(`{"a": ["b"]}`))
+	require.NoError(t, err)
+	packed := PackPositions(v, p)
+	assert.Equal(t, Position{Line: 1, Column: 1}, packed.Position())
+	a := packed.Map()["a"].(Positioned)
+	assert.Equal(t, Position{Line: 1, Column: 7}, a.Position())
+	b := packed.Map()["a"].Array()[0].(Positioned)
+	assert.Equal(t, Position{Line: 1, Column: 8}, b.Position())
+	assert.Equal(t, Position{}, Pack("c").Position())
+}
+
+func TestUnmarshalSyntaxPosition(t *testing.T) {
+	var v interface{}
+	err := UnmarshalUntyped(nil, []byte("{\n\"a\": }"), &v)
+	assert.HasError(t, err, "DNWJYQLKAW")
+	err = Unmarshal(nil, []byte("{\n\"a\": }"), &v)
+	assert.HasError(t, err, "DNWJYQLKAW")
+}
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/json/systests","Hash":9500717051976719187}
+// info:{"Path":"kego.io/json/systests","Hash":4039951669491598187}
 package systests
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for a
@@ -32,6 +30,6 @@ func (o *A) GetA(ctx context.Context) *A {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/json/systests", 9500717051976719187)
+	pkg := jsonctx.InitPackage("kego.io/json/systests", 4039951669491598187)
 	pkg.InitType("a", reflect.TypeOf((*A)(nil)), reflect.TypeOf((*ARule)(nil)), reflect.TypeOf((*AInterface)(nil)).Elem())
 }
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/json/systests/sub","Hash":7354942973798892629}
+// info:{"Path":"kego.io/json/systests/sub","Hash":3805891381680551813}
 package sub
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for a
@@ -25,6 +23,6 @@ func (o *A) GetA(ctx context.Context) *A {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/json/systests/sub", 7354942973798892629)
+	pkg := jsonctx.InitPackage("kego.io/json/systests/sub", 3805891381680551813)
 	pkg.InitType("a", reflect.TypeOf((*A)(nil)), reflect.TypeOf((*ARule)(nil)), reflect.TypeOf((*AInterface)(nil)).Elem())
 }
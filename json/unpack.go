@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"context"
 
@@ -13,8 +14,9 @@ import (
 )
 
 type unpackStruct struct {
-	unknownType    string // have we encountered an unknown type?
-	unknownPackage string // have we encountered an unknown package?
+	unknownType    string   // have we encountered an unknown type?
+	unknownPackage string   // have we encountered an unknown package?
+	position       Position // the source position of the value that failed to unpack, if known
 }
 
 func Unpack(ctx context.Context, in Packed, out *interface{}) error {
@@ -74,12 +76,26 @@ func (us *unpackStruct) unpackFragment(ctx context.Context, in Packed, out *inte
 		}
 	}
 	if err != nil {
+		if !us.position.IsZero() {
+			return NewPositionError("VFSJBSCGVJ", us.position, err)
+		}
 		return kerr.Wrap("FPPKQJMBNA", err)
 	}
 
 	return nil
 }
 
+// setPosition records the position of a value that failed to unpack. The innermost value fails
+// first, so the position is only recorded once.
+func (us *unpackStruct) setPosition(in Packed) {
+	if !us.position.IsZero() {
+		return
+	}
+	if p, ok := in.(Positioned); ok {
+		us.position = p.Position()
+	}
+}
+
 func (us *unpackStruct) unpack(ctx context.Context, in Packed, v reflect.Value) error {
 
 	if !v.IsValid() {
@@ -94,14 +110,17 @@ func (us *unpackStruct) unpack(ctx context.Context, in Packed, v reflect.Value)
 	switch typ {
 	case J_MAP:
 		if err := us.unpackObject(ctx, in, v); err != nil {
+			us.setPosition(in)
 			return kerr.Wrap("LMLUICBTBA", err)
 		}
 	case J_ARRAY:
 		if err := us.unpackArray(ctx, in, v); err != nil {
+			us.setPosition(in)
 			return kerr.Wrap("ITJMJWULKO", err)
 		}
 	default:
 		if err := us.unpackLiteral(ctx, in, v); err != nil {
+			us.setPosition(in)
 			return kerr.Wrap("BSTNWUKLYO", err)
 		}
 	}
@@ -423,6 +442,14 @@ func (us *unpackStruct) unpackObject(ctx context.Context, in Packed, v reflect.V
 					subv = subv.Field(i)
 				}
 				foundFields = append(foundFields, *f)
+				// The one-of list is checked here rather than in getTypeFromField, because
+				// getTypeFromField is also used for values that aren't in a field (e.g. the root
+				// object and the items of collections), so it has no tag to check against.
+				if f.kego != nil && len(f.kego.OneOf) > 0 {
+					if err := us.checkOneOf(ctx, val, f.typ, f.kego.OneOf); err != nil {
+						return kerr.Wrap("ZTZHVGQNUV", err)
+					}
+				}
 			}
 		}
 
@@ -479,6 +506,59 @@ func (us *unpackStruct) getTypeFromField(ctx context.Context, in Packed, iface r
 	return us.getType(ctx, typePath, typeName, iface), nil
 }
 
+// checkOneOf returns an error if the type of the value isn't in the one-of list of the field. For
+// collection fields, the list applies to the items. The list in the tag is made from the one-of
+// lists of the collection rule and its items rule (see RuleWrapper.ItemsRule), so both are checked.
+func (us *unpackStruct) checkOneOf(ctx context.Context, in Packed, typ reflect.Type, oneOf []string) error {
+	if in == nil {
+		return nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch in.Type() {
+	case J_ARRAY:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return nil
+		}
+		for _, item := range in.Array() {
+			if err := us.checkOneOf(ctx, item, typ.Elem(), oneOf); err != nil {
+				return kerr.Wrap("QTUFKBLDMB", err)
+			}
+		}
+		return nil
+	case J_MAP:
+		if typ.Kind() == reflect.Map {
+			for _, item := range in.Map() {
+				if err := us.checkOneOf(ctx, item, typ.Elem(), oneOf); err != nil {
+					return kerr.Wrap("HHWXHJTDVH", err)
+				}
+			}
+			return nil
+		}
+	default:
+		return nil
+	}
+	t, ok := in.Map()["type"]
+	if !ok || t.Type() != J_STRING {
+		// If the type is omitted, the value has the type of the field.
+		return nil
+	}
+	typePath, typeName, err := GetReferencePartsFromTypeString(ctx, t.String())
+	if err != nil {
+		// Unknown packages are tolerated in getTypeFromField.
+		return nil
+	}
+	value := typePath + ":" + typeName
+	for _, o := range oneOf {
+		if o == value {
+			return nil
+		}
+	}
+	us.setPosition(in)
+	return kerr.New("CNEFWADBMU", "Type %s is not one of %s", value, strings.Join(oneOf, ", "))
+}
+
 func (us *unpackStruct) getType(ctx context.Context, typePath string, typeName string, iface reflect.Value) reflect.Type {
 	jcache := jsonctx.FromContext(ctx)
 	typ, ok := jcache.GetType(typePath, typeName)
@@ -71,6 +71,43 @@ func TestUnpackObject(t *testing.T) {
 
 }
 
+type unpackOneOf struct {
+	A interface{}            `kego:"{\"one-of\":[\"a.b/c:d\"]}"`
+	B []interface{}          `kego:"{\"one-of\":[\"a.b/c:d\"]}"`
+	C map[string]interface{} `kego:"{\"one-of\":[\"a.b/c:d\", \"a.b/c:e\"]}"`
+}
+
+func TestUnpackOneOf(t *testing.T) {
+	cb := tests.Context("a.b/c").Jempty()
+	us := &unpackStruct{}
+
+	in := &packed{v: map[string]interface{}{
+		"A": map[string]interface{}{"type": "d"},
+		"B": []interface{}{map[string]interface{}{"type": "a.b/c:d"}, nil},
+		"C": map[string]interface{}{"x": map[string]interface{}{"type": "e"}, "y": map[string]interface{}{"type": "d"}},
+	}}
+	var v1 unpackOneOf
+	err := us.unpackObject(cb.Ctx(), in, reflect.ValueOf(&v1))
+	assert.NoError(t, err)
+
+	in = &packed{v: map[string]interface{}{"A": map[string]interface{}{"type": "e"}}}
+	err = us.unpackObject(cb.Ctx(), in, reflect.ValueOf(&v1))
+	assert.IsError(t, err, "ZTZHVGQNUV")
+	assert.HasError(t, err, "CNEFWADBMU")
+	assert.Contains(t, err.Error(), "Type a.b/c:e is not one of a.b/c:d")
+
+	in = &packed{v: map[string]interface{}{"B": []interface{}{map[string]interface{}{"type": "e"}}}}
+	err = us.unpackObject(cb.Ctx(), in, reflect.ValueOf(&v1))
+	assert.IsError(t, err, "ZTZHVGQNUV")
+	assert.HasError(t, err, "QTUFKBLDMB")
+
+	in = &packed{v: map[string]interface{}{"C": map[string]interface{}{"x": map[string]interface{}{"type": "f"}}}}
+	err = us.unpackObject(cb.Ctx(), in, reflect.ValueOf(&v1))
+	assert.IsError(t, err, "ZTZHVGQNUV")
+	assert.HasError(t, err, "HHWXHJTDVH")
+	assert.Contains(t, err.Error(), "Type a.b/c:f is not one of a.b/c:d, a.b/c:e")
+}
+
 func TestUnpackArray(t *testing.T) {
 	cb := tests.New()
 	us := &unpackStruct{}
@@ -181,6 +181,10 @@ func (v *visitor) Visit(node ast.Node) (w ast.Visitor) {
 	case *ast.CallExpr:
 		name := ""
 		pkg := ""
+		if f, ok := ty.Fun.(*ast.Ident); ok && f.Name == "NewPositionError" {
+			// NewPositionError wraps the error with a kerr id, so we treat it like kerr.Wrap.
+			v.thrown(ty.Args, f.Pos(), false)
+		}
 		if f, ok := ty.Fun.(*ast.SelectorExpr); ok {
 			name = f.Sel.Name
 			if i, ok := f.X.(*ast.Ident); ok {
@@ -190,16 +194,9 @@ func (v *visitor) Visit(node ast.Node) (w ast.Visitor) {
 				return v
 			}
 			if pkg == v.kerrName && (name == "New" || name == "Wrap") {
-				def := getErrData(v.t, ty.Args, 0, v.filePath, v.fset.Position(f.Pos()))
-				if def == nil {
-					return v
-				}
-				if def.thrown {
-					assert.Fail(v.t, fmt.Sprint("Duplicate kerr id: ", def.id))
-				}
-				def.pkg = v.pkg
-				def.new = name == "New"
-				def.thrown = true
+				v.thrown(ty.Args, f.Pos(), name == "New")
+			} else if pkg == "json" && name == "NewPositionError" {
+				v.thrown(ty.Args, f.Pos(), false)
 			} else if pkg == v.assertName && (name == "IsError" || name == "HasError") {
 				def := getErrData(v.t, ty.Args, 2, v.filePath, v.fset.Position(f.Pos()))
 				if def == nil {
@@ -219,6 +216,19 @@ func (v *visitor) Visit(node ast.Node) (w ast.Visitor) {
 	return v
 }
 
+func (v *visitor) thrown(args []ast.Expr, pos token.Pos, new bool) {
+	def := getErrData(v.t, args, 0, v.filePath, v.fset.Position(pos))
+	if def == nil {
+		return
+	}
+	if def.thrown {
+		assert.Fail(v.t, fmt.Sprint("Duplicate kerr id: ", def.id))
+	}
+	def.pkg = v.pkg
+	def.new = new
+	def.thrown = true
+}
+
 func getErrData(t *testing.T, args []ast.Expr, arg int, file string, pos token.Position) *errDef {
 	require.True(t, len(args) > arg, "Not enough args (%s:%d)", file, pos.Line)
 	b, ok := args[arg].(*ast.BasicLit)
@@ -0,0 +1,230 @@
+package process
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+)
+
+// Command is a ke sub-command, e.g. "ke generate". Each command has its own flags and help
+// text, and builds the Options used to initialise the context. Flags and arguments that only
+// one command uses are stored in the flags struct of the command, e.g. RenameFlags.
+type Command struct {
+	// Name is the word used to invoke the command.
+	Name string
+	// Usage summarises the arguments, e.g. "[flags] [package]".
+	Usage string
+	// Short is the one line description shown in the list of commands.
+	Short string
+	// Long is the help text shown by "ke help <command>".
+	Long string
+	// Flags registers the command flags, storing the values in o.
+	Flags func(fs *flag.FlagSet, o *Options)
+	// Args stores the positional arguments in o. If nil, a single optional package path is
+	// permitted.
+	Args func(o *Options, args []string) error
+	// Run executes the command.
+	Run func(ctx context.Context, o *Options) error
+}
+
+// UsageError is returned by ParseCommand when the command line can't be parsed. The usage
+// information has already been written to the output.
+type UsageError struct {
+	kerr.Struct
+}
+
+// CommonFlags registers the flags that are shared by all commands that parse a package.
+func CommonFlags(fs *flag.FlagSet, o *Options) {
+	fs.BoolVar(&o.Log, "l", false, "Log: print progress messages")
+	fs.BoolVar(&o.Update, "u", false, "Update: update all import packages e.g. go get -u")
+	fs.IntVar(&o.Workers, "j", 0, "Jobs: the number of packages to parse or generate at the same time. Default: the number of CPUs")
+}
+
+// PathArgs accepts a single optional package path. If it's omitted, the package in the current
+// directory is used.
+func PathArgs(o *Options, args []string) error {
+	if len(args) > 1 {
+		return kerr.New("KHTYOPDUVW", "Too many arguments: %s", strings.Join(args, " "))
+	}
+	if len(args) == 1 {
+		o.Path = args[0]
+	}
+	return nil
+}
+
+// QueryFlags holds the arguments of the query command.
+type QueryFlags struct {
+	Selector string // Selector that the nodes are matched with
+}
+
+// Args accepts a selector followed by an optional package path.
+func (q *QueryFlags) Args(o *Options, args []string) error {
+	if len(args) == 0 {
+		return kerr.New("FWPNUAJYKE", "No selector specified")
+	}
+	q.Selector = args[0]
+	return PathArgs(o, args[1:])
+}
+
+// DiffFlags holds the arguments of the diff command.
+type DiffFlags struct {
+	Base string // Base git revision or directory
+	Dir  string // Dir is the package directory. Default: the current directory
+}
+
+// Args accepts a git revision or directory, followed by an optional package directory.
+func (d *DiffFlags) Args(o *Options, args []string) error {
+	if len(args) == 0 {
+		return kerr.New("SMQYBTFVAE", "No revision or directory specified")
+	}
+	if len(args) > 2 {
+		return kerr.New("HVBWOCEPGI", "Too many arguments: %s", strings.Join(args, " "))
+	}
+	d.Base = args[0]
+	d.Dir = ""
+	if len(args) == 2 {
+		d.Dir = args[1]
+	}
+	return nil
+}
+
+// RenameFlags holds the arguments of the rename command.
+type RenameFlags struct {
+	Target string // Target is the type or global to rename, e.g. alias:name
+	Name   string // Name is the new name
+}
+
+// Args accepts the type or global to rename and the new name, followed by an optional package
+// path.
+func (r *RenameFlags) Args(o *Options, args []string) error {
+	if len(args) < 2 {
+		return kerr.New("QGTWHBEKVA", "No type or new name specified")
+	}
+	r.Target = args[0]
+	r.Name = args[1]
+	return PathArgs(o, args[2:])
+}
+
+// GraphFlags holds the flags of the graph command.
+type GraphFlags struct {
+	Packages string // Packages is a comma separated list of packages to show the edges from
+	Types    string // Types is a comma separated list of types to show the edges to or from
+}
+
+// Flags registers the common flags, the format flag and the graph flags.
+func (g *GraphFlags) Flags(fs *flag.FlagSet, o *Options) {
+	CommonFlags(fs, o)
+	fs.StringVar(&o.Format, "format", "", "Format: write the graph in this format: dot or json")
+	fs.StringVar(&g.Packages, "packages", "", "Packages: comma separated package paths to show the edges from")
+	fs.StringVar(&g.Types, "types", "", "Types: comma separated types to show the edges to or from, e.g. site:page")
+}
+
+// ParseCommand finds the command named by the first argument, and parses the remaining
+// arguments with the command flags. If help is requested, the help text is written to output
+// and flag.ErrHelp is returned.
+func ParseCommand(commands []*Command, args []string, output io.Writer) (*Command, *Options, error) {
+
+	if len(args) == 0 {
+		PrintCommands(commands, output)
+		return nil, nil, UsageError{Struct: kerr.New("XOHBNGBVGS", "No command specified")}
+	}
+
+	name := args[0]
+
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) < 2 || name != "help" {
+			PrintCommands(commands, output)
+			return nil, nil, flag.ErrHelp
+		}
+		c := findCommand(commands, args[1])
+		if c == nil {
+			PrintCommands(commands, output)
+			return nil, nil, UsageError{Struct: kerr.New("JMQEWJNYRP", "Unknown command %s", args[1])}
+		}
+		c.flagSet(&Options{}, output).Usage()
+		return nil, nil, flag.ErrHelp
+	}
+
+	c := findCommand(commands, name)
+	if c == nil {
+		PrintCommands(commands, output)
+		return nil, nil, UsageError{Struct: kerr.New("RBYLWXOBEC", "Unknown command %s", name)}
+	}
+
+	o := &Options{}
+	fs := c.flagSet(o, output)
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return nil, nil, err
+		}
+		return nil, nil, UsageError{Struct: kerr.New("QFJWYDGHBS", "%s", err.Error())}
+	}
+
+	parseArgs := c.Args
+	if parseArgs == nil {
+		parseArgs = PathArgs
+	}
+	if err := parseArgs(o, fs.Args()); err != nil {
+		fmt.Fprintln(output, err.Error())
+		fs.Usage()
+		return nil, nil, UsageError{Struct: kerr.New("NAQFTQYBSV", "%s", err.Error())}
+	}
+
+	return c, o, nil
+}
+
+// PrintCommands writes the list of commands to output.
+func PrintCommands(commands []*Command, output io.Writer) {
+	fmt.Fprintln(output, "Usage: ke <command> [flags] [arguments]")
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Commands:")
+	width := 0
+	for _, c := range commands {
+		if len(c.Name) > width {
+			width = len(c.Name)
+		}
+	}
+	for _, c := range commands {
+		fmt.Fprintf(output, "  %-*s  %s\n", width, c.Name, c.Short)
+	}
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, `Use "ke help <command>" for more information about a command.`)
+}
+
+func findCommand(commands []*Command, name string) *Command {
+	for _, c := range commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (c *Command) flagSet(o *Options, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	if c.Flags != nil {
+		c.Flags(fs, o)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage: ke %s %s\n", c.Name, c.Usage)
+		if c.Long != "" {
+			fmt.Fprintln(output)
+			fmt.Fprintln(output, c.Long)
+		}
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(output)
+			fmt.Fprintln(output, "Flags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
@@ -0,0 +1,167 @@
+package process
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"context"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+)
+
+func testCommands() []*Command {
+	return []*Command{
+		{
+			Name:  "a",
+			Usage: "[flags] [package]",
+			Short: "short a",
+			Long:  "long a",
+			Flags: CommonFlags,
+			Run:   func(context.Context, *Options) error { return nil },
+		},
+		{
+			Name:  "bb",
+			Usage: "[flags] <selector>",
+			Short: "short bb",
+			Flags: func(fs *flag.FlagSet, o *Options) {
+				fs.IntVar(&o.Port, "p", 0, "Port")
+			},
+			Args: func(o *Options, args []string) error {
+				o.Path = "foo/" + args[0]
+				return nil
+			},
+		},
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	c, o, err := ParseCommand(testCommands(), []string{"a", "-l", "-u", "b.c/d"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, "a", c.Name)
+	assert.True(t, o.Log)
+	assert.True(t, o.Update)
+	assert.Equal(t, "b.c/d", o.Path)
+	assert.Equal(t, "", out.String())
+
+	c, o, err = ParseCommand(testCommands(), []string{"a"}, out)
+	require.NoError(t, err)
+	assert.False(t, o.Log)
+	assert.Equal(t, "", o.Path)
+
+	c, o, err = ParseCommand(testCommands(), []string{"bb", "-p", "2", "e"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, "bb", c.Name)
+	assert.Equal(t, 2, o.Port)
+	assert.Equal(t, "foo/e", o.Path)
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	out := &bytes.Buffer{}
+	_, _, err := ParseCommand(testCommands(), []string{}, out)
+	assert.IsError(t, err, "XOHBNGBVGS")
+	assert.Contains(t, out.String(), "  a   short a\n  bb  short bb\n")
+
+	out.Reset()
+	_, _, err = ParseCommand(testCommands(), []string{"c"}, out)
+	assert.IsError(t, err, "RBYLWXOBEC")
+	assert.Contains(t, out.String(), "Usage: ke <command>")
+
+	out.Reset()
+	_, _, err = ParseCommand(testCommands(), []string{"a", "-p", "2"}, out)
+	assert.IsError(t, err, "QFJWYDGHBS")
+	assert.Contains(t, out.String(), "Usage: ke a [flags] [package]")
+
+	out.Reset()
+	_, _, err = ParseCommand(testCommands(), []string{"a", "b", "c"}, out)
+	assert.IsError(t, err, "NAQFTQYBSV")
+	assert.Contains(t, out.String(), "Too many arguments: b c")
+
+	out.Reset()
+	_, _, err = ParseCommand(testCommands(), []string{"help", "d"}, out)
+	assert.IsError(t, err, "JMQEWJNYRP")
+}
+
+func TestParseCommandHelp(t *testing.T) {
+	out := &bytes.Buffer{}
+	_, _, err := ParseCommand(testCommands(), []string{"help"}, out)
+	assert.Equal(t, flag.ErrHelp, err)
+	assert.Contains(t, out.String(), "Commands:")
+
+	out.Reset()
+	_, _, err = ParseCommand(testCommands(), []string{"help", "a"}, out)
+	assert.Equal(t, flag.ErrHelp, err)
+	assert.Contains(t, out.String(), "Usage: ke a [flags] [package]\n\nlong a\n\nFlags:\n")
+	assert.Contains(t, out.String(), "Log: print progress messages")
+
+	out.Reset()
+	_, _, err = ParseCommand(testCommands(), []string{"bb", "-h"}, out)
+	assert.Equal(t, flag.ErrHelp, err)
+	assert.Contains(t, out.String(), "Usage: ke bb [flags] <selector>")
+}
+
+func TestQueryFlags(t *testing.T) {
+	q := &QueryFlags{}
+	o := &Options{}
+	require.NoError(t, q.Args(o, []string{".a", "b.c/d"}))
+	assert.Equal(t, ".a", q.Selector)
+	assert.Equal(t, "b.c/d", o.Path)
+
+	o = &Options{}
+	require.NoError(t, q.Args(o, []string{".b"}))
+	assert.Equal(t, ".b", q.Selector)
+	assert.Equal(t, "", o.Path)
+
+	err := q.Args(&Options{}, []string{})
+	assert.IsError(t, err, "FWPNUAJYKE")
+
+	err = q.Args(&Options{}, []string{".a", "b", "c"})
+	assert.IsError(t, err, "KHTYOPDUVW")
+}
+
+func TestDiffFlags(t *testing.T) {
+	d := &DiffFlags{}
+	require.NoError(t, d.Args(&Options{}, []string{"HEAD", "a/b"}))
+	assert.Equal(t, "HEAD", d.Base)
+	assert.Equal(t, "a/b", d.Dir)
+
+	// The flags of an earlier command line are replaced.
+	require.NoError(t, d.Args(&Options{}, []string{"HEAD~1"}))
+	assert.Equal(t, "HEAD~1", d.Base)
+	assert.Equal(t, "", d.Dir)
+
+	err := d.Args(&Options{}, []string{})
+	assert.IsError(t, err, "SMQYBTFVAE")
+
+	err = d.Args(&Options{}, []string{"a", "b", "c"})
+	assert.IsError(t, err, "HVBWOCEPGI")
+}
+
+func TestRenameFlags(t *testing.T) {
+	r := &RenameFlags{}
+	o := &Options{}
+	require.NoError(t, r.Args(o, []string{"a:b", "c", "d.e/f"}))
+	assert.Equal(t, "a:b", r.Target)
+	assert.Equal(t, "c", r.Name)
+	assert.Equal(t, "d.e/f", o.Path)
+
+	err := r.Args(&Options{}, []string{"a:b"})
+	assert.IsError(t, err, "QGTWHBEKVA")
+
+	err = r.Args(&Options{}, []string{"a", "b", "c", "d"})
+	assert.IsError(t, err, "KHTYOPDUVW")
+}
+
+func TestGraphFlags(t *testing.T) {
+	g := &GraphFlags{}
+	commands := []*Command{{Name: "graph", Flags: g.Flags}}
+	_, o, err := ParseCommand(commands, []string{"graph", "-format", "json", "-packages", "a,b", "-types", "c:d", "e"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "json", o.Format)
+	assert.Equal(t, "e", o.Path)
+	assert.Equal(t, "a,b", g.Packages)
+	assert.Equal(t, "c:d", g.Types)
+}
@@ -0,0 +1,182 @@
+package dag // import "kego.io/process/dag"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/davelondon/kerr"
+)
+
+// Graph is a directed acyclic graph of nodes (e.g. package paths) and the nodes each one
+// depends on.
+type Graph struct {
+	nodes []string
+	deps  map[string][]string
+}
+
+func New() *Graph {
+	return &Graph{deps: map[string][]string{}}
+}
+
+// Add adds a node and the nodes it depends on. Dependencies that are never added as nodes are
+// treated as already done.
+func (g *Graph) Add(node string, deps ...string) {
+	if _, found := g.deps[node]; !found {
+		g.nodes = append(g.nodes, node)
+	}
+	g.deps[node] = deps
+}
+
+// Has is true if the node has been added.
+func (g *Graph) Has(node string) bool {
+	_, found := g.deps[node]
+	return found
+}
+
+// Order returns the nodes so that each node is after the nodes it depends on. The order is
+// deterministic: the nodes are visited depth first, in the order they were added, and the
+// dependencies of each node in the order they were given.
+func (g *Graph) Order() ([]string, error) {
+	order := []string{}
+	done := map[string]bool{}
+	var visit func(node string, queue []string) error
+	visit = func(node string, queue []string) error {
+		if done[node] {
+			return nil
+		}
+		for _, q := range queue {
+			if q == node {
+				return kerr.New("WBNSGPCKHY", "Circular dependency %v -> %v", queue, node)
+			}
+		}
+		for _, d := range g.deps[node] {
+			if _, found := g.deps[d]; !found {
+				continue
+			}
+			if err := visit(d, append(queue, node)); err != nil {
+				return kerr.Wrap("HJSUAEQMRB", err)
+			}
+		}
+		done[node] = true
+		order = append(order, node)
+		return nil
+	}
+	for _, node := range g.nodes {
+		if err := visit(node, []string{}); err != nil {
+			return nil, kerr.Wrap("YFKQMCHOTA", err)
+		}
+	}
+	return order, nil
+}
+
+// Errors is returned by Run when there's an error for more than one node.
+type Errors struct {
+	kerr.Struct
+	// Errors are keyed by node.
+	Errors map[string]error
+}
+
+const (
+	waiting = iota
+	running
+	succeeded
+	failed
+	skipped
+)
+
+// Run calls f for each node, with up to workers calls at the same time. If workers is less than
+// one, the number of CPUs is used. f is only called for a node when it has succeeded for all the
+// nodes it depends on, so if f fails for a node, the nodes that depend on it are skipped. The
+// other nodes still run, so all the errors are found. After f has succeeded for a node, done is
+// called with the node. done isn't called concurrently, and it's called in the order returned by
+// Order, so output from done is deterministic. If there's an error for a single node, it's
+// returned as is, otherwise an Errors is returned.
+func (g *Graph) Run(workers int, f func(node string) error, done func(node string)) error {
+
+	order, err := g.Order()
+	if err != nil {
+		return kerr.Wrap("OCXRVJAKPN", err)
+	}
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	type result struct {
+		node string
+		err  error
+	}
+	results := make(chan result)
+
+	state := map[string]int{}
+	errors := map[string]error{}
+	active := 0
+	next := 0
+
+	for {
+		// Start the nodes that are ready. The order is topological, so a dependency is always
+		// before the nodes that depend on it, and a failure is passed on in a single pass.
+		for _, node := range order {
+			if state[node] != waiting {
+				continue
+			}
+			ready := true
+			for _, d := range g.deps[node] {
+				if _, found := g.deps[d]; !found {
+					continue
+				}
+				switch state[d] {
+				case succeeded:
+				case failed, skipped:
+					state[node] = skipped
+					ready = false
+				default:
+					ready = false
+				}
+			}
+			if !ready || active >= workers {
+				continue
+			}
+			state[node] = running
+			active++
+			go func(node string) {
+				results <- result{node: node, err: f(node)}
+			}(node)
+		}
+
+		for ; next < len(order) && state[order[next]] > running; next++ {
+			if state[order[next]] == succeeded && done != nil {
+				done(order[next])
+			}
+		}
+
+		if active == 0 {
+			break
+		}
+
+		r := <-results
+		active--
+		if r.err != nil {
+			state[r.node] = failed
+			errors[r.node] = r.err
+		} else {
+			state[r.node] = succeeded
+		}
+	}
+
+	if len(errors) == 0 {
+		return nil
+	}
+	lines := []string{}
+	for _, node := range order {
+		if err, ok := errors[node]; ok {
+			if len(errors) == 1 {
+				return err
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", node, err.Error()))
+		}
+	}
+	return Errors{Struct: kerr.New("PXGLWDKQEU", "%d errors:\n%s", len(errors), strings.Join(lines, "\n")), Errors: errors}
+}
@@ -0,0 +1,91 @@
+package dag
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+)
+
+func TestOrder(t *testing.T) {
+	g := New()
+	g.Add("a", "s", "c", "b")
+	g.Add("b", "s", "c")
+	g.Add("c", "s", "x")
+	g.Add("s")
+	assert.True(t, g.Has("c"))
+	assert.False(t, g.Has("x"))
+	order, err := g.Order()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s", "c", "b", "a"}, order)
+
+	g.Add("s", "a")
+	_, err = g.Order()
+	assert.IsError(t, err, "YFKQMCHOTA")
+	assert.HasError(t, err, "WBNSGPCKHY")
+
+	err = g.Run(1, func(string) error { return nil }, nil)
+	assert.IsError(t, err, "OCXRVJAKPN")
+}
+
+func TestRun(t *testing.T) {
+	g := New()
+	g.Add("a", "b", "c")
+	g.Add("b", "d")
+	g.Add("c", "d")
+	g.Add("d")
+
+	var m sync.Mutex
+	called := map[string]bool{}
+	done := []string{}
+	err := g.Run(2, func(node string) error {
+		m.Lock()
+		defer m.Unlock()
+		for _, d := range g.deps[node] {
+			assert.True(t, called[d], "%s before %s", d, node)
+		}
+		called[node] = true
+		return nil
+	}, func(node string) {
+		done = append(done, node)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "b", "c", "a"}, done)
+}
+
+func TestRunErrors(t *testing.T) {
+	g := New()
+	g.Add("a", "b", "c")
+	g.Add("b")
+	g.Add("c")
+	g.Add("d", "a")
+	g.Add("e")
+
+	var m sync.Mutex
+	called := []string{}
+	fail := map[string]bool{"b": true}
+	f := func(node string) error {
+		m.Lock()
+		defer m.Unlock()
+		called = append(called, node)
+		if fail[node] {
+			return kerr.New("BVRUKXNDYO", "Failed %s", node)
+		}
+		return nil
+	}
+	done := []string{}
+	err := g.Run(0, f, func(node string) { done = append(done, node) })
+	assert.IsError(t, err, "BVRUKXNDYO")
+	assert.Equal(t, []string{"c", "e"}, done)
+	assert.Equal(t, 3, len(called))
+
+	fail["e"] = true
+	err = g.Run(0, f, nil)
+	assert.IsError(t, err, "PXGLWDKQEU")
+	e, ok := kerr.Source(err).(Errors)
+	require.True(t, ok)
+	assert.Equal(t, 2, len(e.Errors))
+	assert.Contains(t, err.Error(), "b: BVRUKXNDYO: Failed b\ne: BVRUKXNDYO: Failed e")
+}
@@ -0,0 +1,30 @@
+package process
+
+import (
+	"os"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/process/diff"
+)
+
+// Diff compares the data files in the package with base, which is either a directory or a git
+// revision of the package directory.
+func Diff(ctx context.Context, base string) ([]diff.Change, error) {
+	env := envctx.FromContext(ctx)
+	if info, err := os.Stat(base); err != nil || !info.IsDir() {
+		tmp, err := diff.Revision(ctx, base, env.Dir, env.Recursive)
+		if err != nil {
+			return nil, kerr.Wrap("CKVNRQEHTI", err)
+		}
+		defer os.RemoveAll(tmp)
+		base = tmp
+	}
+	changes, err := diff.Dirs(ctx, base, env.Dir, env)
+	if err != nil {
+		return nil, kerr.Wrap("PAWHXSMFJY", err)
+	}
+	return changes, nil
+}
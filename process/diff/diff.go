@@ -0,0 +1,336 @@
+package diff // import "kego.io/process/diff"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/json"
+	"kego.io/process/format"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+type Kind string
+
+const (
+	ADDED   Kind = "added"
+	REMOVED Kind = "removed"
+	CHANGED Kind = "changed"
+)
+
+// Change is a global or a field that is different in the two packages. The values are in
+// canonical form (see format.Value), and Before is nil if the value was added, After is nil if
+// it was removed.
+type Change struct {
+	Kind   Kind
+	Path   string
+	Before interface{}
+	After  interface{}
+}
+
+// Dirs compares the data files in two directories, and returns the globals and fields that have
+// been added, removed or changed, sorted by path. Globals are matched by id (or by file name if
+// they have no id), and fields by node path. Differences in formatting, map order and the alias
+// used in references are ignored, and a missing field is the same as the default value. The data
+// files in each directory are selected in the same way as in the package of env: with its
+// recursive flag, its include and exclude patterns, and the .keignore file in the directory.
+func Dirs(ctx context.Context, a, b string, env *envctx.Env) ([]Change, error) {
+	before, err := load(ctx, a, env)
+	if err != nil {
+		return nil, kerr.Wrap("YVPKDRHSQI", err)
+	}
+	after, err := load(ctx, b, env)
+	if err != nil {
+		return nil, kerr.Wrap("NOWJGFCXUT", err)
+	}
+	keys := []string{}
+	for k := range before {
+		keys = append(keys, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	changes := []Change{}
+	for _, k := range keys {
+		c, err := Nodes(ctx, k, before[k], after[k])
+		if err != nil {
+			return nil, kerr.Wrap("LBKUGFMWDE", err)
+		}
+		changes = append(changes, c...)
+	}
+	return changes, nil
+}
+
+// load unmarshals the globals in dir, keyed by id. The files are selected as in the package of
+// env.
+func load(ctx context.Context, dir string, env *envctx.Env) (map[string]*node.Node, error) {
+	globals := map[string]*node.Node{}
+	e := *env
+	e.Dir = dir
+	files := scanner.ScanPackageToFiles(ctx, &e)
+	bytes := scanner.ScanFilesToBytes(ctx, files)
+	for c := range bytes {
+		if c.Err != nil {
+			return nil, kerr.Wrap("GWQMTHXAPO", c.Err)
+		}
+		n, err := node.UnmarshalPositions(ctx, c.Bytes, c.Positions)
+		if err != nil {
+			return nil, kerr.Wrap("RHXVMWNQEI", err)
+		}
+		if err := n.RecomputeHash(ctx, true); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("FPSKAOEUWN", err)
+		}
+		key := ""
+		if id, ok := n.Map["id"]; ok && !id.Missing && !id.Null {
+			key = id.ValueString
+		} else {
+			rel, err := c.Name(dir)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("JDTEXNSOMV", err)
+			}
+			key = filepath.ToSlash(rel)
+		}
+		if _, ok := globals[key]; ok {
+			return nil, kerr.New("WMEQRCAHUY", "Duplicate global %s in %s", key, dir)
+		}
+		globals[key] = n
+	}
+	return globals, nil
+}
+
+// Nodes compares two nodes, and returns the changes in the node and its descendants. Either node
+// may be nil. Subtrees with the same hash are skipped, so RecomputeHash must have been called on
+// both nodes.
+func Nodes(ctx context.Context, path string, a, b *node.Node) ([]Change, error) {
+	if !exists(a) && !exists(b) {
+		return nil, nil
+	}
+	if !exists(a) || !exists(b) {
+		n := a
+		if !exists(a) {
+			n = b
+		}
+		v, err := format.Value(ctx, n)
+		if err != nil {
+			return nil, kerr.Wrap("ABOXQTVMEJ", err)
+		}
+		d, ok, err := defaultValue(ctx, n)
+		if err != nil {
+			return nil, kerr.Wrap("UIHCPWERYJ", err)
+		}
+		switch {
+		case ok && reflect.DeepEqual(d, v):
+			// A missing field is the same as the default value.
+			return nil, nil
+		case ok && !exists(a):
+			return []Change{{Kind: CHANGED, Path: path, Before: d, After: v}}, nil
+		case ok:
+			return []Change{{Kind: CHANGED, Path: path, Before: v, After: d}}, nil
+		case !exists(a):
+			return []Change{{Kind: ADDED, Path: path, After: v}}, nil
+		}
+		return []Change{{Kind: REMOVED, Path: path, Before: v}}, nil
+	}
+	if a.Hash() == b.Hash() {
+		return nil, nil
+	}
+	if a.JsonType == b.JsonType && a.Type != nil && b.Type != nil && *a.Type.Id == *b.Type.Id {
+		switch a.JsonType {
+		case json.J_OBJECT, json.J_MAP:
+			keys := []string{}
+			for k := range a.Map {
+				keys = append(keys, k)
+			}
+			for k := range b.Map {
+				if _, ok := a.Map[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			changes := []Change{}
+			for _, k := range keys {
+				c, err := Nodes(ctx, path+"/"+k, a.Map[k], b.Map[k])
+				if err != nil {
+					return nil, kerr.Wrap("SJFNWQVOXB", err)
+				}
+				changes = append(changes, c...)
+			}
+			return changes, nil
+		case json.J_ARRAY:
+			changes := []Change{}
+			for i := 0; i < len(a.Array) || i < len(b.Array); i++ {
+				var ai, bi *node.Node
+				if i < len(a.Array) {
+					ai = a.Array[i]
+				}
+				if i < len(b.Array) {
+					bi = b.Array[i]
+				}
+				c, err := Nodes(ctx, path+"/"+strconv.Itoa(i), ai, bi)
+				if err != nil {
+					// ke: {"block": {"notest": true}}
+					return nil, kerr.Wrap("GBXIMQPUKE", err)
+				}
+				changes = append(changes, c...)
+			}
+			return changes, nil
+		}
+	}
+	va, err := format.Value(ctx, a)
+	if err != nil {
+		return nil, kerr.Wrap("TFRMVXJEAS", err)
+	}
+	vb, err := format.Value(ctx, b)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("QHOYXLRWKC", err)
+	}
+	if reflect.DeepEqual(va, vb) {
+		// e.g. references with a different alias
+		return nil, nil
+	}
+	return []Change{{Kind: CHANGED, Path: path, Before: va, After: vb}}, nil
+}
+
+// exists is false for missing fields and null values.
+func exists(n *node.Node) bool {
+	return n != nil && !n.Missing && !n.Null
+}
+
+// defaultValue returns the default of the node's rule in canonical form, if it has one.
+func defaultValue(ctx context.Context, n *node.Node) (interface{}, bool, error) {
+	if n.Rule == nil {
+		return nil, false, nil
+	}
+	dr, ok := n.Rule.Interface.(system.DefaultRule)
+	if !ok {
+		return nil, false, nil
+	}
+	d := dr.GetDefault()
+	if d == nil {
+		return nil, false, nil
+	}
+	if v := reflect.ValueOf(d); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false, nil
+	}
+	switch d := d.(type) {
+	case *system.Reference:
+		s, err := d.ValueContext(ctx)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, false, kerr.Wrap("VPMWNLHGYS", err)
+		}
+		return s, true, nil
+	case system.NativeString:
+		return d.NativeString(), true, nil
+	case system.NativeNumber:
+		return d.NativeNumber(), true, nil
+	case system.NativeBool:
+		return d.NativeBool(), true, nil
+	case string, float64, bool:
+		return d, true, nil
+	}
+	return nil, false, nil
+}
+
+// Revision writes the data files and the .keignore file in dir at a git revision to a temporary
+// directory, so they can be compared with Dirs. The caller should remove the directory.
+func Revision(ctx context.Context, rev, dir string, recursive bool) (string, error) {
+	out, err := git(ctx, dir, "ls-tree", "-r", "--name-only", rev, "--", ".")
+	if err != nil {
+		return "", kerr.Wrap("XRMJVAYKDO", err)
+	}
+	tmp, err := ioutil.TempDir("", "ke-diff")
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return "", kerr.Wrap("LTUDNEQXFW", err)
+	}
+	for _, name := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		ext := filepath.Ext(name)
+		if name == "" || (ext != ".json" && ext != ".yaml" && ext != ".yml" && name != scanner.IgnoreFile) {
+			continue
+		}
+		if !recursive && strings.Contains(name, "/") {
+			continue
+		}
+		b, err := git(ctx, dir, "show", rev+":./"+name)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			os.RemoveAll(tmp)
+			return "", kerr.Wrap("BFQMUWTCHA", err)
+		}
+		file := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+			// ke: {"block": {"notest": true}}
+			os.RemoveAll(tmp)
+			return "", kerr.Wrap("OEVRNYGKSC", err)
+		}
+		if err := ioutil.WriteFile(file, b, 0666); err != nil {
+			// ke: {"block": {"notest": true}}
+			os.RemoveAll(tmp)
+			return "", kerr.Wrap("WKSAHDQYLM", err)
+		}
+	}
+	return tmp, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, kerr.New("TEMYCJUSPB", "git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Write writes the changes, one per line. Added values are prefixed with "+", removed values with
+// "-" and changed values with "~". Values are written as compact json.
+func Write(w io.Writer, changes []Change) error {
+	for _, c := range changes {
+		var line string
+		switch c.Kind {
+		case ADDED:
+			line = fmt.Sprintf("+ %s: %s", c.Path, compact(c.After))
+		case REMOVED:
+			line = fmt.Sprintf("- %s: %s", c.Path, compact(c.Before))
+		default:
+			line = fmt.Sprintf("~ %s: %s => %s", c.Path, compact(c.Before), compact(c.After))
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return kerr.Wrap("MFXHUGRVOA", err)
+		}
+	}
+	return nil
+}
+
+func compact(v interface{}) string {
+	b, err := format.MarshalJson(v, "")
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return err.Error()
+	}
+	return string(b)
+}
@@ -0,0 +1,185 @@
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestDirs(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	files := map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				b:
+					type: system:@string
+					default: foo
+					optional: true
+				c:
+					type: system:@map
+					items:
+						type: system:@number
+					optional: true
+				d:
+					type: system:@array
+					items:
+						type: system:@string
+					optional: true
+				e:
+					type: system:@reference
+					optional: true
+		`,
+		"f.yaml": `
+			type: a
+			id: f
+			c:
+				w: 2
+				x: 1
+			d: [g, h]
+			e: system:string
+		`,
+		"i.yaml": `
+			type: a
+			id: i
+		`,
+	}
+	path, dir := cb.TempPackage("a", files)
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	write := func(name, contents string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(base, name), []byte(contents), 0666))
+	}
+
+	// Formatting, map order, explicit defaults and aliases are ignored.
+	write("a.json", `{"type": "system:type", "id": "a", "fields": {
+		"e": {"type": "system:@reference", "optional": true},
+		"d": {"type": "system:@array", "items": {"type": "system:@string"}, "optional": true},
+		"c": {"type": "system:@map", "items": {"type": "system:@number"}, "optional": true},
+		"b": {"type": "system:@string", "default": "foo", "optional": true}
+	}}`)
+	write("f.json", `{"type": "`+path+`:a", "id": "f", "b": "foo", "c": {"x": 1, "w": 2}, "d": ["g", "h"], "e": "kego.io/system:string"}`)
+	write("i.json", `{"type": "a", "id": "i"}`)
+
+	changes, err := Dirs(cb.Ctx(), base, dir, cb.Env())
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(changes))
+
+	write("f.json", `{"type": "a", "id": "f", "b": "bar", "c": {"x": 3, "z": 4}, "d": ["g"], "e": "system:bool"}`)
+	require.NoError(t, os.Remove(filepath.Join(base, "i.json")))
+	write("j.json", `{"type": "a", "id": "j"}`)
+	write("k.json", `{"type": "system:package"}`)
+
+	changes, err = Dirs(cb.Ctx(), base, dir, cb.Env())
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(out, changes))
+	assert.Equal(t, `~ f/b: "bar" => "foo"
++ f/c/w: 2
+~ f/c/x: 3 => 1
+- f/c/z: 4
++ f/d/1: "h"
+~ f/e: "system:bool" => "system:string"
++ i: {"type":"a","id":"i"}
+- j: {"type":"a","id":"j"}
+- k.json: {"type":"system:package"}
+`, out.String())
+
+	// The .keignore file in each dir and the exclude patterns of the package are applied.
+	write(".keignore", "m.json\n")
+	write("m.json", `{"type": "a", "id": "j"}`)
+	write("o.json", `{"type": "a", "id": "j"}`)
+	env := *cb.Env()
+	env.Exclude = []string{"o.json"}
+	changes, err = Dirs(cb.Ctx(), base, dir, &env)
+	require.NoError(t, err)
+	assert.Equal(t, 9, len(changes))
+	require.NoError(t, os.Remove(filepath.Join(base, "m.json")))
+	require.NoError(t, os.Remove(filepath.Join(base, "o.json")))
+
+	write("l.json", `{"type": "a", "id": "j"}`)
+	_, err = Dirs(cb.Ctx(), base, dir, cb.Env())
+	assert.IsError(t, err, "YVPKDRHSQI")
+	assert.HasError(t, err, "WMEQRCAHUY")
+
+	write("l.json", `{"type": "a", "id": "l", "m": 1}`)
+	_, err = Dirs(cb.Ctx(), base, dir, cb.Env())
+	assert.HasError(t, err, "RHXVMWNQEI")
+
+	require.NoError(t, os.Remove(filepath.Join(base, "l.json")))
+	_, err = Dirs(cb.Ctx(), base, filepath.Join(dir, "n"), cb.Env())
+	assert.IsError(t, err, "NOWJGFCXUT")
+	assert.HasError(t, err, "GWQMTHXAPO")
+}
+
+func TestRevision(t *testing.T) {
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+
+	_, dir := cb.TempPackage("a", map[string]string{
+		"a.json":    `{"type": "system:type", "id": "a"}`,
+		"b.go":      "package a",
+		".keignore": "e.json\n",
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "c"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "c", "d.yaml"), []byte("type: system:package"), 0666))
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@b", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@b")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "a")
+
+	cb.TempFile("a.json", `{"type": "system:type", "id": "b"}`)
+
+	tmp, err := Revision(cb.Ctx(), "HEAD", dir, false)
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+
+	b, err := ioutil.ReadFile(filepath.Join(tmp, "a.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"type": "system:type", "id": "a"}`, string(b))
+	_, err = os.Stat(filepath.Join(tmp, "b.go"))
+	assert.True(t, os.IsNotExist(err))
+	b, err = ioutil.ReadFile(filepath.Join(tmp, ".keignore"))
+	require.NoError(t, err)
+	assert.Equal(t, "e.json\n", string(b))
+	_, err = os.Stat(filepath.Join(tmp, "c", "d.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	tmp, err = Revision(cb.Ctx(), "HEAD", dir, true)
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	_, err = os.Stat(filepath.Join(tmp, "c", "d.yaml"))
+	assert.NoError(t, err)
+
+	_, err = Revision(cb.Ctx(), "foo", dir, false)
+	assert.IsError(t, err, "XRMJVAYKDO")
+	assert.HasError(t, err, "TEMYCJUSPB")
+}
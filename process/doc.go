@@ -0,0 +1,36 @@
+package process
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/process/doc"
+)
+
+// Doc writes the documentation for the types in the package to dir, which is created if it
+// doesn't exist. The files that were written are returned, sorted.
+func Doc(ctx context.Context, f doc.Format, dir string) ([]string, error) {
+	pages, err := doc.Package(ctx, f)
+	if err != nil {
+		return nil, kerr.Wrap("RYMBGQAXKE", err)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, kerr.Wrap("WFUCOSLDHN", err)
+	}
+	written := []string{}
+	for name, b := range pages {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, b, 0666); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("EPHQJMIVXU", err)
+		}
+		written = append(written, file)
+	}
+	sort.Strings(written)
+	return written, nil
+}
@@ -0,0 +1,526 @@
+package doc // import "kego.io/process/doc"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/process/format"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+type Format string
+
+const (
+	FORMAT_HTML     Format = "html"
+	FORMAT_MARKDOWN Format = "markdown"
+)
+
+// Formats lists the formats accepted by Package.
+var Formats = []Format{FORMAT_HTML, FORMAT_MARKDOWN}
+
+// MaxExamples is the maximum number of example globals shown for each type.
+const MaxExamples = 3
+
+// Index is the model for the index page.
+type Index struct {
+	Path  string
+	Pages []*Page
+}
+
+// Page is the model for the page of a type.
+type Page struct {
+	Id           string
+	File         string
+	GoName       string
+	Description  string
+	Native       string
+	Interface    bool
+	Embeds       []Link
+	Implementers []Link
+	Fields       []Field
+	RuleFields   []Field
+	Examples     []Example
+}
+
+// Link is a type, with the page that documents it if it's in the package.
+type Link struct {
+	Text string
+	Href string
+}
+
+// Field is a field of a type, or of the rule of a type. The default and the restrictions are
+// compact json.
+type Field struct {
+	Name         string
+	GoName       string
+	Type         Link
+	Optional     bool
+	Default      string
+	Description  string
+	Restrictions []Restriction
+}
+
+type Restriction struct {
+	Name  string
+	Value string
+}
+
+// Example is a global of the type, as canonical json.
+type Example struct {
+	Id   string
+	Json string
+}
+
+// Package documents the types in the local package. The pages are returned keyed by file name:
+// an index page (index.html or README.md) and a page for each type (e.g. page.html or
+// page.md).
+func Package(ctx context.Context, f Format) (map[string][]byte, error) {
+
+	var ext, index string
+	switch f {
+	case FORMAT_HTML, "":
+		ext, index = ".html", "index.html"
+	case FORMAT_MARKDOWN:
+		ext, index = ".md", "README.md"
+	default:
+		return nil, kerr.New("GOBXWMEVNK", "Unknown doc format %s", f)
+	}
+
+	env := envctx.FromContext(ctx)
+	pi, ok := sysctx.FromContext(ctx).Get(env.Path)
+	if !ok {
+		return nil, kerr.New("SQFAUKRHNW", "%s not found in sys ctx", env.Path)
+	}
+
+	examples, err := loadExamples(ctx, pi)
+	if err != nil {
+		return nil, kerr.Wrap("YLDWOSVAQC", err)
+	}
+
+	d := &documenter{ctx: ctx, pi: pi, ext: ext}
+	idx := &Index{Path: env.Path}
+	for _, name := range pi.Types.Keys() {
+		if strings.HasPrefix(name, "@") {
+			// rule types are documented on the page of their type
+			continue
+		}
+		p, err := d.page(name)
+		if err != nil {
+			return nil, kerr.Wrap("NCTVRAJEUW", err)
+		}
+		p.Examples = examples[name]
+		idx.Pages = append(idx.Pages, p)
+	}
+
+	out := map[string][]byte{}
+	b, err := render(f, "index", idx)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("HJCNXMOBTD", err)
+	}
+	out[index] = b
+	for _, p := range idx.Pages {
+		b, err := render(f, "page", p)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("PUWEQKSGAF", err)
+		}
+		out[p.File] = b
+	}
+	return out, nil
+}
+
+type documenter struct {
+	ctx context.Context
+	pi  *sysctx.SysPackageInfo
+	ext string
+}
+
+func (d *documenter) page(name string) (*Page, error) {
+
+	ti, _, err := d.pi.Types.GetErr(name)
+	if err != nil {
+		return nil, kerr.Wrap("SLKAQZRYVN", err)
+	}
+	t, ok := ti.Type.(*system.Type)
+	if !ok {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.New("EUKSNFXVBA", "%T is not a *system.Type", ti.Type)
+	}
+
+	p := &Page{
+		Id:          name,
+		File:        name + d.ext,
+		GoName:      t.GoName(),
+		Description: t.Description,
+		Native:      string(t.NativeJsonType()),
+		Interface:   t.Interface,
+	}
+	for _, e := range t.Embed {
+		p.Embeds = append(p.Embeds, d.link(e, ""))
+	}
+	if t.Interface {
+		impls, err := system.GetAllTypesThatImplementInterface(d.ctx, t)
+		if err != nil {
+			return nil, kerr.Wrap("BYHUQOTKFM", err)
+		}
+		for _, impl := range impls {
+			p.Implementers = append(p.Implementers, d.link(impl.Id, ""))
+		}
+	}
+
+	fi, ok := d.pi.Files.Get(name)
+	if !ok {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.New("DVMHQEKOWR", "File for %s not found", name)
+	}
+	n, err := node.Unmarshal(d.ctx, fi.Bytes)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("IOTXGJBWLA", err)
+	}
+	if p.Fields, err = d.fields(n, t); err != nil {
+		return nil, kerr.Wrap("MKFQUHVRIN", err)
+	}
+	if r := child(n, "rule"); r != nil && t.Rule != nil {
+		if p.RuleFields, err = d.fields(r, t.Rule); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("XQBRGNWTLE", err)
+		}
+	}
+	return p, nil
+}
+
+// fields documents the fields of a type, using the node of the type for the restrictions.
+func (d *documenter) fields(n *node.Node, t *system.Type) ([]Field, error) {
+	out := []Field{}
+	fields := child(n, "fields")
+	for _, f := range t.SortedFields() {
+		w, err := system.WrapRule(d.ctx, f.Rule)
+		if err != nil {
+			return nil, kerr.Wrap("OSWUELBCJY", err)
+		}
+		typ, err := d.ruleType(w)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("AHGVTRDNKQ", err)
+		}
+		field := Field{
+			Name:     f.Name,
+			GoName:   system.GoName(f.Name),
+			Type:     typ,
+			Optional: w.Struct.Optional,
+		}
+		if rn := child(fields, f.Name); rn != nil {
+			if desc := child(rn, "description"); desc != nil {
+				field.Description = desc.ValueString
+			}
+			if def := child(rn, "default"); def != nil {
+				if field.Default, err = compact(d.ctx, def); err != nil {
+					// ke: {"block": {"notest": true}}
+					return nil, kerr.Wrap("NAXUFWHQJO", err)
+				}
+			}
+			if field.Restrictions, err = d.restrictions(rn, ""); err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("CYLEPVGTRO", err)
+			}
+		}
+		out = append(out, field)
+	}
+	return out, nil
+}
+
+// ruleType links to the type of the values held by a rule. Collections are shown as []type or
+// map[]type, and interfaces are suffixed with *, in the same way as RuleWrapper.DisplayType.
+func (d *documenter) ruleType(w *system.RuleWrapper) (Link, error) {
+	prefix := ""
+	for w.IsCollection() {
+		if _, ok := w.Interface.(system.CollectionRule); !ok {
+			// e.g. an alias of a collection type
+			break
+		}
+		if w.Parent.IsNativeArray() {
+			prefix += "[]"
+		} else {
+			prefix += "map[]"
+		}
+		items, err := w.ItemsRule()
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return Link{}, kerr.Wrap("SFBTLMVXCW", err)
+		}
+		w = items
+	}
+	suffix := ""
+	if w.Parent.Interface || (w.Struct != nil && w.Struct.Interface) {
+		suffix = "*"
+	}
+	l := d.link(w.Parent.Id, prefix)
+	l.Text += suffix
+	return l, nil
+}
+
+// restrictions returns the fields of a rule node that restrict the value, e.g. enum, pattern or
+// max. The restrictions of the items of collections are prefixed with "items.".
+func (d *documenter) restrictions(rn *node.Node, prefix string) ([]Restriction, error) {
+	out := []Restriction{}
+	for _, c := range node.SortNodeMap(rn.Map) {
+		if c.Missing || c.Null {
+			continue
+		}
+		switch c.Key {
+		case "type", "id", "description", "optional", "default", "interface":
+			continue
+		case "items":
+			r, err := d.restrictions(c, prefix+"items.")
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("BRMXTWFJQU", err)
+			}
+			out = append(out, r...)
+			continue
+		}
+		v, err := compact(d.ctx, c)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("JKWVOPNEYA", err)
+		}
+		out = append(out, Restriction{Name: prefix + c.Key, Value: v})
+	}
+	return out, nil
+}
+
+func (d *documenter) link(r *system.Reference, prefix string) Link {
+	text, err := r.ValueContext(d.ctx)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		text = r.Value()
+	}
+	l := Link{Text: prefix + text}
+	if r.Package == d.pi.Path {
+		l.Href = r.Name + d.ext
+	}
+	return l
+}
+
+func child(n *node.Node, key string) *node.Node {
+	if n == nil {
+		return nil
+	}
+	c, ok := n.Map[key]
+	if !ok || c.Missing || c.Null {
+		return nil
+	}
+	return c
+}
+
+func compact(ctx context.Context, n *node.Node) (string, error) {
+	v, err := format.Value(ctx, n)
+	if err != nil {
+		return "", kerr.Wrap("RCOVHIDGMB", err)
+	}
+	b, err := format.MarshalJson(v, "")
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return "", kerr.Wrap("WMGQTSAYLX", err)
+	}
+	return string(b), nil
+}
+
+// loadExamples returns up to MaxExamples globals of each type in the package, sorted by id,
+// keyed by type name.
+func loadExamples(ctx context.Context, pi *sysctx.SysPackageInfo) (map[string][]Example, error) {
+	out := map[string][]Example{}
+	for _, id := range pi.Globals.Keys() {
+		gi, _ := pi.Globals.Get(id)
+		b, _, err := scanner.ProcessDocument(filepath.Join(pi.Dir, gi.File))
+		if err != nil {
+			return nil, kerr.Wrap("UGTXKVIPLN", err)
+		}
+		n, err := node.Unmarshal(ctx, b)
+		if err != nil {
+			return nil, kerr.Wrap("FELWBOQDRS", err)
+		}
+		if n.Type == nil || n.Type.Id.Package != pi.Path || len(out[n.Type.Id.Name]) >= MaxExamples {
+			continue
+		}
+		j, err := format.Json(ctx, n)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("KHNYEXOPQA", err)
+		}
+		out[n.Type.Id.Name] = append(out[n.Type.Id.Name], Example{Id: id, Json: string(j)})
+	}
+	for _, examples := range out {
+		sort.Sort(sorter.New(
+			len(examples),
+			func(i, j int) { examples[i], examples[j] = examples[j], examples[i] },
+			func(i, j int) bool { return examples[i].Id < examples[j].Id },
+		))
+	}
+	return out, nil
+}
+
+func render(f Format, name string, data interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if f == FORMAT_MARKDOWN {
+		if err := markdown.ExecuteTemplate(buf, name, data); err != nil {
+			return nil, kerr.Wrap("TIQPNDYMCF", err)
+		}
+	} else {
+		if err := html.ExecuteTemplate(buf, name, data); err != nil {
+			return nil, kerr.Wrap("OGHLWRCVXB", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// cell escapes text for a markdown table cell.
+func cell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+var markdown = template.Must(template.New("").Funcs(template.FuncMap{"cell": cell}).Parse(`
+{{- define "link" }}{{ if .Href }}[{{ .Text }}]({{ .Href }}){{ else }}{{ .Text }}{{ end }}{{ end }}
+
+{{- define "fields" -}}
+| Field | Go name | Type | Optional | Default | Restrictions | Description |
+| --- | --- | --- | --- | --- | --- | --- |
+{{- range . }}
+| {{ .Name }} | {{ .GoName }} | {{ template "link" .Type }} | {{ if .Optional }}yes{{ end }} | {{ cell .Default }} | {{ range $i, $r := .Restrictions }}{{ if $i }}, {{ end }}{{ $r.Name }}: {{ cell $r.Value }}{{ end }} | {{ cell .Description }} |
+{{- end }}
+{{- end }}
+
+{{- define "index" -}}
+# {{ .Path }}
+
+| Type | Description |
+| --- | --- |
+{{ range .Pages -}}
+| [{{ .Id }}]({{ .File }}) | {{ cell .Description }} |
+{{ end }}
+{{- end }}
+
+{{- define "page" -}}
+# {{ .Id }}
+
+{{ if .Description }}{{ .Description }}
+
+{{ end -}}
+Go name: {{ .GoName }}
+
+Native json type: {{ .Native }}
+{{- if .Interface }}
+
+This is an interface type.
+{{- end }}
+{{- if .Embeds }}
+
+Embeds: {{ range $i, $l := .Embeds }}{{ if $i }}, {{ end }}{{ template "link" $l }}{{ end }}
+{{- end }}
+{{- if .Implementers }}
+
+Implemented by: {{ range $i, $l := .Implementers }}{{ if $i }}, {{ end }}{{ template "link" $l }}{{ end }}
+{{- end }}
+{{- if .Fields }}
+
+## Fields
+
+{{ template "fields" .Fields }}
+{{- end }}
+{{- if .RuleFields }}
+
+## Rule
+
+Fields that can be used in rules for this type:
+
+{{ template "fields" .RuleFields }}
+{{- end }}
+{{- if .Examples }}
+
+## Examples
+{{ range .Examples }}
+{{ .Id }}:
+
+` + "```json" + `
+{{ .Json }}` + "```" + `
+{{ end }}
+{{- end }}
+{{ end }}
+`))
+
+var html = htmltemplate.Must(htmltemplate.New("").Parse(`
+{{- define "link" }}{{ if .Href }}<a href="{{ .Href }}">{{ .Text }}</a>{{ else }}{{ .Text }}{{ end }}{{ end }}
+
+{{- define "fields" -}}
+<table>
+<tr><th>Field</th><th>Go name</th><th>Type</th><th>Optional</th><th>Default</th><th>Restrictions</th><th>Description</th></tr>
+{{ range . -}}
+<tr><td>{{ .Name }}</td><td>{{ .GoName }}</td><td>{{ template "link" .Type }}</td><td>{{ if .Optional }}yes{{ end }}</td><td>{{ .Default }}</td><td>{{ range $i, $r := .Restrictions }}{{ if $i }}, {{ end }}{{ $r.Name }}: {{ $r.Value }}{{ end }}</td><td>{{ .Description }}</td></tr>
+{{ end -}}
+</table>
+{{- end }}
+
+{{- define "index" -}}
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Path }}</title></head>
+<body>
+<h1>{{ .Path }}</h1>
+<table>
+<tr><th>Type</th><th>Description</th></tr>
+{{ range .Pages -}}
+<tr><td><a href="{{ .File }}">{{ .Id }}</a></td><td>{{ .Description }}</td></tr>
+{{ end -}}
+</table>
+</body>
+</html>
+{{ end }}
+
+{{- define "page" -}}
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{ .Id }}</title></head>
+<body>
+<p><a href="index.html">Index</a></p>
+<h1>{{ .Id }}</h1>
+{{ if .Description }}<p>{{ .Description }}</p>
+{{ end -}}
+<p>Go name: {{ .GoName }}</p>
+<p>Native json type: {{ .Native }}</p>
+{{ if .Interface }}<p>This is an interface type.</p>
+{{ end -}}
+{{ if .Embeds }}<p>Embeds: {{ range $i, $l := .Embeds }}{{ if $i }}, {{ end }}{{ template "link" $l }}{{ end }}</p>
+{{ end -}}
+{{ if .Implementers }}<p>Implemented by: {{ range $i, $l := .Implementers }}{{ if $i }}, {{ end }}{{ template "link" $l }}{{ end }}</p>
+{{ end -}}
+{{ if .Fields }}<h2>Fields</h2>
+{{ template "fields" .Fields }}
+{{ end -}}
+{{ if .RuleFields }}<h2>Rule</h2>
+<p>Fields that can be used in rules for this type:</p>
+{{ template "fields" .RuleFields }}
+{{ end -}}
+{{ if .Examples }}<h2>Examples</h2>
+{{ range .Examples }}<p>{{ .Id }}:</p>
+<pre><code>{{ .Json }}</code></pre>
+{{ end -}}
+{{ end -}}
+</body>
+</html>
+{{ end }}
+`))
@@ -0,0 +1,108 @@
+package doc
+
+import (
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestPackage(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"page.yaml": `
+			type: system:type
+			id: page
+			description: A page | of the site
+			fields:
+				title:
+					type: system:@string
+					description: The title
+					default: Home
+					max-length: 20
+				photos:
+					type: system:@array
+					items:
+						type: "@photo"
+						secure: true
+					optional: true
+				labels:
+					type: system:@map
+					items:
+						type: system:@string
+						enum: [a, b]
+					optional: true
+		`,
+		"photo.yaml": `
+			type: system:type
+			id: photo
+			embed: ["system:rule"]
+			rule:
+				type: system:type
+				embed: ["system:rule"]
+				fields:
+					secure:
+						type: system:@bool
+						optional: true
+		`,
+		"home.yaml": "type: page\nid: home\ntitle: Welcome\n",
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	pages, err := Package(cb.Ctx(), FORMAT_MARKDOWN)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(pages))
+
+	assert.Equal(t, `# `+path+`
+
+| Type | Description |
+| --- | --- |
+| [page](page.md) | A page \| of the site |
+| [photo](photo.md) |  |
+`, string(pages["README.md"]))
+
+	assert.Equal(t, "# page\n\nA page | of the site\n\nGo name: Page\n\nNative json type: object\n\n## Fields\n\n"+
+		"| Field | Go name | Type | Optional | Default | Restrictions | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- | --- |\n"+
+		"| labels | Labels | map[]system:string | yes |  | items.enum: [\"a\",\"b\"] |  |\n"+
+		"| photos | Photos | [[]photo](photo.md) | yes |  | items.secure: true |  |\n"+
+		"| title | Title | system:string |  | \"Home\" | max-length: 20 | The title |\n"+
+		"\n## Examples\n\nhome:\n\n```json\n{\n\t\"type\": \"page\",\n\t\"id\": \"home\",\n\t\"title\": \"Welcome\"\n}\n```\n\n",
+		string(pages["page.md"]))
+
+	assert.Contains(t, string(pages["photo.md"]), "Embeds: system:rule\n")
+	assert.Contains(t, string(pages["photo.md"]), "## Rule\n\nFields that can be used in rules for this type:\n\n")
+	assert.Contains(t, string(pages["photo.md"]), "| secure | Secure | system:bool | yes |  |  |  |\n")
+
+	pages, err = Package(cb.Ctx(), FORMAT_HTML)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(pages))
+	assert.Contains(t, string(pages["index.html"]), `<tr><td><a href="page.html">page</a></td><td>A page | of the site</td></tr>`)
+	assert.Contains(t, string(pages["page.html"]), `<tr><td>photos</td><td>Photos</td><td><a href="photo.html">[]photo</a></td><td>yes</td><td></td><td>items.secure: true</td><td></td></tr>`)
+	assert.Contains(t, string(pages["page.html"]), `<tr><td>title</td><td>Title</td><td>system:string</td><td></td><td>&#34;Home&#34;</td><td>max-length: 20</td><td>The title</td></tr>`)
+
+	_, err = Package(cb.Ctx(), "foo")
+	assert.IsError(t, err, "GOBXWMEVNK")
+
+	cb.TempFile("home.yaml", "type: page\nid: home\nfoo: bar\n")
+	_, err = Package(cb.Ctx(), FORMAT_HTML)
+	assert.HasError(t, err, "FELWBOQDRS")
+}
+
+func TestPackageInterface(t *testing.T) {
+
+	cb := tests.New()
+
+	cb.Path("kego.io/system").Jauto().Sauto(parser.Parse)
+
+	pages, err := Package(cb.Ctx(), FORMAT_MARKDOWN)
+	require.NoError(t, err)
+	assert.Contains(t, string(pages["operation.md"]), "This is an interface type.\n\nImplemented by: [change-type-reference](change-type-reference.md), [move-field](move-field.md), [rename-field](rename-field.md), [set-default](set-default.md), [wrap-value](wrap-value.md)\n")
+	assert.Contains(t, string(pages["migration.md"]), "| operations | Operations | [[]operation*](operation.md) |")
+}
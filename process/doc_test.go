@@ -0,0 +1,40 @@
+package process
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/doc"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestDoc(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"b.yaml": "type: system:type\nid: b\ndescription: The b type\n",
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	out := filepath.Join(dir, "doc")
+	written, err := Doc(cb.Ctx(), doc.FORMAT_MARKDOWN, out)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(out, "README.md"), filepath.Join(out, "b.md")}, written)
+
+	b, err := ioutil.ReadFile(filepath.Join(out, "b.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "# b\n\nThe b type\n")
+
+	_, err = Doc(cb.Ctx(), "foo", out)
+	assert.IsError(t, err, "RYMBGQAXKE")
+
+	_, err = Doc(cb.Ctx(), doc.FORMAT_HTML, filepath.Join(dir, "b.yaml", "doc"))
+	assert.IsError(t, err, "WFUCOSLDHN")
+}
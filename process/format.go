@@ -0,0 +1,52 @@
+package process
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/process/format"
+	"kego.io/process/scanner"
+)
+
+// Format rewrites the data files in the package in canonical form. If check is true, the files
+// are not written. The files that were changed (or would be changed in check mode) are returned,
+// relative to the package directory.
+func Format(ctx context.Context, check bool) (changed []string, err error) {
+	env := envctx.FromContext(ctx)
+	for f := range scanner.ScanPackageToFiles(ctx, env) {
+		if f.Err != nil {
+			return nil, kerr.Wrap("CUPFMHXOJD", f.Err)
+		}
+		original, formatted, err := format.File(ctx, f.File)
+		if err != nil {
+			return nil, kerr.Wrap("NWJXVAOGRT", err)
+		}
+		if formatted == nil || bytes.Equal(original, formatted) {
+			continue
+		}
+		rel, err := filepath.Rel(env.Dir, f.File)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("YBQEJTLDKA", err)
+		}
+		changed = append(changed, rel)
+		if check {
+			continue
+		}
+		info, err := os.Stat(f.File)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("DMVHUKQAXS", err)
+		}
+		if err := ioutil.WriteFile(f.File, formatted, info.Mode()); err != nil {
+			return nil, kerr.Wrap("RJOGWESTYL", err)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,484 @@
+package format // import "kego.io/process/format"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	yaml "gopkg.in/yaml.v3"
+	"kego.io/json"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+// File formats a json or yaml data file. The canonical form of the file is returned with the
+// original bytes, so the caller can check if it has changed. The comments in a yaml file are
+// kept with the values they belong to. Files with other extensions are ignored, and nil is
+// returned.
+func File(ctx context.Context, file string) (original, formatted []byte, err error) {
+	ext := filepath.Ext(file)
+	if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+		return nil, nil, nil
+	}
+	if original, err = ioutil.ReadFile(file); err != nil {
+		return nil, nil, kerr.Wrap("QEYTFLBIUM", err)
+	}
+	docs, multi, err := scanner.ProcessFileDocuments(file)
+	if err != nil {
+		return nil, nil, kerr.Wrap("JXGNRWHUNT", err)
+	}
+	nodes := []*node.Node{}
+	for _, d := range docs {
+		n, err := node.Unmarshal(ctx, d.Bytes)
+		if err != nil {
+			return nil, nil, kerr.Wrap("PVRAHHCKXQ", err)
+		}
+		nodes = append(nodes, n)
+	}
+	if formatted, err = Objects(ctx, file, original, nodes, multi); err != nil {
+		return nil, nil, kerr.Wrap("TSFWKYOQAP", err)
+	}
+	return original, formatted, nil
+}
+
+// Objects returns the canonical form of a data file with the objects in nodes, which replace the
+// objects in the original contents of the file. multi is true if the file holds several objects.
+// The comments in a yaml file are kept with the values they belong to, as they are by File.
+func Objects(ctx context.Context, file string, original []byte, nodes []*node.Node, multi bool) ([]byte, error) {
+	var b []byte
+	var err error
+	if filepath.Ext(file) != ".json" {
+		b, err = yamlFile(ctx, original, nodes)
+	} else if multi {
+		b, err = Documents(ctx, file, nodes)
+	} else {
+		b, err = Json(ctx, nodes[0])
+	}
+	if err != nil {
+		return nil, kerr.Wrap("KXBQTEMWOH", err)
+	}
+	return b, nil
+}
+
+// Documents returns the canonical form of a file that holds several objects. A json file is an
+// array, and a yaml file has a document for each object, separated by ---.
+func Documents(ctx context.Context, file string, nodes []*node.Node) ([]byte, error) {
+	values := []interface{}{}
+	for _, n := range nodes {
+		v, err := Value(ctx, n)
+		if err != nil {
+			return nil, kerr.Wrap("YRGVUSOKEC", err)
+		}
+		values = append(values, v)
+	}
+	if filepath.Ext(file) == ".json" {
+		b, err := MarshalJson(values, "\t")
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("MQWUDHKTXE", err)
+		}
+		return append(b, '\n'), nil
+	}
+	buf := &bytes.Buffer{}
+	for i, v := range values {
+		b, err := MarshalYaml(v)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("CJPAXNEVQB", err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// Json returns the canonical json for a node. The fields of objects are in the type's field
+// order with type and id first, map keys are sorted, references are in their shortest form and
+// indentation is with tabs.
+func Json(ctx context.Context, n *node.Node) ([]byte, error) {
+	v, err := Value(ctx, n)
+	if err != nil {
+		return nil, kerr.Wrap("HBTMNXUFGD", err)
+	}
+	b, err := MarshalJson(v, "\t")
+	if err != nil {
+		return nil, kerr.Wrap("DOKWMRPJVY", err)
+	}
+	return append(b, '\n'), nil
+}
+
+// Yaml returns the canonical yaml for a node, in the same order as Json. Indentation is with
+// four spaces.
+func Yaml(ctx context.Context, n *node.Node) ([]byte, error) {
+	v, err := Value(ctx, n)
+	if err != nil {
+		return nil, kerr.Wrap("UVJQBNKPRW", err)
+	}
+	b, err := MarshalYaml(v)
+	if err != nil {
+		return nil, kerr.Wrap("NHGDXWLSQE", err)
+	}
+	return b, nil
+}
+
+// yamlFile returns the canonical yaml for the objects in a yaml file. The yaml is built from the
+// node tree of the original file, so the head, line and foot comments are kept. If a comment
+// can't be kept, e.g. a comment on a merge key, the file isn't formatted and an error is
+// returned.
+func yamlFile(ctx context.Context, original []byte, nodes []*node.Node) ([]byte, error) {
+	sources, err := scanner.YamlDocuments(original)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("WTOQDKRUJH", err)
+	}
+	b, err := YamlObjects(ctx, sources, nodes)
+	if err != nil {
+		return nil, kerr.Wrap("RFHOYAWGKN", err)
+	}
+	return b, nil
+}
+
+// YamlObjects returns the canonical yaml for the objects in nodes. sources are the documents of
+// the original file, as returned by scanner.YamlDocuments, and their comments are kept with the
+// values at the same path. If a comment can't be kept, an error is returned.
+func YamlObjects(ctx context.Context, sources []*yaml.Node, nodes []*node.Node) ([]byte, error) {
+	if len(sources) != len(nodes) {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.New("PGXUBLWNQA", "Found %d yaml documents for %d objects", len(sources), len(nodes))
+	}
+	buf := &bytes.Buffer{}
+	for i, n := range nodes {
+		v, err := Value(ctx, n)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("CYHEQWMTLA", err)
+		}
+		doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{yamlNode(v)}}
+		kept := map[*yaml.Node]bool{}
+		keepComments(doc, sources[i], kept)
+		if c := lostComment(sources[i], kept); c != nil {
+			return nil, kerr.New("FMQIUZXOVD", "%d:%d: the comment can't be kept, so the file isn't formatted", c.Line, c.Column)
+		}
+		b, err := marshalYamlNode(doc)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("AXUGTDNWKE", err)
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+// keepComments copies the comments from the source yaml node to the canonical node, and
+// continues with the values at the same path. Map keys are matched by name, so the comments move
+// with the keys when they are sorted. The comments inside an anchor are only kept where the
+// anchor is defined, and not where an alias expands it.
+func keepComments(out, src *yaml.Node, kept map[*yaml.Node]bool) {
+	out.HeadComment, out.LineComment, out.FootComment = src.HeadComment, src.LineComment, src.FootComment
+	kept[src] = true
+	switch {
+	case out.Kind == yaml.DocumentNode && src.Kind == yaml.DocumentNode:
+		if len(out.Content) > 0 && len(src.Content) > 0 {
+			keepComments(out.Content[0], src.Content[0], kept)
+		}
+	case out.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		for i := 0; i < len(out.Content) && i < len(src.Content); i++ {
+			keepComments(out.Content[i], src.Content[i], kept)
+		}
+	case out.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		keys := map[string]int{}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if key := src.Content[i]; key.Kind == yaml.ScalarNode && key.Tag != "!!merge" {
+				keys[key.Value] = i
+			}
+		}
+		for i := 0; i+1 < len(out.Content); i += 2 {
+			if j, ok := keys[out.Content[i].Value]; ok {
+				keepComments(out.Content[i], src.Content[j], kept)
+				keepComments(out.Content[i+1], src.Content[j+1], kept)
+			}
+		}
+	}
+}
+
+// lostComment returns the first node in the source yaml that has a comment that wasn't kept.
+func lostComment(src *yaml.Node, kept map[*yaml.Node]bool) *yaml.Node {
+	if !kept[src] && (src.HeadComment != "" || src.LineComment != "" || src.FootComment != "") {
+		return src
+	}
+	for _, c := range src.Content {
+		if n := lostComment(c, kept); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// MarshalJson returns the json for a value returned by Value. If indent is empty, the json is
+// compact.
+func MarshalJson(v interface{}, indent string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeJson(buf, v, "", indent); err != nil {
+		return nil, kerr.Wrap("LMCYQRGTVB", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalYaml returns the yaml for a value returned by Value.
+func MarshalYaml(v interface{}) ([]byte, error) {
+	b, err := marshalYamlNode(yamlNode(v))
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("OJVNRCWQSM", err)
+	}
+	return b, nil
+}
+
+func marshalYamlNode(n *yaml.Node) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(n); err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("JBWQHOTDUS", err)
+	}
+	if err := enc.Close(); err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("EQRSBWPTCI", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Object is a json object with ordered keys.
+type Object []Field
+
+// Field is a key and value in an Object.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Value converts the node to its canonical form: an Object, []interface{}, string, float64, bool
+// or nil.
+func Value(ctx context.Context, n *node.Node) (interface{}, error) {
+	if n.Null {
+		return nil, nil
+	}
+	switch n.JsonType {
+	case json.J_STRING, json.J_NUMBER, json.J_BOOL:
+		v, err := native(ctx, n)
+		if err != nil {
+			return nil, kerr.Wrap("KXMUGYSRCE", err)
+		}
+		return v, nil
+	case json.J_ARRAY:
+		out := []interface{}{}
+		for _, child := range n.Array {
+			v, err := Value(ctx, child)
+			if err != nil {
+				return nil, kerr.Wrap("FTBHQKWOYC", err)
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case json.J_MAP:
+		if n.Type.IsNativeValue() {
+			// A native value in object form, e.g. {"type": "foo", "value": "bar"}, is used when
+			// the type can't be inferred from the rule.
+			t, err := n.Type.Id.ValueContext(ctx)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("GCMWRLVHNQ", err)
+			}
+			v, err := native(ctx, n)
+			if err != nil {
+				return nil, kerr.Wrap("FDLRHMXBUE", err)
+			}
+			return Object{{"type", t}, {"value", v}}, nil
+		}
+		out := Object{}
+		for _, child := range node.SortNodeMap(n.Map) {
+			v, err := Value(ctx, child)
+			if err != nil {
+				return nil, kerr.Wrap("MJVELCNQTX", err)
+			}
+			out = append(out, Field{child.Key, v})
+		}
+		return out, nil
+	case json.J_OBJECT:
+		out := Object{}
+		for _, child := range sortFields(n) {
+			v, err := Value(ctx, child)
+			if err != nil {
+				return nil, kerr.Wrap("IRGAUVMCDO", err)
+			}
+			out = append(out, Field{child.Key, v})
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+// native returns the string, number or bool value of the node. References are written in their
+// shortest form: without the package if it's the local package, or with the package alias.
+func native(ctx context.Context, n *node.Node) (interface{}, error) {
+	switch n.Type.NativeJsonType() {
+	case json.J_NUMBER:
+		return n.ValueNumber, nil
+	case json.J_BOOL:
+		return n.ValueBool, nil
+	}
+	if *n.Type.Id != *system.NewReference("kego.io/system", "reference") {
+		return n.ValueString, nil
+	}
+	r, err := system.NewReferenceFromString(ctx, n.ValueString)
+	if err != nil {
+		return nil, kerr.Wrap("WYXQNSPGLA", err)
+	}
+	s, err := r.ValueContext(ctx)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("OHCJWEMTRY", err)
+	}
+	return s, nil
+}
+
+// sortFields returns the fields of an object node that are present in the data. The type and id
+// fields are first, followed by the fields of the type, then the fields of embedded types in
+// the order given by Type.FieldOrigins. Within each type the fields are sorted by name.
+func sortFields(n *node.Node) []*node.Node {
+	origins := n.Type.FieldOrigins()
+	rank := func(c *node.Node) int {
+		switch c.Key {
+		case "type":
+			return 0
+		case "id":
+			return 1
+		}
+		for i, origin := range origins {
+			if c.Origin != nil && *origin == *c.Origin {
+				return i + 2
+			}
+		}
+		return len(origins) + 2
+	}
+	out := []*node.Node{}
+	for _, c := range n.Map {
+		if !c.Missing {
+			out = append(out, c)
+		}
+	}
+	sort.Sort(sorter.New(
+		len(out),
+		func(i, j int) { out[i], out[j] = out[j], out[i] },
+		func(i, j int) bool {
+			if ri, rj := rank(out[i]), rank(out[j]); ri != rj {
+				return ri < rj
+			}
+			return out[i].Key < out[j].Key
+		},
+	))
+	return out
+}
+
+func writeJson(buf *bytes.Buffer, v interface{}, prefix, indent string) error {
+	// In compact mode there are no new lines or spaces between the values.
+	newline, colon := "\n", ": "
+	if indent == "" {
+		newline, colon = "", ":"
+	}
+	switch v := v.(type) {
+	case Object:
+		if len(v) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{" + newline)
+		for i, f := range v {
+			buf.WriteString(prefix + indent)
+			if err := writeJson(buf, f.Key, "", ""); err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("BTNKQVXAYE", err)
+			}
+			buf.WriteString(colon)
+			if err := writeJson(buf, f.Value, prefix+indent, indent); err != nil {
+				return kerr.Wrap("LUWQFSKDYE", err)
+			}
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(newline)
+		}
+		buf.WriteString(prefix + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[" + newline)
+		for i, item := range v {
+			buf.WriteString(prefix + indent)
+			if err := writeJson(buf, item, prefix+indent, indent); err != nil {
+				return kerr.Wrap("SVCWDHAPNM", err)
+			}
+			if i < len(v)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString(newline)
+		}
+		buf.WriteString(prefix + "]")
+	default:
+		b, err := json.MarshalPlain(v)
+		if err != nil {
+			return kerr.Wrap("XKTNVAPWCE", err)
+		}
+		buf.Write(b)
+	}
+	return nil
+}
+
+func yamlNode(v interface{}) *yaml.Node {
+	switch v := v.(type) {
+	case Object:
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range v {
+			n.Content = append(n.Content, yamlNode(f.Key), yamlNode(f.Value))
+		}
+		return n
+	case []interface{}:
+		n := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v {
+			n.Content = append(n.Content, yamlNode(item))
+		}
+		return n
+	case string:
+		n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+		if strings.Contains(v, "\n") {
+			n.Style = yaml.LiteralStyle
+		}
+		return n
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if strings.Contains(s, ".") {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: s}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: s}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
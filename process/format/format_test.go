@@ -0,0 +1,163 @@
+package format
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/system/node"
+	"kego.io/tests"
+)
+
+func TestFormat(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.json": `{
+			"fields": {"c": {"type": "system:@string"}, "b": {"type": "system:@map", "items": {"type": "system:@number"}}},
+			"id": "a", "type": "system:type", "description": "foo"
+		}`,
+		"d.yaml": `
+			b:
+				x: 1.5
+				w: 2
+			type: a
+			c: "multi\nline"
+			id: d
+		`,
+		"e.yaml": "type: system:type\nid: e\nfields:\n    f:\n        type: system:@bool\n        optional: true\n",
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	original, formatted, err := File(cb.Ctx(), filepath.Join(dir, "a.json"))
+	require.NoError(t, err)
+	assert.NotEqual(t, string(original), string(formatted))
+	assert.Equal(t, `{
+	"type": "system:type",
+	"id": "a",
+	"fields": {
+		"b": {
+			"type": "system:@map",
+			"items": {
+				"type": "system:@number"
+			}
+		},
+		"c": {
+			"type": "system:@string"
+		}
+	},
+	"description": "foo"
+}
+`, string(formatted))
+
+	_, formatted, err = File(cb.Ctx(), filepath.Join(dir, "d.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `type: a
+id: d
+b:
+    w: 2
+    x: 1.5
+c: |-
+    multi
+    line
+`, string(formatted))
+
+	original, formatted, err = File(cb.Ctx(), filepath.Join(dir, "e.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, string(original), string(formatted))
+
+	cb.TempFile("m.yaml", "id: m\ntype: a\n---\nid: p\ntype: a\nc: o\n")
+	_, formatted, err = File(cb.Ctx(), filepath.Join(dir, "m.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "type: a\nid: m\n---\ntype: a\nid: p\nc: o\n", string(formatted))
+
+	// The comments move with the keys they belong to.
+	cb.TempFile("n.yaml", "# head\n\n# c\nc: o # line\nid: k\nb:\n    # x\n    x: 1\n    w: 2\ntype: a\n---\n# p\nid: p\ntype: a\n")
+	_, formatted, err = File(cb.Ctx(), filepath.Join(dir, "n.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "# head\n\ntype: a\nid: k\nb:\n    w: 2\n    # x\n    x: 1\n# c\nc: o # line\n---\ntype: a\n# p\nid: p\n", string(formatted))
+
+	cb.TempFile("q.yaml", "type: a\nid: q\nb:\n    # merge\n    <<: {w: 2}\n")
+	_, _, err = File(cb.Ctx(), filepath.Join(dir, "q.yaml"))
+	assert.IsError(t, err, "TSFWKYOQAP")
+	assert.HasError(t, err, "FMQIUZXOVD")
+
+	cb.TempFile("m.json", `[{"id": "m", "type": "a"}, {"id": "p", "type": "a"}]`)
+	_, formatted, err = File(cb.Ctx(), filepath.Join(dir, "m.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "[\n\t{\n\t\t\"type\": \"a\",\n\t\t\"id\": \"m\"\n\t},\n\t{\n\t\t\"type\": \"a\",\n\t\t\"id\": \"p\"\n\t}\n]\n", string(formatted))
+
+	cb.TempFile("f.txt", "foo")
+	original, formatted, err = File(cb.Ctx(), filepath.Join(dir, "f.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, original)
+	assert.Nil(t, formatted)
+
+	_, _, err = File(cb.Ctx(), filepath.Join(dir, "g.json"))
+	assert.IsError(t, err, "QEYTFLBIUM")
+
+	cb.TempFile("h.json", `{"type": "system:type", "id": "h", "foo": "bar"}`)
+	_, _, err = File(cb.Ctx(), filepath.Join(dir, "h.json"))
+	assert.IsError(t, err, "PVRAHHCKXQ")
+
+	cb.TempFile("i.yaml", "type: [")
+	_, _, err = File(cb.Ctx(), filepath.Join(dir, "i.yaml"))
+	assert.IsError(t, err, "JXGNRWHUNT")
+}
+
+func TestFormatReferences(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.json": `{
+			"type": "system:type",
+			"id": "a",
+			"fields": {
+				"b": {"type": "system:@reference"},
+				"c": {"type": "system:@string", "interface": true}
+			}
+		}`,
+	})
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	n, err := node.Unmarshal(cb.Ctx(), []byte(`{
+		"type": "`+path+`:a",
+		"id": "d",
+		"b": "`+path+`:a",
+		"c": {"type": "system:string", "value": "e"}
+	}`))
+	require.NoError(t, err)
+
+	b, err := Json(cb.Ctx(), n)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+	"type": "a",
+	"id": "d",
+	"b": "a",
+	"c": {
+		"type": "system:string",
+		"value": "e"
+	}
+}
+`, string(b))
+
+	b, err = Yaml(cb.Ctx(), n)
+	require.NoError(t, err)
+	assert.Equal(t, "type: a\nid: d\nb: a\nc:\n    type: system:string\n    value: e\n", string(b))
+
+	// The package of the type is unknown in another package.
+	cb.Path("kego.io/b")
+	_, err = Json(cb.Ctx(), n)
+	assert.HasError(t, err, "WYXQNSPGLA")
+	_, err = Yaml(cb.Ctx(), n)
+	assert.HasError(t, err, "UVJQBNKPRW")
+	_, err = Documents(cb.Ctx(), "a.yaml", []*node.Node{n})
+	assert.IsError(t, err, "YRGVUSOKEC")
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestFormat(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	a := "{\n\t\"type\": \"system:type\",\n\t\"id\": \"a\"\n}\n"
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.json": a,
+		"b.yaml": "id: b\ntype: a\n",
+		"c.txt":  "foo",
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	changed, err := Format(cb.Ctx(), true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.yaml"}, changed)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "b.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "id: b\ntype: a\n", string(b))
+
+	changed, err = Format(cb.Ctx(), false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b.yaml"}, changed)
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "b.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "type: a\nid: b\n", string(b))
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "a.json"))
+	require.NoError(t, err)
+	assert.Equal(t, a, string(b))
+
+	changed, err = Format(cb.Ctx(), true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(changed))
+
+	cb.TempFile("d.json", `{"type": "a", "id": "d", "e": "f"}`)
+	_, err = Format(cb.Ctx(), true)
+	assert.IsError(t, err, "NWJXVAOGRT")
+
+	cb.Dir(filepath.Join(dir, "e"))
+	_, err = Format(cb.Ctx(), true)
+	assert.IsError(t, err, "CUPFMHXOJD")
+}
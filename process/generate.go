@@ -2,11 +2,14 @@ package process
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
-
+	"sort"
 	"strings"
+	"sync"
 
 	"context"
 
@@ -16,6 +19,7 @@ import (
 	"kego.io/context/sysctx"
 	"kego.io/context/wgctx"
 	"kego.io/json"
+	"kego.io/process/dag"
 	"kego.io/process/generate"
 )
 
@@ -26,6 +30,10 @@ const (
 	S_TYPES   SourceType = "types"
 )
 
+// GenerateAll generates the Go types for the package and the packages it imports, where the
+// types have changed. The packages in done are skipped, and the generated packages are added to
+// done. The packages are generated concurrently, with up to cmd.Workers at a time, each one after
+// the packages it imports.
 func GenerateAll(ctx context.Context, path string, done map[string]bool) error {
 
 	if _, found := done[path]; found {
@@ -33,36 +41,173 @@ func GenerateAll(ctx context.Context, path string, done map[string]bool) error {
 	}
 
 	scache := sysctx.FromContext(ctx)
-	pi, ok := scache.Get(path)
-	if !ok {
+	if _, ok := scache.Get(path); !ok {
 		return kerr.New("XMVXECGDOX", "%s not found in ctx", path)
 	}
-	if path != "kego.io/system" {
-		if err := GenerateAll(ctx, "kego.io/system", done); err != nil {
-			return kerr.Wrap("HBKXDVYWUP", err)
+
+	g := dag.New()
+	if err := addPackage(ctx, g, path, done); err != nil {
+		return kerr.Wrap("HBKXDVYWUP", err)
+	}
+
+	var m sync.Mutex
+	generated := map[string]bool{}
+
+	gen := func(path string) error {
+		pi, _ := scache.Get(path)
+		info, found, err := getInfo(ctx, pi.Dir)
+		if err != nil {
+			return kerr.Wrap("SIMBVNBWOV", err)
+		}
+		if found && info.Hash == pi.Hash {
+			return nil
+		}
+		if err := generatePackage(ctx, pi.Env); err != nil {
+			return kerr.Wrap("TUFKDUPWMD", err)
+		}
+		m.Lock()
+		defer m.Unlock()
+		generated[path] = true
+		return nil
+	}
+
+	cmd := cmdctx.FromContext(ctx)
+	finished := func(path string) {
+		m.Lock()
+		defer m.Unlock()
+		if generated[path] {
+			cmd.Printf("Generating types for %s... OK.\n", path)
 		}
+		done[path] = true
+	}
+
+	if err := g.Run(cmd.Workers, gen, finished); err != nil {
+		return kerr.Wrap("KNQPSXEUVA", err)
+	}
+
+	return nil
+
+}
+
+// addPackage adds the package and the packages it imports to the graph, apart from the packages
+// in done.
+func addPackage(ctx context.Context, g *dag.Graph, path string, done map[string]bool) error {
+
+	if _, found := done[path]; found || g.Has(path) {
+		return nil
+	}
+
+	pi, ok := sysctx.FromContext(ctx).Get(path)
+	if !ok {
+		return kerr.New("QOGJDWHNTA", "%s not found in ctx", path)
+	}
+
+	// The package is added before its imports, so it isn't added again if there's a cycle.
+	g.Add(path)
+
+	deps := []string{}
+	if path != "kego.io/system" {
+		deps = append(deps, "kego.io/system")
+	}
+	names := []string{}
+	for aliasName := range pi.Aliases {
+		names = append(names, aliasName)
+	}
+	sort.Strings(names)
+	for _, aliasName := range names {
+		deps = append(deps, pi.Aliases[aliasName])
 	}
-	for _, aliasPath := range pi.Aliases {
-		if err := GenerateAll(ctx, aliasPath, done); err != nil {
+	for _, d := range deps {
+		if err := addPackage(ctx, g, d, done); err != nil {
 			return kerr.Wrap("WVXTUBQYVT", err)
 		}
 	}
 
-	info, found, err := getInfo(ctx, pi.Dir)
-	if err != nil {
-		return kerr.Wrap("SIMBVNBWOV", err)
+	g.Add(path, deps...)
+
+	return nil
+}
+
+// StalePackage is a package whose generated.go doesn't match its types, and the reasons.
+type StalePackage struct {
+	Path    string
+	Reasons []string
+}
+
+// CheckGenerated is like GenerateAll, but nothing is written. The packages whose generated.go is
+// missing or out of date are returned in the order GenerateAll would generate them. As well as
+// comparing the hash in the info comment, the file is compared byte for byte with the source
+// that would be generated, so a change to the hash scheme or the generator is found too.
+func CheckGenerated(ctx context.Context, path string, done map[string]bool) ([]StalePackage, error) {
+
+	if _, found := done[path]; found {
+		return nil, nil
 	}
 
-	if !found || info.Hash != pi.Hash {
-		if err := Generate(ctx, pi.Env); err != nil {
-			return kerr.Wrap("TUFKDUPWMD", err)
+	scache := sysctx.FromContext(ctx)
+	pi, ok := scache.Get(path)
+	if !ok {
+		return nil, kerr.New("DVQCSMHNEA", "%s not found in ctx", path)
+	}
+
+	stale := []StalePackage{}
+	if path != "kego.io/system" {
+		s, err := CheckGenerated(ctx, "kego.io/system", done)
+		if err != nil {
+			return nil, kerr.Wrap("TYOWNELSUI", err)
+		}
+		stale = append(stale, s...)
+	}
+	// The aliases are sorted, so the stale packages are always returned in the same order.
+	names := []string{}
+	for aliasName := range pi.Aliases {
+		names = append(names, aliasName)
+	}
+	sort.Strings(names)
+	for _, aliasName := range names {
+		s, err := CheckGenerated(ctx, pi.Aliases[aliasName], done)
+		if err != nil {
+			return nil, kerr.Wrap("HXMQWFAJBE", err)
 		}
+		stale = append(stale, s...)
 	}
 
 	done[path] = true
 
-	return nil
+	source, err := generate.Structs(ctx, pi.Env)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("QMAKRLNWUB", err)
+	}
+	existing, err := ioutil.ReadFile(filepath.Join(pi.Dir, "generated.go"))
+	if os.IsNotExist(err) {
+		stale = append(stale, StalePackage{Path: path, Reasons: []string{"generated.go is missing"}})
+		return stale, nil
+	} else if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("GSNXFYWOPV", err)
+	}
+
+	info, found, err := getInfo(ctx, pi.Dir)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("BLEUFCNXYQ", err)
+	}
+	// The hash and the source are compared independently, so both reasons are reported.
+	reasons := []string{}
+	if !found {
+		reasons = append(reasons, "generated.go has no info comment")
+	} else if info.Hash != pi.Hash {
+		reasons = append(reasons, "the types have changed")
+	}
+	if !bytes.Equal(existing, source) {
+		reasons = append(reasons, "generated.go differs from the generated source")
+	}
+	if len(reasons) > 0 {
+		stale = append(stale, StalePackage{Path: path, Reasons: reasons})
+	}
 
+	return stale, nil
 }
 
 func getInfo(ctx context.Context, dir string) (info *generate.InfoStruct, found bool, err error) {
@@ -98,13 +243,24 @@ func getInfo(ctx context.Context, dir string) (info *generate.InfoStruct, found
 // filesystem.
 func Generate(ctx context.Context, env *envctx.Env) error {
 
-	wgctx.Add(ctx, "Generate")
-	defer wgctx.Done(ctx, "Generate")
-
 	cmd := cmdctx.FromContext(ctx)
 
 	cmd.Printf("Generating types for %s... ", env.Path)
 
+	if err := generatePackage(ctx, env); err != nil {
+		return kerr.Wrap("RCTUMXBIWL", err)
+	}
+
+	cmd.Println("OK.")
+
+	return nil
+}
+
+func generatePackage(ctx context.Context, env *envctx.Env) error {
+
+	wgctx.Add(ctx, "Generate")
+	defer wgctx.Done(ctx, "Generate")
+
 	outputDir := env.Dir
 	filename := "generated.go"
 	source, err := generate.Structs(ctx, env)
@@ -118,8 +274,6 @@ func Generate(ctx context.Context, env *envctx.Env) error {
 
 	if err = save(outputDir, source, filename, backup); err != nil {
 		return kerr.Wrap("UONJTTSTWW", err)
-	} else {
-		cmd.Println("OK.")
 	}
 
 	return nil
@@ -95,6 +95,11 @@ func formatTag(ctx context.Context, fieldName string, defaultBytes []byte, r *sy
 
 	env := envctx.FromContext(ctx)
 
+	oneOf, err := getOneOf(r)
+	if err != nil {
+		return "", kerr.Wrap("QDAQZMCHSC", err)
+	}
+
 	kegoTag := ""
 	if defaultBytes != nil && string(defaultBytes) != "null" {
 		defaultRaw := json.RawMessage(defaultBytes)
@@ -119,12 +124,20 @@ func formatTag(ctx context.Context, fieldName string, defaultBytes []byte, r *sy
 				},
 			}
 		}
+		tag.OneOf = oneOf
 
 		jsonBytes, err := json.MarshalPlain(tag)
 		if err != nil {
 			return "", kerr.Wrap("LKBWJTMJCF", err)
 		}
 		kegoTag = string(jsonBytes)
+	} else if len(oneOf) > 0 {
+		jsonBytes, err := json.MarshalPlain(json.KegoTag{OneOf: oneOf})
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return "", kerr.Wrap("SAEJQPGYKC", err)
+		}
+		kegoTag = string(jsonBytes)
 	}
 
 	tag := ""
@@ -150,6 +163,30 @@ func addSubTag(tag string, name string, content string) string {
 	return fmt.Sprintf("%s%s:%s", tag, name, strconv.Quote(content))
 }
 
+// getOneOf returns the types in the one-of list of the rule. For collections the list can be in the
+// collection rule or the items rule, so collections are followed until we find a rule that isn't a
+// collection. ItemsRule restricts the items to the types permitted by both rules.
+func getOneOf(r *system.RuleWrapper) ([]string, error) {
+	if r == nil {
+		return nil, nil
+	}
+	for r.Parent != nil && r.Parent.Native != nil && r.IsCollection() {
+		ir, err := r.ItemsRule()
+		if err != nil {
+			return nil, kerr.Wrap("UYZIOLUXIP", err)
+		}
+		r = ir
+	}
+	if r.Struct == nil {
+		return nil, nil
+	}
+	var out []string
+	for _, ref := range r.Struct.OneOf {
+		out = append(out, ref.Value())
+	}
+	return out, nil
+}
+
 func getTag(ctx context.Context, fieldName string, r *system.RuleWrapper) (string, error) {
 
 	dr, ok := r.Interface.(system.DefaultRule)
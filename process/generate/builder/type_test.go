@@ -79,6 +79,20 @@ func TestFormatTag(t *testing.T) {
 
 	_, err = formatTag(ctx, "n", []byte(`foo`), r)
 	assert.IsError(t, err, "LKBWJTMJCF")
+
+	r.Struct = &system.Rule{OneOf: []*system.Reference{system.NewReference("a.b/c", "a")}}
+	s, err = formatTag(ctx, "n", nil, r)
+	assert.NoError(t, err)
+	assert.Equal(t, "`kego:\"{\\\"one-of\\\":[\\\"a.b/c:a\\\"]}\" json:\"n\"`", s)
+
+	s, err = formatTag(ctx, "n", []byte(`"a"`), r)
+	assert.NoError(t, err)
+	assert.Equal(t, "`kego:\"{\\\"default\\\":{\\\"value\\\":\\\"a\\\"},\\\"one-of\\\":[\\\"a.b/c:a\\\"]}\" json:\"n\"`", s)
+
+	parentType.Native = system.NewString("array")
+	_, err = formatTag(ctx, "n", nil, r)
+	assert.IsError(t, err, "QDAQZMCHSC")
+	assert.HasError(t, err, "UYZIOLUXIP")
 }
 
 type structWithCustomMarshaler struct {
@@ -174,6 +188,46 @@ func (r *ruleStructF) GetDefault() interface{} {
 
 var _ system.DefaultRule = (*ruleStructF)(nil)
 
+func TestGetOneOf(t *testing.T) {
+	itemsType := &system.Type{
+		Object: &system.Object{Id: system.NewReference("a.b/c", "a"), Type: system.NewReference("kego.io/system", "type")},
+	}
+	ctx := tests.Context("d.e/f").StypePath("a.b/c", "a", itemsType).Ctx()
+
+	items := &system.DummyRule{
+		Object: &system.Object{Type: system.NewReference("a.b/c", "@a")},
+		Rule:   &system.Rule{},
+	}
+	collection := &system.DummyRule{
+		Object: &system.Object{Type: system.NewReference("a.b/c", "@b")},
+		Rule:   &system.Rule{OneOf: []*system.Reference{system.NewReference("a.b/c", "d"), system.NewReference("a.b/c", "e")}},
+		Items:  items,
+	}
+	r := &system.RuleWrapper{
+		Ctx:       ctx,
+		Interface: collection,
+		Struct:    collection.Rule,
+		Parent:    &system.Type{Object: &system.Object{Id: system.NewReference("a.b/c", "b")}, Native: system.NewString("array")},
+	}
+
+	// one-of on the @array rule
+	oneOf, err := getOneOf(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.b/c:d", "a.b/c:e"}, oneOf)
+
+	// one-of on both the @array rule and the items rule
+	items.OneOf = []*system.Reference{system.NewReference("a.b/c", "e"), system.NewReference("a.b/c", "f")}
+	oneOf, err = getOneOf(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.b/c:e"}, oneOf)
+
+	// one-of on the items rule only
+	collection.OneOf = nil
+	oneOf, err = getOneOf(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.b/c:e", "a.b/c:f"}, oneOf)
+}
+
 func TestGetTag(t *testing.T) {
 	type parentStruct struct {
 		*system.Object
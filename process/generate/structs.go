@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"sort"
+	"strings"
 
 	"context"
 
@@ -55,6 +56,10 @@ func Structs(ctx context.Context, env *envctx.Env) (source []byte, err error) {
 				if err := printAliasDefinition(ctx, env, g, typ); err != nil {
 					return nil, kerr.Wrap("TRERIECOEP", err)
 				}
+			} else if typ.IsEnum() {
+				if err := printEnumDefinition(env, g, typ); err != nil {
+					return nil, kerr.Wrap("JMUSVPAIFD", err)
+				}
 			} else if typ.IsNativeValue() {
 				if err := printNativeDefinition(ctx, env, g, typ); err != nil {
 					return nil, kerr.Wrap("LGNVUCNYSE", err)
@@ -122,6 +127,73 @@ func printNativeDefinition(ctx context.Context, env *envctx.Env, g *builder.Buil
 	return nil
 }
 
+// printEnumDefinition prints a string type with a constant for each member, and methods that
+// reject values that aren't members when the type is unpacked or marshaled.
+func printEnumDefinition(env *envctx.Env, g *builder.Builder, typ *system.Type) error {
+	if typ.Description != "" {
+		g.Println("// ", typ.Description)
+	}
+	name := system.GoName(typ.Id.Name)
+	g.Println("type ", name, " string")
+
+	members := typ.SortedMembers()
+	args := []interface{}{strconv.Quote(typ.Id.Name)}
+	constants := []string{}
+	g.Println("const (")
+	{
+		for _, m := range members {
+			c, ok := typ.MemberConstant(m)
+			if !ok {
+				return kerr.New("WQBXNTRMEH", "Member %s of %s can't be a Go constant", strconv.Quote(m), typ.Id.Value())
+			}
+			if typ.Members[m] != "" {
+				g.Println("// ", typ.Members[m])
+			}
+			g.Println(c, " ", name, " = ", strconv.Quote(m))
+			args = append(args, strconv.Quote(m))
+			constants = append(constants, c)
+		}
+	}
+	g.Println(")")
+
+	g.Println("func (v ", name, ") Values() []", name, " {")
+	{
+		g.Println("return []", name, "{", strings.Join(constants, ", "), "}")
+	}
+	g.Println("}")
+
+	g.Println("func (v *", name, ") Unpack(ctx ",
+		builder.Reference("context", "Context", env.Path, g.Imports.Add),
+		", in ",
+		builder.Reference("kego.io/json", "Packed", env.Path, g.Imports.Add),
+		") error {")
+	{
+		g.Println("s, err := ", g.SprintFunctionCall("kego.io/system", "UnpackEnum", append([]interface{}{"in"}, args...)...))
+		g.Println("if err != nil {")
+		{
+			g.Println("return err")
+		}
+		g.Println("}")
+		g.Println("*v = ", name, "(s)")
+		g.Println("return nil")
+	}
+	g.Println("}")
+
+	g.Println("func (v *", name, ") MarshalJSON(ctx ",
+		builder.Reference("context", "Context", env.Path, g.Imports.Add),
+		") ([]byte, error) {")
+	{
+		g.Println("if v == nil {")
+		{
+			g.Println(`return []byte("null"), nil`)
+		}
+		g.Println("}")
+		g.Println("return ", g.SprintFunctionCall("kego.io/system", "MarshalEnum", append([]interface{}{"string(*v)"}, args...)...))
+	}
+	g.Println("}")
+	return nil
+}
+
 func printAliasDefinition(ctx context.Context, env *envctx.Env, g *builder.Builder, typ *system.Type) error {
 	if typ.Description != "" {
 		g.Println("// ", typ.Description)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/davelondon/ktest/assert"
 	"github.com/davelondon/ktest/require"
+	"kego.io/context/envctx"
 	"kego.io/process"
 	"kego.io/process/generate"
 	"kego.io/tests"
@@ -16,6 +17,7 @@ func TestStructs(t *testing.T) {
 
 	testAlias(t, cb)
 	testNative(t, cb)
+	testEnum(t, cb)
 
 }
 
@@ -71,6 +73,56 @@ func testNative(t *testing.T, cb *tests.ContextBuilder) {
 	assert.Regexp(t, `FieldNativeBool\s+\*TypeNativeBool`, source)
 }
 
+func testEnum(t *testing.T, cb *tests.ContextBuilder) {
+	source := initialise(t, cb, "b", map[string]string{
+		"type-enum.json": `
+			{
+				"description": "Enum",
+				"type": "system:type",
+				"id": "type-enum",
+				"native": "string",
+				"members": {
+					"first": "The first member",
+					"second-member": ""
+				}
+			}
+		`,
+		"struct-containing-enum-field.json": `
+			{
+				"type": "system:type",
+				"id": "struct-containing-enum-field",
+				"fields": {
+					"field-enum": {
+						"type": "@type-enum"
+					}
+				}
+			}
+		`,
+	})
+	assert.Contains(t, source, "// Enum\ntype TypeEnum string\n")
+	assert.Regexp(t, `// The first member\n\tTypeEnumFirst\s+TypeEnum = "first"\n\tTypeEnumSecondMember\s+TypeEnum = "second-member"\n`, source)
+	assert.Contains(t, source, "func (v TypeEnum) Values() []TypeEnum {\n\treturn []TypeEnum{TypeEnumFirst, TypeEnumSecondMember}\n}\n")
+	assert.Contains(t, source, "func (v *TypeEnum) Unpack(ctx context.Context, in json.Packed) error {\n\ts, err := system.UnpackEnum(in, \"type-enum\", \"first\", \"second-member\")\n")
+	assert.Contains(t, source, "return system.MarshalEnum(string(*v), \"type-enum\", \"first\", \"second-member\")\n")
+	assert.Regexp(t, `FieldEnum\s+\*TypeEnum`, source)
+}
+
+func TestStructsEnumInvalidMember(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, _ := cb.TempPackage("a", map[string]string{
+		"status.yaml": "type: system:type\nid: status\nnative: string\nmembers:\n    in progress: \"\"\n",
+	})
+	ctx, _, err := process.Initialise(cb.Ctx(), &process.Options{
+		Path: path,
+	})
+	require.NoError(t, err)
+	_, err = generate.Structs(ctx, envctx.FromContext(ctx))
+	assert.IsError(t, err, "JMUSVPAIFD")
+	assert.HasError(t, err, "WQBXNTRMEH")
+}
+
 func testAlias(t *testing.T, cb *tests.ContextBuilder) {
 	source := initialise(t, cb, "a", map[string]string{
 		"type-alias-map-json-string.json": `
@@ -127,6 +127,60 @@ type: system:type`,
 
 }
 
+func TestCheckGenerated(t *testing.T) {
+
+	cb := tests.Context("a.b/c").Wg().Sempty().Jsystem().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"a.yml": "id: a\ntype: system:type\n",
+	})
+	pathB, dirB := cb.TempPackage("b", map[string]string{
+		"pkg.yml": "type: system:package\naliases: {\"a\": \"" + pathA + "\"}\n",
+	})
+
+	// The system package is skipped, because its generated.go isn't in the temp gopath.
+	_, err := CheckGenerated(cb.Ctx(), pathA, map[string]bool{"kego.io/system": true})
+	assert.IsError(t, err, "DVQCSMHNEA")
+
+	cb.Path(pathB).Dir(dirB).Sauto(parser.Parse)
+
+	stale, err := CheckGenerated(cb.Ctx(), pathB, map[string]bool{"kego.io/system": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []StalePackage{
+		{Path: pathA, Reasons: []string{"generated.go is missing"}},
+		{Path: pathB, Reasons: []string{"generated.go is missing"}},
+	}, stale)
+	_, err = os.Stat(filepath.Join(dirA, "generated.go"))
+	assert.True(t, os.IsNotExist(err))
+
+	err = GenerateAll(cb.Ctx(), pathB, map[string]bool{"kego.io/system": true})
+	assert.NoError(t, err)
+
+	stale, err = CheckGenerated(cb.Ctx(), pathB, map[string]bool{"kego.io/system": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []StalePackage{}, stale)
+
+	file := filepath.Join(dirA, "generated.go")
+	b, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(file, append(b, []byte("// changed\n")...), 0600))
+	stale, err = CheckGenerated(cb.Ctx(), pathA, map[string]bool{"kego.io/system": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []StalePackage{{Path: pathA, Reasons: []string{"generated.go differs from the generated source"}}}, stale)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("// info:{\"Path\":\""+pathA+"\",\"Hash\":1}\npackage a\n"), 0600))
+	stale, err = CheckGenerated(cb.Ctx(), pathA, map[string]bool{"kego.io/system": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []StalePackage{{Path: pathA, Reasons: []string{"the types have changed", "generated.go differs from the generated source"}}}, stale)
+
+	assert.NoError(t, ioutil.WriteFile(file, []byte("package a\n"), 0600))
+	stale, err = CheckGenerated(cb.Ctx(), pathA, map[string]bool{"kego.io/system": true})
+	assert.NoError(t, err)
+	assert.Equal(t, []StalePackage{{Path: pathA, Reasons: []string{"generated.go has no info comment", "generated.go differs from the generated source"}}}, stale)
+}
+
 func TestGenerate(t *testing.T) {
 
 	cb := tests.New().TempGopath(true)
@@ -0,0 +1,391 @@
+package graph // import "kego.io/process/graph"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/process/format"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+type Kind string
+
+const (
+	// EMBED is from a type to a type it embeds.
+	EMBED Kind = "embed"
+	// FIELD is from a type to the type of one of its fields. For collections, the type of the
+	// items is used.
+	FIELD Kind = "field"
+	// IMPLEMENTS is from a type to an interface type it implements.
+	IMPLEMENTS Kind = "implements"
+	// ALIAS is from a package to a package it imports with an alias.
+	ALIAS Kind = "alias"
+	// TYPE is from a global to its type.
+	TYPE Kind = "type"
+	// REFERENCE is from a global to a global it refers to with a reference.
+	REFERENCE Kind = "reference"
+)
+
+type Format string
+
+const (
+	FORMAT_DOT  Format = "dot"
+	FORMAT_JSON Format = "json"
+)
+
+// Formats lists the formats accepted by Write.
+var Formats = []Format{FORMAT_DOT, FORMAT_JSON}
+
+// Node is a package, type or global. The id of a package is the package path, and the id of a
+// type or global is the full reference, e.g. kego.io/demo/site:page.
+type Node struct {
+	Id   string
+	Kind string
+}
+
+// Edge is a relationship between two nodes. The label holds the field name for FIELD edges,
+// the alias for ALIAS edges and the node path for REFERENCE edges.
+type Edge struct {
+	From  string
+	To    string
+	Kind  Kind
+	Label string
+}
+
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Filter restricts the graph. Edges are only built from the types and globals in Packages, or
+// if Packages is empty, from every package in the sys context apart from system and json. If
+// Types isn't empty, only the edges to or from the types are kept.
+type Filter struct {
+	Packages []string
+	Types    []string
+}
+
+// Build builds the graph of the types and globals in the packages in the sys context. The types
+// in the filter are references in the form used in the local package. Types and globals with no
+// edges are included, so unused types are easy to spot.
+func Build(ctx context.Context, filter Filter) (*Graph, error) {
+
+	scache := sysctx.FromContext(ctx)
+
+	types := map[string]bool{}
+	for _, t := range filter.Types {
+		r, err := system.NewReferenceFromString(ctx, t)
+		if err != nil {
+			return nil, kerr.Wrap("VMKQOSYHEA", err)
+		}
+		types[r.Value()] = true
+	}
+
+	packages := filter.Packages
+	if len(packages) == 0 {
+		for _, path := range scache.Keys() {
+			if path != "kego.io/system" && path != "kego.io/json" {
+				packages = append(packages, path)
+			}
+		}
+	}
+
+	b := &builder{ctx: ctx, nodes: map[string]string{}}
+	for _, path := range packages {
+		pi, ok := scache.Get(path)
+		if !ok {
+			return nil, kerr.New("RAOTWHNFUX", "Package %s not found", path)
+		}
+		if err := b.pkg(envctx.NewContext(ctx, pi.Env), pi); err != nil {
+			return nil, kerr.Wrap("DBHXLPWSMQ", err)
+		}
+	}
+
+	g := &Graph{}
+	for _, e := range b.edges {
+		if len(types) > 0 && !types[e.From] && !types[e.To] {
+			continue
+		}
+		g.Edges = append(g.Edges, e)
+	}
+	for id, kind := range b.nodes {
+		if len(types) > 0 && !types[id] && !connected(id, g.Edges) {
+			continue
+		}
+		g.Nodes = append(g.Nodes, Node{Id: id, Kind: kind})
+	}
+	for _, e := range g.Edges {
+		for _, id := range []string{e.From, e.To} {
+			if _, ok := b.nodes[id]; !ok {
+				b.nodes[id] = b.kind(id)
+				g.Nodes = append(g.Nodes, Node{Id: id, Kind: b.nodes[id]})
+			}
+		}
+	}
+
+	sort.Sort(sorter.New(
+		len(g.Nodes),
+		func(i, j int) { g.Nodes[i], g.Nodes[j] = g.Nodes[j], g.Nodes[i] },
+		func(i, j int) bool { return g.Nodes[i].Id < g.Nodes[j].Id },
+	))
+	sort.Sort(sorter.New(
+		len(g.Edges),
+		func(i, j int) { g.Edges[i], g.Edges[j] = g.Edges[j], g.Edges[i] },
+		func(i, j int) bool {
+			a, b := g.Edges[i], g.Edges[j]
+			if a.From != b.From {
+				return a.From < b.From
+			}
+			if a.To != b.To {
+				return a.To < b.To
+			}
+			if a.Kind != b.Kind {
+				return a.Kind < b.Kind
+			}
+			return a.Label < b.Label
+		},
+	))
+
+	return g, nil
+}
+
+func connected(id string, edges []Edge) bool {
+	for _, e := range edges {
+		if e.From == id || e.To == id {
+			return true
+		}
+	}
+	return false
+}
+
+type builder struct {
+	ctx   context.Context
+	nodes map[string]string
+	edges []Edge
+}
+
+func (b *builder) edge(from, to string, kind Kind, label string) {
+	b.edges = append(b.edges, Edge{From: from, To: to, Kind: kind, Label: label})
+}
+
+// kind returns the kind of a node that isn't in the filtered packages.
+func (b *builder) kind(id string) string {
+	if !strings.Contains(id, ":") {
+		return "package"
+	}
+	r := system.NewReference(id[:strings.LastIndex(id, ":")], id[strings.LastIndex(id, ":")+1:])
+	if pi, ok := sysctx.FromContext(b.ctx).Get(r.Package); ok {
+		if _, ok := pi.Globals.Get(r.Name); ok {
+			return "global"
+		}
+	}
+	return "type"
+}
+
+// pkg adds the edges from a package, and its types and globals. The context has the env of the
+// package, so references in its globals are resolved with its aliases.
+func (b *builder) pkg(ctx context.Context, pi *sysctx.SysPackageInfo) error {
+
+	b.nodes[pi.Path] = "package"
+	for alias, path := range pi.Aliases {
+		b.edge(pi.Path, path, ALIAS, alias)
+	}
+
+	for _, name := range pi.Types.Keys() {
+		if strings.HasPrefix(name, "@") {
+			// rule types
+			continue
+		}
+		ti, _, err := pi.Types.GetErr(name)
+		if err != nil {
+			return kerr.Wrap("HQMVBOTEXS", err)
+		}
+		t, ok := ti.Type.(*system.Type)
+		if !ok {
+			// ke: {"block": {"notest": true}}
+			continue
+		}
+		if err := b.typ(ctx, t); err != nil {
+			return kerr.Wrap("QWSPTMCXEI", err)
+		}
+	}
+
+	for _, name := range pi.Globals.Keys() {
+		gi, _ := pi.Globals.Get(name)
+		bytes, _, err := scanner.ProcessDocument(filepath.Join(pi.Dir, gi.File))
+		if err != nil {
+			return kerr.Wrap("JYGDUHOAWL", err)
+		}
+		n, err := node.Unmarshal(ctx, bytes)
+		if err != nil {
+			return kerr.Wrap("ELXMRNQKBT", err)
+		}
+		if err := b.global(ctx, system.NewReference(pi.Path, name), n); err != nil {
+			// ke: {"block": {"notest": true}}
+			return kerr.Wrap("CNHWYUFQAI", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *builder) typ(ctx context.Context, t *system.Type) error {
+
+	id := t.Id.Value()
+	b.nodes[id] = "type"
+
+	for _, e := range t.Embed {
+		b.edge(id, e.Value(), EMBED, "")
+	}
+
+	for _, f := range t.SortedFields() {
+		r, err := ruleType(ctx, f.Rule)
+		if err != nil {
+			return kerr.Wrap("ODSJAIWGKY", err)
+		}
+		b.edge(id, r.Value(), FIELD, f.Name)
+	}
+
+	if t.Interface {
+		// Implementations are found with the Go types, so they are only found for packages that
+		// are compiled into the binary.
+		impls, err := system.GetAllTypesThatImplementInterface(ctx, t)
+		if err != nil {
+			return kerr.Wrap("JEGLVNMSAO", err)
+		}
+		for _, impl := range impls {
+			b.edge(impl.Id.Value(), id, IMPLEMENTS, "")
+		}
+	}
+
+	return nil
+}
+
+// ruleType returns the type of the values held by a field rule. For collections, the type of
+// the items is returned.
+func ruleType(ctx context.Context, rule system.RuleInterface) (*system.Reference, error) {
+	w, err := system.WrapRule(ctx, rule)
+	if err != nil {
+		return nil, kerr.Wrap("PBAEVNTJXC", err)
+	}
+	for w.IsCollection() {
+		if _, ok := w.Interface.(system.CollectionRule); !ok {
+			// e.g. an alias of a collection type
+			break
+		}
+		if w, err = w.ItemsRule(); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("HWNRCJYFSO", err)
+		}
+	}
+	return w.Parent.Id, nil
+}
+
+func (b *builder) global(ctx context.Context, id *system.Reference, n *node.Node) error {
+
+	b.nodes[id.Value()] = "global"
+	if n.Type != nil {
+		b.edge(id.Value(), n.Type.Id.Value(), TYPE, "")
+	}
+
+	scache := sysctx.FromContext(ctx)
+	for _, child := range n.Flatten(true) {
+		r, ok := child.Value.(*system.Reference)
+		if !ok || r == nil || child.Null || *r == *id {
+			continue
+		}
+		pi, ok := scache.Get(r.Package)
+		if !ok {
+			continue
+		}
+		if _, ok := pi.Globals.Get(r.Name); !ok {
+			continue
+		}
+		b.edge(id.Value(), r.Value(), REFERENCE, child.Path())
+	}
+
+	return nil
+}
+
+// Write writes the graph in DOT or JSON format.
+func Write(w io.Writer, f Format, g *Graph) error {
+	switch f {
+	case FORMAT_DOT, "":
+		return writeDot(w, g)
+	case FORMAT_JSON:
+		return writeJson(w, g)
+	}
+	return kerr.New("TGMJXEQWSU", "Unknown graph format %s", f)
+}
+
+var shapes = map[string]string{
+	"package": "folder",
+	"type":    "box",
+	"global":  "ellipse",
+}
+
+func writeDot(w io.Writer, g *Graph) error {
+	lines := []string{"digraph ke {"}
+	for _, n := range g.Nodes {
+		lines = append(lines, fmt.Sprintf("\t%s [shape=%s];", strconv.Quote(n.Id), shapes[n.Kind]))
+	}
+	for _, e := range g.Edges {
+		label := string(e.Kind)
+		if e.Label != "" {
+			label += " " + e.Label
+		}
+		lines = append(lines, fmt.Sprintf("\t%s -> %s [label=%s];", strconv.Quote(e.From), strconv.Quote(e.To), strconv.Quote(label)))
+	}
+	lines = append(lines, "}")
+	if _, err := fmt.Fprintln(w, strings.Join(lines, "\n")); err != nil {
+		return kerr.Wrap("WSEKIHGPQV", err)
+	}
+	return nil
+}
+
+func writeJson(w io.Writer, g *Graph) error {
+	nodes := []interface{}{}
+	for _, n := range g.Nodes {
+		nodes = append(nodes, format.Object{
+			{Key: "id", Value: n.Id},
+			{Key: "kind", Value: n.Kind},
+		})
+	}
+	edges := []interface{}{}
+	for _, e := range g.Edges {
+		o := format.Object{
+			{Key: "from", Value: e.From},
+			{Key: "to", Value: e.To},
+			{Key: "kind", Value: string(e.Kind)},
+		}
+		if e.Label != "" {
+			o = append(o, format.Field{Key: "label", Value: e.Label})
+		}
+		edges = append(edges, o)
+	}
+	b, err := format.MarshalJson(format.Object{
+		{Key: "nodes", Value: nodes},
+		{Key: "edges", Value: edges},
+	}, "\t")
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("BQFYDRUNWI", err)
+	}
+	if _, err := w.Write(append(b, '\n')); err != nil {
+		return kerr.Wrap("LAXNJGKWEO", err)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestBuild(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, _ := cb.TempPackage("a", map[string]string{
+		"photo.yaml": `
+			type: system:type
+			id: photo
+			fields:
+				url:
+					type: system:@string
+					optional: true
+		`,
+		"gallery.yaml": `
+			type: system:type
+			id: gallery
+			basic: true
+			embed: [photo]
+			fields:
+				photos:
+					type: system:@array
+					items:
+						type: "@photo"
+					optional: true
+		`,
+		"link.yaml": `
+			type: system:type
+			id: link
+			fields:
+				to:
+					type: system:@reference
+					optional: true
+		`,
+		"orphan.yaml": `
+			type: system:type
+			id: orphan
+		`,
+		"p.yaml": "type: photo\nid: p\n",
+		"l.yaml": "type: link\nid: l\nto: p\n",
+	})
+
+	pathB, dirB := cb.TempPackage("b", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    x: " + pathA + "\n",
+		"c.yaml":       "type: x:gallery\nid: c\n",
+	})
+
+	cb.Path(pathB).Dir(dirB).Alias("x", pathA).Cmd().Jauto().Sauto(parser.Parse)
+
+	g, err := Build(cb.Ctx(), Filter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Edge{
+		{From: pathA + ":gallery", To: pathA + ":photo", Kind: EMBED},
+		{From: pathA + ":gallery", To: pathA + ":photo", Kind: FIELD, Label: "photos"},
+		{From: pathA + ":l", To: pathA + ":link", Kind: TYPE},
+		{From: pathA + ":l", To: pathA + ":p", Kind: REFERENCE, Label: "l/to"},
+		{From: pathA + ":link", To: "kego.io/system:reference", Kind: FIELD, Label: "to"},
+		{From: pathA + ":p", To: pathA + ":photo", Kind: TYPE},
+		{From: pathA + ":photo", To: "kego.io/system:string", Kind: FIELD, Label: "url"},
+		{From: pathB, To: pathA, Kind: ALIAS, Label: "x"},
+		{From: pathB + ":c", To: pathA + ":gallery", Kind: TYPE},
+	}, g.Edges)
+
+	assert.Equal(t, []Node{
+		{Id: "kego.io/system:reference", Kind: "type"},
+		{Id: "kego.io/system:string", Kind: "type"},
+		{Id: pathA, Kind: "package"},
+		{Id: pathA + ":gallery", Kind: "type"},
+		{Id: pathA + ":l", Kind: "global"},
+		{Id: pathA + ":link", Kind: "type"},
+		{Id: pathA + ":orphan", Kind: "type"},
+		{Id: pathA + ":p", Kind: "global"},
+		{Id: pathA + ":photo", Kind: "type"},
+		{Id: pathB, Kind: "package"},
+		{Id: pathB + ":c", Kind: "global"},
+	}, g.Nodes)
+
+	g, err = Build(cb.Ctx(), Filter{Packages: []string{pathA}, Types: []string{"x:link"}})
+	require.NoError(t, err)
+
+	b := &bytes.Buffer{}
+	require.NoError(t, Write(b, FORMAT_DOT, g))
+	assert.Equal(t, `digraph ke {
+	"kego.io/system:reference" [shape=box];
+	"`+pathA+`:l" [shape=ellipse];
+	"`+pathA+`:link" [shape=box];
+	"`+pathA+`:l" -> "`+pathA+`:link" [label="type"];
+	"`+pathA+`:link" -> "kego.io/system:reference" [label="field to"];
+}
+`, b.String())
+
+	b = &bytes.Buffer{}
+	require.NoError(t, Write(b, FORMAT_JSON, g))
+	assert.Equal(t, `{
+	"nodes": [
+		{
+			"id": "kego.io/system:reference",
+			"kind": "type"
+		},
+		{
+			"id": "`+pathA+`:l",
+			"kind": "global"
+		},
+		{
+			"id": "`+pathA+`:link",
+			"kind": "type"
+		}
+	],
+	"edges": [
+		{
+			"from": "`+pathA+`:l",
+			"to": "`+pathA+`:link",
+			"kind": "type"
+		},
+		{
+			"from": "`+pathA+`:link",
+			"to": "kego.io/system:reference",
+			"kind": "field",
+			"label": "to"
+		}
+	]
+}
+`, b.String())
+
+	// Implementations are found for the system types, because they are compiled in.
+	g, err = Build(cb.Ctx(), Filter{Packages: []string{"kego.io/system"}, Types: []string{"system:operation"}})
+	require.NoError(t, err)
+	assert.Contains(t, g.Edges, Edge{From: "kego.io/system:rename-field", To: "kego.io/system:operation", Kind: IMPLEMENTS})
+	assert.Contains(t, g.Edges, Edge{From: "kego.io/system:migration", To: "kego.io/system:operation", Kind: FIELD, Label: "operations"})
+
+	err = Write(b, "foo", g)
+	assert.IsError(t, err, "TGMJXEQWSU")
+
+	_, err = Build(cb.Ctx(), Filter{Types: []string{"y:foo"}})
+	assert.IsError(t, err, "VMKQOSYHEA")
+
+	_, err = Build(cb.Ctx(), Filter{Packages: []string{"kego.io/foo"}})
+	assert.IsError(t, err, "RAOTWHNFUX")
+
+	cb.TempFile("d.yaml", "type: x:gallery\nid: d\n")
+	cb.TempFile("c.yaml", "type: x:gallery\nid: c\nfoo: bar\n")
+	_, err = Build(cb.Ctx(), Filter{})
+	assert.HasError(t, err, "ELXMRNQKBT")
+}
@@ -1,7 +1,7 @@
 package process
 
 import (
-	"flag"
+	"time"
 
 	"kego.io/process/parser"
 
@@ -17,20 +17,8 @@ import (
 	"kego.io/process/packages"
 )
 
-type OptionsInterface interface {
-	getOptions() Options
-}
-
-type Flags struct {
-	Edit     *bool
-	Validate *bool
-	Update   *bool
-	Log      *bool
-	Path     *string
-	Debug    *bool
-	Port     *int
-}
-
+// Options are used to initialise the context. The ke commands parse them from the command
+// flags and arguments.
 type Options struct {
 	Edit     bool
 	Validate bool
@@ -39,78 +27,25 @@ type Options struct {
 	Path     string
 	Debug    bool
 	Port     int
+	Format   string        // Format of the command output, e.g. the validation report format
+	Output   string        // Output file for the command. Default: stdout
+	Interval time.Duration // Interval between checks for changed files in watch mode
+	Check    bool          // Check: report the changes that would be made without writing files
+	Workers  int           // Workers is the number of packages parsed or generated at the same time
 }
 
-func (f Options) getOptions() Options {
-	return f
-}
-
-func (f Flags) getOptions() Options {
-
-	var edit, update, log, debug, validate *bool
-	var port *int
-	var path *string
-	if f.Edit == nil {
-		edit = flag.Bool("e", false, "Edit: open the editor")
-	} else {
-		edit = f.Edit
-	}
-	if f.Update == nil {
-		update = flag.Bool("u", false, "Update: update all import packages e.g. go get -u")
-	} else {
-		update = f.Update
-	}
-	if f.Log == nil {
-		log = flag.Bool("l", false, "Log")
-	} else {
-		log = f.Log
-	}
-	if f.Validate == nil {
-		validate = flag.Bool("v", false, "Validate")
-	} else {
-		validate = f.Validate
-	}
-	if f.Port == nil {
-		port = flag.Int("p", 0, "Port: use a specific port for the editor server. Default: use a random port")
-	} else {
-		port = f.Port
-	}
-	if f.Debug == nil {
-		debug = flag.Bool("d", false, "Debug: don't close the server when the connection is closed")
-	} else {
-		debug = f.Debug
-	}
-	if !flag.Parsed() {
-		// ke: {"block": {"notest": true}}
-		flag.Parse()
-	}
-	if f.Path == nil {
-		p := flag.Arg(0)
-		path = &p
-	} else {
-		path = f.Path
+func Initialise(ctx context.Context, options *Options) (context.Context, context.CancelFunc, error) {
+	if options == nil {
+		options = &Options{}
 	}
 
-	return Options{
-		Edit:     *edit,
-		Update:   *update,
-		Log:      *log,
-		Path:     *path,
-		Debug:    *debug,
-		Validate: *validate,
-		Port:     *port,
-	}
-}
-
-func Initialise(ctx context.Context, overrides OptionsInterface) (context.Context, context.CancelFunc, error) {
-	if overrides == nil {
-		overrides = Flags{}
-	}
-	options := overrides.getOptions()
-
 	ctx, cancel := context.WithCancel(ctx)
 	ctx = jsonctx.AutoContext(ctx)
-	ctx = wgctx.NewContext(ctx)
+	if wgctx.FromContextOrNil(ctx) == nil {
+		// The command may have already added a wait group so it can wait for long-running
+		// processes before exiting.
+		ctx = wgctx.NewContext(ctx)
+	}
 	ctx = sysctx.NewContext(ctx)
 
 	cmd := &cmdctx.Cmd{}
@@ -124,6 +59,7 @@ func Initialise(ctx context.Context, overrides OptionsInterface) (context.Contex
 	cmd.Log = options.Log
 	cmd.Debug = options.Debug
 	cmd.Port = options.Port
+	cmd.Workers = options.Workers
 	if options.Path == "" {
 		dir, err := vos.Getwd()
 		if err != nil {
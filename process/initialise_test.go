@@ -8,34 +8,6 @@ import (
 	"kego.io/tests"
 )
 
-func TestGetOptions(t *testing.T) {
-
-	getTrue := func() *bool {
-		val := true
-		return &val
-	}
-	a := "a"
-	b := 2
-	f := Flags{
-		Edit:     getTrue(),
-		Update:   getTrue(),
-		Log:      getTrue(),
-		Validate: getTrue(),
-		Debug:    getTrue(),
-		Path:     &a,
-		Port:     &b,
-	}
-	d := f.getOptions()
-	assert.True(t, d.Edit)
-	assert.True(t, d.Update)
-	assert.True(t, d.Log)
-	assert.True(t, d.Validate)
-	assert.True(t, d.Debug)
-	assert.Equal(t, 2, d.Port)
-	assert.Equal(t, "a", d.Path)
-
-}
-
 func TestInitialise(t *testing.T) {
 
 	cb := tests.New().TempGopath(true)
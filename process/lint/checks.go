@@ -0,0 +1,230 @@
+package lint
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/davelondon/kerr"
+	"kego.io/json"
+	"kego.io/process/validate/selectors"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+func init() {
+	Register(typeDescription{})
+	Register(unusedAlias{})
+	Register(unusedType{})
+	Register(defaultEnum{})
+	Register(emptySelector{})
+}
+
+// references returns the nodes in n that hold a reference.
+func references(n *node.Node) []*node.Node {
+	out := []*node.Node{}
+	for _, child := range n.Flatten(true) {
+		if r, ok := child.Value.(*system.Reference); ok && r != nil && !child.Null {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// typeDescription reports types with no description.
+type typeDescription struct{}
+
+func (typeDescription) Id() string         { return "type-description" }
+func (typeDescription) Severity() Severity { return SEVERITY_WARNING }
+
+func (typeDescription) Run(ctx context.Context, p *Package) ([]Problem, error) {
+	problems := []Problem{}
+	for _, f := range p.Types() {
+		t := f.Node.Value.(*system.Type)
+		if t.Description == "" {
+			problems = append(problems, Problem{File: f.Name, Node: f.Node, Message: fmt.Sprintf("Type %s has no description", t.Id.Name)})
+		}
+	}
+	return problems, nil
+}
+
+// unusedAlias reports aliases in the system:package object that aren't used by any reference
+// in the package.
+type unusedAlias struct{}
+
+func (unusedAlias) Id() string         { return "unused-alias" }
+func (unusedAlias) Severity() Severity { return SEVERITY_WARNING }
+
+func (unusedAlias) Run(ctx context.Context, p *Package) ([]Problem, error) {
+	used := map[string]bool{}
+	var pkg File
+	for _, f := range p.Files {
+		if f.Node.Type != nil && *f.Node.Type.Id == *system.NewReference("kego.io/system", "package") {
+			pkg = f
+		}
+		for _, n := range references(f.Node) {
+			used[n.Value.(*system.Reference).Package] = true
+		}
+	}
+	problems := []Problem{}
+	if pkg.Node == nil {
+		return problems, nil
+	}
+	for _, n := range node.SortNodeMap(pkg.Node.Map["aliases"].Map) {
+		if !used[n.ValueString] {
+			problems = append(problems, Problem{File: pkg.Name, Node: n, Message: fmt.Sprintf("Alias %s (%s) is not used", n.Key, n.ValueString)})
+		}
+	}
+	return problems, nil
+}
+
+// unusedType reports types that aren't referred to by any type or global in the package. Types
+// that are only used by other packages are reported too, so library packages will usually turn
+// this check off.
+type unusedType struct{}
+
+func (unusedType) Id() string         { return "unused-type" }
+func (unusedType) Severity() Severity { return SEVERITY_INFO }
+
+func (unusedType) Run(ctx context.Context, p *Package) ([]Problem, error) {
+	used := map[system.Reference]bool{}
+	for _, f := range p.Files {
+		for _, n := range references(f.Node) {
+			if n.Parent == f.Node && n.Key == "id" {
+				// the id of a global or type doesn't refer to itself
+				continue
+			}
+			used[*n.Value.(*system.Reference)] = true
+		}
+	}
+	problems := []Problem{}
+	for _, f := range p.Types() {
+		id := f.Node.Value.(*system.Type).Id
+		if !used[*id] && !used[id.ChangeToRule()] {
+			problems = append(problems, Problem{File: f.Name, Node: f.Node, Message: fmt.Sprintf("Type %s is not used", id.Name)})
+		}
+	}
+	return problems, nil
+}
+
+// defaultEnum reports fields with a default that isn't one of the values in the enum.
+type defaultEnum struct{}
+
+func (defaultEnum) Id() string         { return "default-enum" }
+func (defaultEnum) Severity() Severity { return SEVERITY_ERROR }
+
+func (defaultEnum) Run(ctx context.Context, p *Package) ([]Problem, error) {
+	problems := []Problem{}
+	for _, f := range p.Types() {
+		for _, field := range node.SortNodeMap(f.Node.Map["fields"].Map) {
+			def, ok := field.Map["default"]
+			if !ok || def.Null {
+				continue
+			}
+			enum, ok := field.Map["enum"]
+			if !ok || enum.Null {
+				continue
+			}
+			found := false
+			for _, item := range enum.Array {
+				if value(item) == value(def) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				problems = append(problems, Problem{File: f.Name, Node: def, Message: fmt.Sprintf("Default %s of field %s is not in the enum", value(def), field.Key)})
+			}
+		}
+	}
+	return problems, nil
+}
+
+// value returns the json form of a native value.
+func value(n *node.Node) string {
+	switch n.JsonType {
+	case json.J_STRING:
+		return strconv.Quote(n.ValueString)
+	case json.J_NUMBER:
+		return strconv.FormatFloat(n.ValueNumber, 'f', -1, 64)
+	case json.J_BOOL:
+		return strconv.FormatBool(n.ValueBool)
+	}
+	return ""
+}
+
+// emptySelector reports rules with a selector that doesn't match any nodes. The rules of an
+// object are matched against the object. The rules of a type are matched against the
+// instances of the type in the package, so they aren't checked if there are none.
+type emptySelector struct{}
+
+func (emptySelector) Id() string         { return "empty-selector" }
+func (emptySelector) Severity() Severity { return SEVERITY_WARNING }
+
+func (emptySelector) Run(ctx context.Context, p *Package) ([]Problem, error) {
+
+	instances := map[system.Reference][]*node.Node{}
+	for _, f := range p.Files {
+		for _, n := range f.Node.Flatten(true) {
+			if n.Type != nil && n.JsonType == json.J_OBJECT {
+				instances[*n.Type.Id] = append(instances[*n.Type.Id], n)
+			}
+		}
+	}
+
+	problems := []Problem{}
+	for _, f := range p.Files {
+		for _, n := range f.Node.Flatten(true) {
+			rules, ok := n.Map["rules"]
+			if !ok || rules.Null {
+				continue
+			}
+			var targets []*node.Node
+			if isType(f.Node) {
+				if n != f.Node {
+					// rules of field rules and rule types apply to other nodes
+					continue
+				}
+				targets = instances[*n.Value.(*system.Type).Id]
+				if len(targets) == 0 {
+					continue
+				}
+			} else {
+				targets = []*node.Node{n}
+			}
+			for _, rule := range rules.Array {
+				selector, ok := rule.Map["selector"]
+				if !ok || selector.Null {
+					continue
+				}
+				matched, err := matches(ctx, targets, selector.ValueString)
+				if err != nil {
+					return nil, kerr.Wrap("FQTEXMUBAN", err)
+				}
+				if !matched {
+					problems = append(problems, Problem{File: f.Name, Node: selector, Message: fmt.Sprintf("Selector %s doesn't match any nodes", selector.ValueString)})
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+// matches is true if the selector matches a node in any of the targets.
+func matches(ctx context.Context, targets []*node.Node, selector string) (bool, error) {
+	for _, target := range targets {
+		p, err := selectors.CreateParser(ctx, target)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return false, kerr.Wrap("WRPUHNSJVC", err)
+		}
+		nodes, err := p.GetNodes(selector)
+		if err != nil {
+			return false, kerr.Wrap("BAKYLMQTGI", err)
+		}
+		if len(nodes) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,248 @@
+package lint // import "kego.io/process/lint"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/json"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+type Severity string
+
+const (
+	SEVERITY_OFF     Severity = "off"
+	SEVERITY_INFO    Severity = "info"
+	SEVERITY_WARNING Severity = "warning"
+	SEVERITY_ERROR   Severity = "error"
+)
+
+// Check is a lint check. Checks are registered with Register, and every registered check is run
+// by Package unless it's turned off in the lint config of the package.
+type Check interface {
+	// Id identifies the check in lint config, lint-ignore tags and the reported problems.
+	Id() string
+	// Severity is the severity of the problems if the lint config doesn't change it.
+	Severity() Severity
+	// Run returns the problems in the package. The check and severity of the problems are set
+	// by Package.
+	Run(ctx context.Context, p *Package) ([]Problem, error)
+}
+
+var checks = map[string]Check{}
+
+// Register adds a check. A check with the same id is replaced.
+func Register(c Check) {
+	checks[c.Id()] = c
+}
+
+// Checks returns the registered checks, sorted by id.
+func Checks() []Check {
+	out := []Check{}
+	for _, c := range checks {
+		out = append(out, c)
+	}
+	sort.Sort(sorter.New(
+		len(out),
+		func(i, j int) { out[i], out[j] = out[j], out[i] },
+		func(i, j int) bool { return out[i].Id() < out[j].Id() },
+	))
+	return out
+}
+
+// Problem is a problem found by a check. File is relative to the package directory.
+type Problem struct {
+	Check    string
+	Severity Severity
+	File     string
+	Node     *node.Node
+	Message  string
+}
+
+// Position returns the location of the node with the problem.
+func (p Problem) Position() json.Position {
+	pos := p.Node.Position
+	pos.File = p.File
+	return pos
+}
+
+// File is a data file in the package, relative to the package directory, and its root node.
+type File struct {
+	Name string
+	Node *node.Node
+}
+
+// Package holds the data files of the package that is being linted.
+type Package struct {
+	Info  *sysctx.SysPackageInfo
+	Files []File
+}
+
+// Types returns the files that hold type definitions.
+func (p *Package) Types() []File {
+	out := []File{}
+	for _, f := range p.Files {
+		if isType(f.Node) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func isType(n *node.Node) bool {
+	return n.Type != nil && *n.Type.Id == *system.NewReference("kego.io/system", "type")
+}
+
+// Lint runs the checks over the data files in the package in the env ctx. The severity of each
+// check can be changed, or the check turned off, by a system:lint-config global in the package.
+// There can only be one system:lint-config in the package.
+// Problems on a node tagged lint-ignore, or lint-ignore:<check>, or on one of its descendants,
+// aren't reported. The problems are returned sorted by file, position and path.
+func Lint(ctx context.Context) ([]Problem, error) {
+
+	env := envctx.FromContext(ctx)
+	pi, ok := sysctx.FromContext(ctx).Get(env.Path)
+	if !ok {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.New("XJQPHVEWMA", "%s not found in sys ctx", env.Path)
+	}
+
+	p := &Package{Info: pi}
+
+	files := scanner.ScanPackageToFiles(ctx, env)
+	bytes := scanner.ScanFilesToBytes(ctx, files)
+	for c := range bytes {
+		if c.Err != nil {
+			return nil, kerr.Wrap("NHCBUXKTAS", c.Err)
+		}
+		name, err := filepath.Rel(env.Dir, c.File)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("DMVKJPRCLO", err)
+		}
+		n, err := node.UnmarshalPositions(ctx, c.Bytes, c.Positions)
+		if err != nil {
+			return nil, kerr.Wrap("GQEYWNSBIR", err)
+		}
+		p.Files = append(p.Files, File{Name: name, Node: n})
+	}
+	sort.Sort(sorter.New(
+		len(p.Files),
+		func(i, j int) { p.Files[i], p.Files[j] = p.Files[j], p.Files[i] },
+		func(i, j int) bool { return p.Files[i].Name < p.Files[j].Name },
+	))
+
+	severities := map[string]Severity{}
+	config := ""
+	for _, f := range p.Files {
+		lc, ok := f.Node.Value.(*system.LintConfig)
+		if !ok {
+			continue
+		}
+		if config != "" {
+			return nil, kerr.New("DWNQFRJMYA", "A package can only have one system:lint-config, but found one in %s and %s", config, f.Name)
+		}
+		config = f.Name
+		for id, s := range lc.Checks {
+			severities[id] = Severity(s.Value())
+		}
+	}
+
+	problems := []Problem{}
+	for _, c := range Checks() {
+		severity, ok := severities[c.Id()]
+		if !ok {
+			severity = c.Severity()
+		}
+		if severity == SEVERITY_OFF {
+			continue
+		}
+		found, err := c.Run(ctx, p)
+		if err != nil {
+			return nil, kerr.Wrap("LWDGSOUYRF", err)
+		}
+		for _, problem := range found {
+			if ignored(problem.Node, c.Id()) {
+				continue
+			}
+			problem.Check = c.Id()
+			problem.Severity = severity
+			problems = append(problems, problem)
+		}
+	}
+
+	sort.Stable(sorter.New(
+		len(problems),
+		func(i, j int) { problems[i], problems[j] = problems[j], problems[i] },
+		func(i, j int) bool {
+			a, b := problems[i].Position(), problems[j].Position()
+			if a.File != b.File {
+				return a.File < b.File
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			if a.Column != b.Column {
+				return a.Column < b.Column
+			}
+			return problems[i].Node.Path() < problems[j].Node.Path()
+		},
+	))
+
+	return problems, nil
+}
+
+// ignored is true if the node or one of its ancestors is tagged lint-ignore or
+// lint-ignore:<check>.
+func ignored(n *node.Node, check string) bool {
+	for ; n != nil; n = n.Parent {
+		tags, ok := n.Map["tags"]
+		if !ok {
+			continue
+		}
+		for _, tag := range tags.Array {
+			if tag.ValueString == "lint-ignore" || tag.ValueString == "lint-ignore:"+check {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Failed is true if any of the problems has error severity.
+func Failed(problems []Problem) bool {
+	for _, p := range problems {
+		if p.Severity == SEVERITY_ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the problems, one per line, with the position, severity, check, path and
+// message.
+func Write(w io.Writer, problems []Problem) error {
+	lines := []string{}
+	for _, p := range problems {
+		lines = append(lines, fmt.Sprintf("%s: %s: %s: %s: %s", p.Position(), p.Severity, p.Check, p.Node.Path(), p.Message))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(lines, "\n")); err != nil {
+		return kerr.Wrap("KFXOAHBTRE", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestLint(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathB, _ := cb.TempPackage("b", map[string]string{
+		"thing.yaml": "type: system:type\nid: thing\ndescription: A thing\n",
+	})
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    x: " + pathB + "\n",
+		"page.yaml": `
+			type: system:type
+			id: page
+			description: A page
+			fields:
+				title:
+					type: system:@string
+					default: c
+					enum: [a, b]
+					optional: true
+				photo:
+					type: "@photo"
+					optional: true
+			rules:
+			-   type: system:@string
+				selector: ".title"
+			-   type: system:@string
+				selector: ".foo"
+		`,
+		"photo.yaml":  "type: system:type\nid: photo\n",
+		"orphan.yaml": "type: system:type\nid: orphan\ndescription: An orphan\n",
+		"hidden.yaml": "type: system:type\nid: hidden\ntags: [lint-ignore]\n",
+		"home.yaml": `
+			type: page
+			id: home
+			title: a
+			rules:
+			-   type: system:@string
+				selector: ".bar"
+		`,
+	})
+
+	cb.Path(pathA).Dir(dirA).Alias("x", pathB).Cmd().Jauto().Sauto(parser.Parse)
+
+	problems, err := Lint(cb.Ctx())
+	require.NoError(t, err)
+	assert.True(t, Failed(problems))
+
+	b := &bytes.Buffer{}
+	require.NoError(t, Write(b, problems))
+	assert.Equal(t, `home.yaml:7:27: warning: empty-selector: home/rules/0/selector: Selector .bar doesn't match any nodes
+orphan.yaml:1:1: info: unused-type: orphan: Type orphan is not used
+package.yaml:3:8: warning: unused-alias: root/aliases/x: Alias x (`+pathB+`) is not used
+page.yaml:8:30: error: default-enum: page/fields/title/default: Default "c" of field title is not in the enum
+page.yaml:18:27: warning: empty-selector: page/rules/1/selector: Selector .foo doesn't match any nodes
+photo.yaml:1:1: warning: type-description: photo: Type photo has no description
+`, b.String())
+
+	cb.TempFile("lint.yaml", `
+		type: system:lint-config
+		id: lint
+		checks:
+			default-enum: warning
+			empty-selector: "off"
+			unused-alias: "off"
+	`)
+	cb.TempFile("orphan.yaml", "type: system:type\nid: orphan\ndescription: An orphan\ntags: [lint-ignore:unused-type]\n")
+
+	problems, err = Lint(cb.Ctx())
+	require.NoError(t, err)
+	assert.False(t, Failed(problems))
+	assert.Equal(t, 2, len(problems))
+	assert.Equal(t, "default-enum", problems[0].Check)
+	assert.Equal(t, SEVERITY_WARNING, problems[0].Severity)
+	assert.Equal(t, "type-description", problems[1].Check)
+
+	cb.TempFile("lint2.yaml", "type: system:lint-config\nid: lint2\n")
+	_, err = Lint(cb.Ctx())
+	assert.IsError(t, err, "DWNQFRJMYA")
+	require.NoError(t, os.Remove(filepath.Join(dirA, "lint2.yaml")))
+
+	Register(testCheck{})
+	_, err = Lint(cb.Ctx())
+	delete(checks, "test")
+	assert.IsError(t, err, "LWDGSOUYRF")
+	assert.HasError(t, err, "RMIVQXTHCE")
+
+	require.NoError(t, os.Remove(filepath.Join(dirA, "lint.yaml")))
+	cb.TempFile("home.yaml", "type: page\nid: home\nrules: [{type: system:@string, selector: \"[\"}]\n")
+	_, err = Lint(cb.Ctx())
+	assert.HasError(t, err, "BAKYLMQTGI")
+
+	cb.TempFile("home.yaml", "type: page\nid: home\nfoo: bar\n")
+	_, err = Lint(cb.Ctx())
+	assert.IsError(t, err, "GQEYWNSBIR")
+
+	cb.TempFile("home.yaml", "type: page\nid: home\n\tfoo: bar\n")
+	_, err = Lint(cb.Ctx())
+	assert.IsError(t, err, "NHCBUXKTAS")
+}
+
+type testCheck struct{}
+
+func (testCheck) Id() string         { return "test" }
+func (testCheck) Severity() Severity { return SEVERITY_INFO }
+
+func (testCheck) Run(ctx context.Context, p *Package) ([]Problem, error) {
+	return nil, kerr.New("RMIVQXTHCE", "Test")
+}
+
+func TestChecks(t *testing.T) {
+	ids := []string{}
+	for _, c := range Checks() {
+		ids = append(ids, c.Id())
+	}
+	assert.Equal(t, []string{"default-enum", "empty-selector", "type-description", "unused-alias", "unused-type"}, ids)
+}
+
+func TestWrite(t *testing.T) {
+	b := &bytes.Buffer{}
+	require.NoError(t, Write(b, nil))
+	assert.Equal(t, "", b.String())
+}
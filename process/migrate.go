@@ -0,0 +1,36 @@
+package process
+
+import (
+	"io/ioutil"
+	"os"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/process/migrate"
+)
+
+// Migrate applies the pending migrations in the package, and writes the changed files and the
+// applied migrations. If check is true, the files are not written. The changes are returned in
+// the order they were made.
+func Migrate(ctx context.Context, check bool) ([]migrate.Change, error) {
+	result, err := migrate.Package(ctx)
+	if err != nil {
+		return nil, kerr.Wrap("WNHTAKQBXE", err)
+	}
+	if check {
+		return result.Changes, nil
+	}
+	for file, b := range result.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("SGIVXCMEOL", err)
+		}
+		if err := ioutil.WriteFile(file, b, info.Mode()); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("DYFOQUWTJA", err)
+		}
+	}
+	return result.Changes, nil
+}
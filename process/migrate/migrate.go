@@ -0,0 +1,597 @@
+package migrate // import "kego.io/process/migrate"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	yaml "gopkg.in/yaml.v3"
+	"kego.io/context/envctx"
+	"kego.io/json"
+	"kego.io/process/format"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+// Change is an object in a data file that was changed by an operation in a migration. The file
+// is relative to the package directory.
+type Change struct {
+	File      string
+	Path      string
+	Migration string
+	Operation string
+}
+
+// Result holds the changes made by the pending migrations, and the new contents of each file
+// that has changed, keyed by file name. The migrations are included in Files, marked as applied.
+type Result struct {
+	Changes []Change
+	Files   map[string][]byte
+}
+
+type migration struct {
+	file  string
+	index int
+	node  *node.Node
+	*system.Migration
+}
+
+type global struct {
+	file    string
+	index   int
+	rel     string
+	value   interface{}
+	changed bool
+	// moves are the fields that were renamed or moved, in order, so the comments in a yaml file
+	// can be moved with them.
+	moves []move
+}
+
+// move is a field that was renamed or moved. The paths are from the root of the object.
+type move struct {
+	from, to []string
+}
+
+// Package applies the pending migrations in the package to every other global. Migrations are
+// applied in order of id, and the operations in each migration in order. The data is changed as
+// untyped json, because it won't unmarshal into the types until it has been migrated, so objects
+// are matched by their type field or the rule of their parent field. Each changed file is then
+// unmarshaled to check it matches the types, and returned in canonical form. The comments in a
+// yaml file are kept, and move with the fields that are renamed or moved. Nothing is written, so
+// the caller can report the changes first.
+func Package(ctx context.Context) (*Result, error) {
+
+	env := envctx.FromContext(ctx)
+
+	migrations := []*migration{}
+	globals := []*global{}
+
+	// The objects in each file are kept, so when one of them changes the file can be written with
+	// all of them. replaced holds the changed objects by file and index.
+	objects := map[string][][]byte{}
+	multi := map[string]bool{}
+	replaced := map[string]map[int]*node.Node{}
+
+	files := scanner.ScanPackageToFiles(ctx, env)
+	bytes := scanner.ScanFilesToBytes(ctx, files)
+	for c := range bytes {
+		if c.Err != nil {
+			return nil, kerr.Wrap("QJWVRBTFEA", c.Err)
+		}
+		rel, err := c.Name(env.Dir)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("HMGCSXNDAU", err)
+		}
+		objects[c.File] = append(objects[c.File], c.Bytes)
+		multi[c.File] = c.Multi
+		var v interface{}
+		if err := json.UnmarshalPlain(c.Bytes, &v); err != nil {
+			return nil, kerr.Wrap("BKPELWYTXO", err)
+		}
+		t, err := typeOf(ctx, v)
+		if err != nil {
+			return nil, kerr.Wrap("VXDNQOMAGR", err)
+		}
+		if t != nil && *t == *system.NewReference("kego.io/system", "migration") {
+			n, err := node.Unmarshal(ctx, c.Bytes)
+			if err != nil {
+				return nil, kerr.Wrap("FCAHUKYWSL", err)
+			}
+			m, ok := n.Value.(*system.Migration)
+			if !ok {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.New("OWTLQDEPNI", "%T is not a *system.Migration", n.Value)
+			}
+			if m.Id == nil {
+				return nil, kerr.New("NYIRXGBVHK", "Migration in %s has no id", rel)
+			}
+			if !m.Applied {
+				migrations = append(migrations, &migration{file: c.File, index: c.Index, node: n, Migration: m})
+			}
+			continue
+		}
+		globals = append(globals, &global{file: c.File, index: c.Index, rel: rel, value: v})
+	}
+
+	sort.Sort(sorter.New(
+		len(migrations),
+		func(i, j int) { migrations[i], migrations[j] = migrations[j], migrations[i] },
+		func(i, j int) bool { return migrations[i].Id.Name < migrations[j].Id.Name },
+	))
+
+	result := &Result{Files: map[string][]byte{}}
+
+	for _, m := range migrations {
+		for _, op := range m.Operations {
+			for _, g := range globals {
+				w := &walker{ctx: ctx, op: op, migration: m.Id.Name, global: g}
+				if err := w.walk(g.value, root(g.value), nil, nil); err != nil {
+					return nil, kerr.Wrap("DSUMFVLHCE", err)
+				}
+				result.Changes = append(result.Changes, w.changes...)
+			}
+		}
+		if err := m.node.Map["applied"].SetValueBool(ctx, true); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("GYAOQRNMTW", err)
+		}
+		replace(replaced, m.file, m.index, m.node)
+	}
+
+	for _, g := range globals {
+		if !g.changed {
+			continue
+		}
+		b, err := json.MarshalPlain(g.value)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("TWLCGENQAO", err)
+		}
+		n, err := node.Unmarshal(ctx, b)
+		if err != nil {
+			return nil, kerr.New("KRBMDOAIVF", "Migrated data in %s doesn't match the types: %s", g.rel, err)
+		}
+		replace(replaced, g.file, g.index, n)
+	}
+
+	for file, changed := range replaced {
+		nodes := []*node.Node{}
+		for i, b := range objects[file] {
+			n, ok := changed[i]
+			if !ok {
+				var err error
+				if n, err = node.Unmarshal(ctx, b); err != nil {
+					return nil, kerr.New("DWQGTLHNYE", "Data in %s doesn't match the types: %s", scanner.DocumentName(file, i, true), err)
+				}
+			}
+			nodes = append(nodes, n)
+		}
+		b, err := encode(ctx, file, multi[file], nodes, globals)
+		if err != nil {
+			return nil, kerr.Wrap("OXMEQBJRWA", err)
+		}
+		result.Files[file] = b
+	}
+
+	return result, nil
+}
+
+// encode returns the canonical form of a file with the objects in nodes. The comments in a yaml
+// file are moved with the fields the globals in the file have moved, and then kept.
+func encode(ctx context.Context, file string, multi bool, nodes []*node.Node, globals []*global) ([]byte, error) {
+	original, err := ioutil.ReadFile(file)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("XUEVHGPSKC", err)
+	}
+	if filepath.Ext(file) == ".json" {
+		b, err := format.Objects(ctx, file, original, nodes, multi)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("PHXKEIVUCJ", err)
+		}
+		return b, nil
+	}
+	sources, err := scanner.YamlDocuments(original)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("CQEXMTBSWH", err)
+	}
+	for _, g := range globals {
+		if g.file != file || g.index >= len(sources) {
+			continue
+		}
+		for _, m := range g.moves {
+			moveYaml(sources[g.index], m.from, m.to)
+		}
+	}
+	b, err := format.YamlObjects(ctx, sources, nodes)
+	if err != nil {
+		return nil, kerr.Wrap("GIWLPYDNRT", err)
+	}
+	return b, nil
+}
+
+// moveYaml moves the key and value at from in a yaml document to to, creating mappings along
+// the path as needed, so their comments are kept at the new path. If either path can't be
+// found, e.g. because it's through an alias, nothing is moved.
+func moveYaml(doc *yaml.Node, from, to []string) {
+	if len(doc.Content) == 0 {
+		return
+	}
+	parent := yamlChild(doc.Content[0], from[:len(from)-1], false)
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if key := parent.Content[i]; key.Kind == yaml.ScalarNode && key.Value == from[len(from)-1] {
+			value := parent.Content[i+1]
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			target := yamlChild(doc.Content[0], to[:len(to)-1], true)
+			if target == nil || target.Kind != yaml.MappingNode {
+				return
+			}
+			key.Value = to[len(to)-1]
+			target.Content = append(target.Content, key, value)
+			return
+		}
+	}
+}
+
+// yamlChild returns the node at path in a yaml node, or nil if it isn't found. If create is
+// true, missing mappings along the path are added.
+func yamlChild(n *yaml.Node, path []string, create bool) *yaml.Node {
+	for _, k := range path {
+		var child *yaml.Node
+		switch n.Kind {
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(k); err == nil && i >= 0 && i < len(n.Content) {
+				child = n.Content[i]
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if key := n.Content[i]; key.Kind == yaml.ScalarNode && key.Value == k {
+					child = n.Content[i+1]
+				}
+			}
+			if child == nil && create {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, child)
+			}
+		}
+		if child == nil {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
+// replace adds a changed object in a file that holds several objects.
+func replace(replaced map[string]map[int]*node.Node, file string, index int, n *node.Node) {
+	if replaced[file] == nil {
+		replaced[file] = map[int]*node.Node{}
+	}
+	replaced[file][index] = n
+}
+
+// root returns the label of the root node, in the same way as node.Label.
+func root(v interface{}) string {
+	if m, ok := v.(map[string]interface{}); ok {
+		if id, ok := m["id"].(string); ok && id != "" {
+			return id
+		}
+	}
+	return "root"
+}
+
+// typeOf returns the type in the type field of an untyped object, or nil if it has none.
+func typeOf(ctx context.Context, v interface{}) (*system.Reference, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	s, ok := m["type"].(string)
+	if !ok {
+		return nil, nil
+	}
+	r, err := system.NewReferenceFromString(ctx, s)
+	if err != nil {
+		return nil, kerr.Wrap("LQYGMTAECW", err)
+	}
+	return r, nil
+}
+
+// walker applies an operation to a global.
+type walker struct {
+	ctx       context.Context
+	op        system.Operation
+	migration string
+	global    *global
+	changes   []Change
+}
+
+// walk applies the operation to an untyped value and its descendants. at is the path of the value
+// from the root of the global. The rule is the rule of the parent field, or nil if it's unknown.
+func (w *walker) walk(v interface{}, path string, at []string, rule *system.RuleWrapper) error {
+	var items *system.RuleWrapper
+	if rule != nil && rule.IsCollection() {
+		if _, ok := rule.Interface.(system.CollectionRule); ok {
+			r, err := rule.ItemsRule()
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("EJNPTAWKVQ", err)
+			}
+			items = r
+		}
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		for i, item := range v {
+			if err := w.walk(item, path+"/"+strconv.Itoa(i), child(at, strconv.Itoa(i)), items); err != nil {
+				return kerr.Wrap("MAQOHWUXKE", err)
+			}
+		}
+	case map[string]interface{}:
+		if rule != nil && rule.Parent.IsNativeMap() {
+			for _, k := range keys(v) {
+				if err := w.walk(v[k], path+"/"+k, child(at, k), items); err != nil {
+					return kerr.Wrap("YBCGRELUDT", err)
+				}
+			}
+			return nil
+		}
+		if err := w.object(v, path, at, rule); err != nil {
+			return kerr.Wrap("SNPGWKJVFO", err)
+		}
+	}
+	return nil
+}
+
+func (w *walker) object(v map[string]interface{}, path string, at []string, rule *system.RuleWrapper) error {
+	var t *system.Reference
+	if rule != nil && !rule.Parent.Interface && (rule.Struct == nil || !rule.Struct.Interface) {
+		t = rule.Parent.Id
+	}
+	explicit, err := typeOf(w.ctx, v)
+	if err != nil {
+		return kerr.Wrap("CIXRHFWEBN", err)
+	}
+	if explicit != nil {
+		t = explicit
+	}
+	changed, err := w.apply(v, at, t, explicit)
+	if err != nil {
+		return kerr.Wrap("UQLDVOTYMG", err)
+	}
+	if changed {
+		w.global.changed = true
+		w.changes = append(w.changes, Change{
+			File:      w.global.rel,
+			Path:      path,
+			Migration: w.migration,
+			Operation: w.op.Summary(),
+		})
+		// The operation may have changed the type.
+		if explicit, err = typeOf(w.ctx, v); err != nil {
+			// ke: {"block": {"notest": true}}
+			return kerr.Wrap("RPJAXNWYSE", err)
+		}
+		if explicit != nil {
+			t = explicit
+		}
+	}
+	for _, k := range keys(v) {
+		r, err := fieldRule(w.ctx, t, k)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return kerr.Wrap("WOFHJDKMQI", err)
+		}
+		if err := w.walk(v[k], path+"/"+k, child(at, k), r); err != nil {
+			return kerr.Wrap("AZVKTNCUGL", err)
+		}
+	}
+	return nil
+}
+
+// apply applies the operation to an object of type t at path at, and returns true if it was
+// changed. The explicit type is the type field of the object, or nil if it has none.
+func (w *walker) apply(v map[string]interface{}, at []string, t, explicit *system.Reference) (bool, error) {
+	switch o := w.op.(type) {
+	case *system.RenameField:
+		if !matches(t, o.Target) {
+			return false, nil
+		}
+		value, ok := v[o.From]
+		if !ok {
+			return false, nil
+		}
+		if _, ok := v[o.To]; ok {
+			return false, kerr.New("EGVSTMBUFQ", "Can't rename %s to %s: %s already exists", o.From, o.To, o.To)
+		}
+		delete(v, o.From)
+		v[o.To] = value
+		w.move(at, []string{o.From}, []string{o.To})
+		return true, nil
+	case *system.MoveField:
+		if !matches(t, o.Target) {
+			return false, nil
+		}
+		value, ok := remove(v, strings.Split(o.From, "/"))
+		if !ok {
+			return false, nil
+		}
+		if err := insert(v, strings.Split(o.To, "/"), value); err != nil {
+			return false, kerr.Wrap("JTMVEXSOQB", err)
+		}
+		w.move(at, strings.Split(o.From, "/"), strings.Split(o.To, "/"))
+		return true, nil
+	case *system.WrapValue:
+		if !matches(t, o.Target) {
+			return false, nil
+		}
+		value, ok := v[o.Field]
+		if !ok {
+			return false, nil
+		}
+		if o.Wrapper == nil {
+			return false, kerr.New("HFLIYWQDZR", "wrap-value has no wrapper type")
+		}
+		wrapper, err := o.Wrapper.ValueContext(w.ctx)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return false, kerr.Wrap("OCMUJNBRAT", err)
+		}
+		v[o.Field] = map[string]interface{}{"type": wrapper, o.Key: value}
+		w.move(at, []string{o.Field}, []string{o.Field, o.Key})
+		return true, nil
+	case *system.SetDefault:
+		if !matches(t, o.Target) {
+			return false, nil
+		}
+		if _, ok := v[o.Field]; ok {
+			return false, nil
+		}
+		var value interface{}
+		if err := json.UnmarshalPlain([]byte(o.Value), &value); err != nil {
+			return false, kerr.Wrap("VGKQPDHSXL", err)
+		}
+		v[o.Field] = value
+		return true, nil
+	case *system.ChangeTypeReference:
+		// Only the type field is changed, so objects that get their type from a rule are
+		// unchanged. Rules for the type are changed to rules for the new type.
+		if explicit == nil || o.From == nil {
+			return false, nil
+		}
+		if o.To == nil {
+			return false, kerr.New("RXWABNSUGI", "change-type-reference has no new type")
+		}
+		var to system.Reference
+		switch *explicit {
+		case *o.From:
+			to = *o.To
+		case o.From.ChangeToRule():
+			to = o.To.ChangeToRule()
+		default:
+			return false, nil
+		}
+		s, err := to.ValueContext(w.ctx)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return false, kerr.Wrap("NDLSBEMFXY", err)
+		}
+		v["type"] = s
+		return true, nil
+	}
+	// ke: {"block": {"notest": true}}
+	return false, kerr.New("PUEYCAKHWO", "Unknown operation %T", w.op)
+}
+
+// move records that a field of the object at path at was moved.
+func (w *walker) move(at, from, to []string) {
+	w.global.moves = append(w.global.moves, move{from: child(at, from...), to: child(at, to...)})
+}
+
+// child returns a new path with keys added to the end of path.
+func child(path []string, keys ...string) []string {
+	return append(append([]string{}, path...), keys...)
+}
+
+func matches(t, target *system.Reference) bool {
+	return t != nil && target != nil && *t == *target
+}
+
+// fieldRule returns the rule for a field of type t, or nil if the type or field is unknown.
+func fieldRule(ctx context.Context, t *system.Reference, name string) (*system.RuleWrapper, error) {
+	if t == nil {
+		return nil, nil
+	}
+	typ, ok, err := t.GetType(ctx)
+	if err != nil {
+		return nil, kerr.Wrap("SXFKDMOQNL", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	for _, origin := range typ.FieldOrigins() {
+		o, ok, err := origin.GetType(ctx)
+		if err != nil {
+			return nil, kerr.Wrap("UMVQHRAJEB", err)
+		}
+		if !ok {
+			continue
+		}
+		if rule, ok := o.Fields[name]; ok {
+			return system.WrapRule(ctx, rule)
+		}
+	}
+	return nil, nil
+}
+
+// remove deletes the value at path in an object, and returns it.
+func remove(v map[string]interface{}, path []string) (interface{}, bool) {
+	for _, k := range path[:len(path)-1] {
+		child, ok := v[k].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		v = child
+	}
+	value, ok := v[path[len(path)-1]]
+	if ok {
+		delete(v, path[len(path)-1])
+	}
+	return value, ok
+}
+
+// insert sets the value at path in an object, creating objects along the path as needed.
+func insert(v map[string]interface{}, path []string, value interface{}) error {
+	for _, k := range path[:len(path)-1] {
+		switch child := v[k].(type) {
+		case nil:
+			c := map[string]interface{}{}
+			v[k] = c
+			v = c
+		case map[string]interface{}:
+			v = child
+		default:
+			return kerr.New("FXOKWGLTJD", "Can't move to %s: %s is not an object", strings.Join(path, "/"), k)
+		}
+	}
+	if _, ok := v[path[len(path)-1]]; ok {
+		return kerr.New("QMSDYIVHNR", "Can't move to %s: the field already exists", strings.Join(path, "/"))
+	}
+	v[path[len(path)-1]] = value
+	return nil
+}
+
+func keys(v map[string]interface{}) []string {
+	out := []string{}
+	for k := range v {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Write writes the changes, one per line, as "file: path: migration: operation".
+func Write(w io.Writer, changes []Change) error {
+	for _, c := range changes {
+		if _, err := fmt.Fprintf(w, "%s: %s: %s: %s\n", c.File, c.Path, c.Migration, c.Operation); err != nil {
+			return kerr.Wrap("LKDWUTBHYX", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package migrate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestPackage(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				name:
+					type: system:@string
+					optional: true
+				body:
+					type: "@b"
+					optional: true
+				list:
+					type: system:@array
+					items:
+						type: "@b"
+					optional: true
+				meta:
+					type: "@c"
+					optional: true
+				label:
+					type: "@c"
+					optional: true
+				count:
+					type: system:@number
+					optional: true
+		`,
+		"b.yaml": `
+			type: system:type
+			id: b
+			fields:
+				text:
+					type: system:@string
+					optional: true
+		`,
+		"c.yaml": `
+			type: system:type
+			id: c
+			fields:
+				size:
+					type: system:@number
+					optional: true
+				text:
+					type: system:@string
+					optional: true
+		`,
+		"f.yaml": `
+			type: a
+			id: f
+			title: foo
+			body:
+				value: bar
+			list:
+				-   value: baz
+			size: 2
+			label: qux
+		`,
+		"g.json": `{"type": "old", "id": "g", "title": "x"}`,
+		"h.json": `[{"type": "a", "id": "h", "title": "y"}, {"type": "b", "id": "i", "text": "z"}]`,
+		"m0.yaml": `
+			type: system:migration
+			id: m0
+			applied: true
+			operations:
+				-   type: system:rename-field
+					target: a
+					from: name
+					to: other
+		`,
+		"m1.yaml": `
+			type: system:migration
+			id: m2
+			operations:
+				-   type: system:rename-field
+					target: a
+					from: heading
+					to: name
+				-   type: system:set-default
+					target: a
+					field: count
+					value: "1"
+		`,
+		"m2.yaml": `
+			type: system:migration
+			id: m1
+			operations:
+				-   type: system:change-type-reference
+					from: old
+					to: a
+				-   type: system:rename-field
+					target: a
+					from: title
+					to: heading
+				-   type: system:rename-field
+					target: b
+					from: value
+					to: text
+				-   type: system:move-field
+					target: a
+					from: size
+					to: meta/size
+				-   type: system:wrap-value
+					target: a
+					field: label
+					wrapper: c
+					key: text
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	result, err := Package(cb.Ctx())
+	require.NoError(t, err)
+
+	b := &bytes.Buffer{}
+	require.NoError(t, Write(b, result.Changes))
+	assert.Equal(t, `g.json: g: m1: change-type-reference `+path+`:old to `+path+`:a
+f.yaml: f: m1: rename-field `+path+`:a: title to heading
+g.json: g: m1: rename-field `+path+`:a: title to heading
+h.json:0: h: m1: rename-field `+path+`:a: title to heading
+f.yaml: f/body: m1: rename-field `+path+`:b: value to text
+f.yaml: f/list/0: m1: rename-field `+path+`:b: value to text
+f.yaml: f: m1: move-field `+path+`:a: size to meta/size
+f.yaml: f: m1: wrap-value `+path+`:a: label in `+path+`:c
+f.yaml: f: m2: rename-field `+path+`:a: heading to name
+g.json: g: m2: rename-field `+path+`:a: heading to name
+h.json:0: h: m2: rename-field `+path+`:a: heading to name
+f.yaml: f: m2: set-default `+path+`:a: count to 1
+g.json: g: m2: set-default `+path+`:a: count to 1
+h.json:0: h: m2: set-default `+path+`:a: count to 1
+`, b.String())
+
+	assert.Equal(t, 5, len(result.Files))
+	assert.Equal(t, `type: a
+id: f
+body:
+    text: bar
+count: 1
+label:
+    type: c
+    text: qux
+list:
+    - text: baz
+meta:
+    size: 2
+name: foo
+`, string(result.Files[filepath.Join(dir, "f.yaml")]))
+	assert.Equal(t, "{\n\t\"type\": \"a\",\n\t\"id\": \"g\",\n\t\"count\": 1,\n\t\"name\": \"x\"\n}\n", string(result.Files[filepath.Join(dir, "g.json")]))
+	// All the objects in a file that holds several objects are written.
+	assert.Equal(t, "[\n\t{\n\t\t\"type\": \"a\",\n\t\t\"id\": \"h\",\n\t\t\"count\": 1,\n\t\t\"name\": \"y\"\n\t},\n\t{\n\t\t\"type\": \"b\",\n\t\t\"id\": \"i\",\n\t\t\"text\": \"z\"\n\t}\n]\n", string(result.Files[filepath.Join(dir, "h.json")]))
+	assert.Contains(t, string(result.Files[filepath.Join(dir, "m1.yaml")]), "applied: true")
+	assert.Contains(t, string(result.Files[filepath.Join(dir, "m2.yaml")]), "applied: true")
+}
+
+func TestPackageErrors(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				b:
+					type: system:@string
+					optional: true
+				c:
+					type: system:@string
+					optional: true
+		`,
+		"d.yaml": `
+			type: a
+			id: d
+			b: e
+			c: f
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	migration := func(contents string) error {
+		cb.TempFile("m.yaml", contents)
+		defer os.Remove(filepath.Join(dir, "m.yaml"))
+		_, err := Package(cb.Ctx())
+		return err
+	}
+
+	err := migration("type: system:migration\nid: m\noperations:\n    - type: system:rename-field\n      target: a\n      from: b\n      to: c\n")
+	assert.HasError(t, err, "EGVSTMBUFQ")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:rename-field\n      target: a\n      from: b\n      to: g\n")
+	assert.IsError(t, err, "KRBMDOAIVF")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:move-field\n      target: a\n      from: b\n      to: c/g\n")
+	assert.HasError(t, err, "FXOKWGLTJD")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:move-field\n      target: a\n      from: b\n      to: c\n")
+	assert.HasError(t, err, "QMSDYIVHNR")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:set-default\n      target: a\n      field: g\n      value: \"{\"\n")
+	assert.HasError(t, err, "VGKQPDHSXL")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:wrap-value\n      target: a\n      field: b\n      key: g\n")
+	assert.HasError(t, err, "HFLIYWQDZR")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:change-type-reference\n      from: a\n")
+	assert.HasError(t, err, "RXWABNSUGI")
+
+	err = migration("type: system:migration\noperations: []\n")
+	assert.IsError(t, err, "NYIRXGBVHK")
+
+	err = migration("type: system:migration\nid: m\nfoo: bar\n")
+	assert.IsError(t, err, "FCAHUKYWSL")
+
+	err = migration("type: foo:bar\n")
+	assert.IsError(t, err, "VXDNQOMAGR")
+
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:rename-field\n      target: a\n      from: b\n      to: g\n    - type: foo:bar\n")
+	assert.HasError(t, err, "FCAHUKYWSL")
+
+	cb.TempFile("h.json", "{")
+	_, err = Package(cb.Ctx())
+	assert.IsError(t, err, "BKPELWYTXO")
+	os.Remove(filepath.Join(dir, "h.json"))
+
+	cb.TempFile("i.json", `{"type": "a", "b": {"type": "foo:bar"}}`)
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:rename-field\n      target: a\n      from: c\n      to: g\n")
+	assert.HasError(t, err, "CIXRHFWEBN")
+	os.Remove(filepath.Join(dir, "i.json"))
+
+	cb.TempFile("k.json", `[{"type": "a", "id": "k", "c": "l"}, {"type": "a", "id": "m", "b": "o", "foo": "bar"}]`)
+	err = migration("type: system:migration\nid: m\noperations:\n    - type: system:set-default\n      target: a\n      field: b\n      value: '\"p\"'\n")
+	assert.HasError(t, err, "DWQGTLHNYE")
+	os.Remove(filepath.Join(dir, "k.json"))
+
+	cb.Dir(filepath.Join(dir, "j"))
+	_, err = Package(cb.Ctx())
+	assert.IsError(t, err, "QJWVRBTFEA")
+}
+
+func TestPackageComments(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				name:
+					type: system:@string
+					optional: true
+				meta:
+					type: "@b"
+					optional: true
+				label:
+					type: "@b"
+					optional: true
+		`,
+		"b.yaml": `
+			type: system:type
+			id: b
+			fields:
+				size:
+					type: system:@number
+					optional: true
+				text:
+					type: system:@string
+					optional: true
+		`,
+		"c.yaml": `
+			# c is an a
+			type: a
+			id: c
+			# the title
+			title: foo # inline
+			size: 2 # the size
+			# the label
+			label: bar
+		`,
+		"d.yaml": "# d\ntype: a\nid: d\n---\n# e\ntype: a\nid: e\ntitle: baz # inline\n",
+		"m.yaml": `
+			# m renames the title
+			type: system:migration
+			id: m
+			operations:
+				-   type: system:rename-field
+					target: a
+					from: title
+					to: name
+				-   type: system:move-field
+					target: a
+					from: size
+					to: meta/size
+				-   type: system:wrap-value
+					target: a
+					field: label
+					wrapper: b
+					key: text
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	result, err := Package(cb.Ctx())
+	require.NoError(t, err)
+
+	// The comments move with the fields that are renamed or moved.
+	assert.Equal(t, `# c is an a
+type: a
+id: c
+label:
+    type: b
+    # the label
+    text: bar
+meta:
+    size: 2 # the size
+# the title
+name: foo # inline
+`, string(result.Files[filepath.Join(dir, "c.yaml")]))
+	assert.Equal(t, "# d\ntype: a\nid: d\n---\n# e\ntype: a\nid: e\nname: baz # inline\n", string(result.Files[filepath.Join(dir, "d.yaml")]))
+	assert.Contains(t, string(result.Files[filepath.Join(dir, "m.yaml")]), "# m renames the title\n")
+
+	// A comment on a merge key can't be kept, so the file isn't migrated.
+	cb.TempFile("e.yaml", "type: a\nid: f\n<<: {title: x} # merged\n")
+	_, err = Package(cb.Ctx())
+	assert.IsError(t, err, "OXMEQBJRWA")
+	assert.HasError(t, err, "FMQIUZXOVD")
+}
@@ -0,0 +1,50 @@
+package process
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestMigrate(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.json": `{"type": "system:type", "id": "a", "fields": {"c": {"type": "system:@string", "optional": true}}}`,
+		"b.yaml": "type: a\nid: b\nd: foo\n",
+		"m.yaml": "type: system:migration\nid: m\noperations:\n    - type: system:rename-field\n      target: a\n      from: d\n      to: c\n",
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	changes, err := Migrate(cb.Ctx(), true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "b.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "type: a\nid: b\nd: foo\n", string(b))
+
+	changes, err = Migrate(cb.Ctx(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(changes))
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "b.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "type: a\nid: b\nc: foo\n", string(b))
+
+	changes, err = Migrate(cb.Ctx(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(changes))
+
+	cb.TempFile("e.json", "{")
+	_, err = Migrate(cb.Ctx(), false)
+	assert.IsError(t, err, "WNHTAKQBXE")
+}
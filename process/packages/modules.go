@@ -0,0 +1,319 @@
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/vosctx"
+)
+
+// Module is a Go module, read from its go.mod file.
+type Module struct {
+	Path     string            // module path
+	Dir      string            // directory containing go.mod
+	Requires map[string]string // module path -> version
+	Replaces []Replace
+}
+
+// Replace is a replace directive in a go.mod file. If Version is empty, all versions of Old are
+// replaced. If NewVersion is empty, New is a local directory.
+type Replace struct {
+	Old        string
+	Version    string
+	New        string
+	NewVersion string
+}
+
+// FindModule finds the go.mod file in dir or one of its parents. If modules are disabled with
+// GO111MODULE=off, or there's no go.mod file, found is false.
+func FindModule(ctx context.Context, dir string) (module *Module, found bool, err error) {
+	vos := vosctx.FromContext(ctx)
+	if vos.Getenv("GO111MODULE") == "off" {
+		return nil, false, nil
+	}
+	for {
+		b, err := vos.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			m, err := ParseModule(dir, b)
+			if err != nil {
+				return nil, false, kerr.Wrap("PYIOTMGWAE", err)
+			}
+			return m, true, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, false, kerr.Wrap("HWQXSKCRUJ", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, false, nil
+		}
+		dir = parent
+	}
+}
+
+// currentModule finds the module containing the current directory.
+func currentModule(ctx context.Context) (*Module, bool, error) {
+	wd, err := vosctx.FromContext(ctx).Getwd()
+	if err != nil {
+		return nil, false, kerr.Wrap("DSMFCVIJRN", err)
+	}
+	m, found, err := FindModule(ctx, wd)
+	if err != nil {
+		return nil, false, kerr.Wrap("LWBNBNPFEJ", err)
+	}
+	return m, found, nil
+}
+
+// ParseModule parses the module, require and replace directives in a go.mod file.
+func ParseModule(dir string, data []byte) (*Module, error) {
+	m := &Module{Dir: dir, Requires: map[string]string{}}
+	block := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "//"); i > -1 {
+			text = text[:i]
+		}
+		fields, err := modFields(text)
+		if err != nil {
+			return nil, kerr.Wrap("QXJKCAXLVM", err)
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return nil, kerr.New("FXEYVDHNUX", "go.mod line %d: usage: module path", line)
+			}
+			m.Path = fields[1]
+		case "require":
+			if len(fields) != 3 {
+				return nil, kerr.New("KAUSHUJQLW", "go.mod line %d: usage: require module version", line)
+			}
+			m.Requires[fields[1]] = fields[2]
+		case "replace":
+			r, ok := parseReplace(fields[1:])
+			if !ok {
+				return nil, kerr.New("SCPYMJTXQR", "go.mod line %d: usage: replace module [version] => new [version]", line)
+			}
+			m.Replaces = append(m.Replaces, r)
+		}
+	}
+	if m.Path == "" {
+		return nil, kerr.New("WBMBAUJMEF", "go.mod in %s has no module directive", dir)
+	}
+	return m, nil
+}
+
+func parseReplace(fields []string) (r Replace, ok bool) {
+	arrow := -1
+	for i, f := range fields {
+		if f == "=>" {
+			arrow = i
+		}
+	}
+	if arrow < 1 || arrow > 2 || len(fields)-arrow-1 < 1 || len(fields)-arrow-1 > 2 {
+		return Replace{}, false
+	}
+	r.Old = fields[0]
+	if arrow == 2 {
+		r.Version = fields[1]
+	}
+	r.New = fields[arrow+1]
+	if len(fields) == arrow+3 {
+		r.NewVersion = fields[arrow+2]
+	}
+	return r, true
+}
+
+// modFields splits a go.mod line into fields. Fields may be quoted.
+func modFields(text string) ([]string, error) {
+	var fields []string
+	text = strings.TrimSpace(text)
+	for text != "" {
+		if text[0] == '"' || text[0] == '`' {
+			s, err := strconv.QuotedPrefix(text)
+			if err != nil {
+				return nil, kerr.Wrap("NKCRMPLSYS", err)
+			}
+			u, err := strconv.Unquote(s)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("OFLTVRGQUS", err)
+			}
+			fields = append(fields, u)
+			text = strings.TrimLeftFunc(text[len(s):], unicode.IsSpace)
+			continue
+		}
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end == -1 {
+			end = len(text)
+		}
+		fields = append(fields, text[:end])
+		text = strings.TrimLeftFunc(text[end:], unicode.IsSpace)
+	}
+	return fields, nil
+}
+
+// PackageDir returns the directory of the package in the module, its vendor directory, a replaced
+// module or the module cache. If the package isn't provided by a module in go.mod, ok is false.
+func (m *Module) PackageDir(ctx context.Context, path string) (dir string, ok bool) {
+	if rest, ok := trimModule(path, m.Path); ok {
+		return filepath.Join(m.Dir, rest), true
+	}
+
+	modulePath, version, ok := m.Provider(path)
+	if !ok {
+		return "", false
+	}
+	rest, _ := trimModule(path, modulePath)
+
+	if m.Vendor(ctx) {
+		return filepath.Join(m.Dir, "vendor", filepath.FromSlash(path)), true
+	}
+
+	if r, ok := m.replace(modulePath, version); ok {
+		if r.NewVersion == "" {
+			newDir := r.New
+			if !filepath.IsAbs(newDir) {
+				newDir = filepath.Join(m.Dir, newDir)
+			}
+			return filepath.Join(newDir, rest), true
+		}
+		modulePath, version = r.New, r.NewVersion
+	}
+
+	if version == "" {
+		return "", false
+	}
+	return filepath.Join(moduleCache(ctx), escapePath(modulePath)+"@"+escapePath(version), rest), true
+}
+
+// PackagePath returns the path of the package in dir, if dir is in the module or its vendor
+// directory.
+func (m *Module) PackagePath(dir string) (path string, ok bool) {
+	rel, err := filepath.Rel(m.Dir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		return m.Path, true
+	}
+	if strings.HasPrefix(rel, "vendor/") {
+		return strings.TrimPrefix(rel, "vendor/"), true
+	}
+	return m.Path + "/" + rel, true
+}
+
+// Provider returns the module in go.mod that provides the package, which is the longest matching
+// module path in the require and replace directives. The version is empty if the module is
+// replaced by all versions of a replace directive.
+func (m *Module) Provider(path string) (modulePath, version string, ok bool) {
+	for p, v := range m.Requires {
+		if _, ok := trimModule(path, p); ok && len(p) > len(modulePath) {
+			modulePath, version = p, v
+		}
+	}
+	for _, r := range m.Replaces {
+		if _, ok := trimModule(path, r.Old); ok && len(r.Old) > len(modulePath) {
+			modulePath, version = r.Old, ""
+		}
+	}
+	return modulePath, version, modulePath != ""
+}
+
+func (m *Module) replace(modulePath, version string) (Replace, bool) {
+	for _, r := range m.Replaces {
+		if r.Old == modulePath && (r.Version == "" || r.Version == version) {
+			return r, true
+		}
+	}
+	return Replace{}, false
+}
+
+// Vendor is true if packages are loaded from the vendor directory. As in the go command, this is
+// the default if vendor/modules.txt exists, unless it's overridden by -mod in GOFLAGS.
+func (m *Module) Vendor(ctx context.Context) bool {
+	vos := vosctx.FromContext(ctx)
+	for _, flag := range strings.Fields(vos.Getenv("GOFLAGS")) {
+		if strings.HasPrefix(flag, "-mod=") {
+			return flag == "-mod=vendor"
+		}
+	}
+	_, err := vos.ReadFile(filepath.Join(m.Dir, "vendor", "modules.txt"))
+	return err == nil
+}
+
+// trimModule returns the package path relative to the module path, as a file path.
+func trimModule(path, modulePath string) (rest string, ok bool) {
+	if path == modulePath {
+		return "", true
+	}
+	if strings.HasPrefix(path, modulePath+"/") {
+		return filepath.FromSlash(path[len(modulePath)+1:]), true
+	}
+	return "", false
+}
+
+// moduleCache returns GOMODCACHE, which defaults to pkg/mod in the first GOPATH.
+func moduleCache(ctx context.Context) string {
+	vos := vosctx.FromContext(ctx)
+	if c := vos.Getenv("GOMODCACHE"); c != "" {
+		return c
+	}
+	if gopath := filepath.SplitList(vos.Getenv("GOPATH")); len(gopath) > 0 && gopath[0] != "" {
+		return filepath.Join(gopath[0], "pkg", "mod")
+	}
+	return filepath.Join(vos.Getenv("HOME"), "go", "pkg", "mod")
+}
+
+// escapePath escapes a module path or version for the module cache. Upper case letters are
+// replaced by an exclamation mark followed by the lower case letter.
+func escapePath(path string) string {
+	var b bytes.Buffer
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteRune('!')
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Local is true if the package is in the module, or in a module that is replaced by a local
+// directory.
+func (m *Module) Local(path string) bool {
+	if _, ok := trimModule(path, m.Path); ok {
+		return true
+	}
+	for _, r := range m.Replaces {
+		if _, ok := trimModule(path, r.Old); ok && r.NewVersion == "" {
+			return true
+		}
+	}
+	return false
+}
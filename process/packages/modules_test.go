@@ -0,0 +1,209 @@
+package packages_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/packages"
+	"kego.io/tests"
+)
+
+func TestParseModule(t *testing.T) {
+	m, err := packages.ParseModule("/a", []byte(`
+		module a.b/c // comment
+
+		go 1.16
+
+		require d.e/f v1.0.0
+		require (
+			g.h/I v1.2.3 // indirect
+			"j.k/l" v0.1.0
+		)
+
+		replace d.e/f => ../f
+		replace (
+			g.h/I v1.2.3 => m.n/o v1.0.0
+		)
+	`))
+	require.NoError(t, err)
+	assert.Equal(t, "a.b/c", m.Path)
+	assert.Equal(t, "/a", m.Dir)
+	assert.Equal(t, map[string]string{"d.e/f": "v1.0.0", "g.h/I": "v1.2.3", "j.k/l": "v0.1.0"}, m.Requires)
+	assert.Equal(t, []packages.Replace{
+		{Old: "d.e/f", New: "../f"},
+		{Old: "g.h/I", Version: "v1.2.3", New: "m.n/o", NewVersion: "v1.0.0"},
+	}, m.Replaces)
+
+	_, err = packages.ParseModule("/a", []byte("go 1.16"))
+	assert.IsError(t, err, "WBMBAUJMEF")
+
+	_, err = packages.ParseModule("/a", []byte("module a b"))
+	assert.IsError(t, err, "FXEYVDHNUX")
+
+	_, err = packages.ParseModule("/a", []byte("module a\nrequire b"))
+	assert.IsError(t, err, "KAUSHUJQLW")
+
+	_, err = packages.ParseModule("/a", []byte("module a\nreplace b c"))
+	assert.IsError(t, err, "SCPYMJTXQR")
+
+	_, err = packages.ParseModule("/a", []byte("module \"a"))
+	assert.IsError(t, err, "QXJKCAXLVM")
+	assert.HasError(t, err, "NKCRMPLSYS")
+}
+
+func TestModule_PackageDir(t *testing.T) {
+	cb := tests.New().OsVar("GOMODCACHE", "/cache")
+
+	m := &packages.Module{
+		Path: "a.b/c",
+		Dir:  "/a",
+		Requires: map[string]string{
+			"d.e/f":     "v1.0.0",
+			"d.e/f/g":   "v2.0.0",
+			"g.h/I":     "v1.2.3",
+			"j.k/l":     "v0.1.0",
+			"p.q/r":     "v0.2.0",
+			"s.t/Upper": "v1.0.0-Beta",
+		},
+		Replaces: []packages.Replace{
+			{Old: "d.e/f", New: "../f"},
+			{Old: "g.h/I", Version: "v1.2.3", New: "m.n/o", NewVersion: "v1.0.0"},
+			{Old: "p.q/r", Version: "v0.1.0", New: "/r"},
+			{Old: "u.v/w", New: "/w"},
+		},
+	}
+
+	dir := func(path string) string {
+		d, ok := m.PackageDir(cb.Ctx(), path)
+		if !ok {
+			return ""
+		}
+		return filepath.ToSlash(d)
+	}
+
+	assert.Equal(t, "/a", dir("a.b/c"))
+	assert.Equal(t, "/a/d/e", dir("a.b/c/d/e"))
+	assert.Equal(t, "/f/x", dir("d.e/f/x"))
+	assert.Equal(t, "/cache/d.e/f/g@v2.0.0/x", dir("d.e/f/g/x"))
+	assert.Equal(t, "/cache/m.n/o@v1.0.0/x", dir("g.h/I/x"))
+	assert.Equal(t, "/cache/j.k/l@v0.1.0", dir("j.k/l"))
+	assert.Equal(t, "/cache/p.q/r@v0.2.0", dir("p.q/r"))
+	assert.Equal(t, "/cache/s.t/!upper@v1.0.0-!beta/x", dir("s.t/Upper/x"))
+	assert.Equal(t, "/w/x", dir("u.v/w/x"))
+	assert.Equal(t, "", dir("a.b/cd"))
+	assert.Equal(t, "", dir("x.y/z"))
+
+	cb.OsVar("GOFLAGS", "-mod=vendor")
+	assert.Equal(t, "/a/d/e", dir("a.b/c/d/e"))
+	assert.Equal(t, "/a/vendor/d.e/f/x", dir("d.e/f/x"))
+
+	cb.OsVar("GOFLAGS", "-mod=mod")
+	assert.Equal(t, "/f/x", dir("d.e/f/x"))
+
+	assert.True(t, m.Local("a.b/c/d"))
+	assert.True(t, m.Local("d.e/f"))
+	assert.True(t, m.Local("u.v/w/x"))
+	assert.False(t, m.Local("g.h/I"))
+	assert.False(t, m.Local("j.k/l"))
+}
+
+func TestModule_PackagePath(t *testing.T) {
+	m := &packages.Module{Path: "a.b/c", Dir: filepath.FromSlash("/a/b")}
+
+	path := func(dir string) string {
+		p, ok := m.PackagePath(filepath.FromSlash(dir))
+		if !ok {
+			return ""
+		}
+		return p
+	}
+
+	assert.Equal(t, "a.b/c", path("/a/b"))
+	assert.Equal(t, "a.b/c/d/e", path("/a/b/d/e"))
+	assert.Equal(t, "f.g/h", path("/a/b/vendor/f.g/h"))
+	assert.Equal(t, "", path("/a"))
+	assert.Equal(t, "", path("/a/bc"))
+}
+
+func TestModules(t *testing.T) {
+	root, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	write := func(file, contents string) {
+		file = filepath.Join(root, filepath.FromSlash(file))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0777))
+		require.NoError(t, ioutil.WriteFile(file, []byte(contents), 0666))
+	}
+	write("a/go.mod", "module a.b/c\n\nrequire d.e/f v1.0.0\n\nreplace g.h/i => ../i\n")
+	write("a/d/d.go", "package d")
+	write("i/go.mod", "module g.h/i\n")
+	write("i/j/j.go", "package j")
+	write("cache/d.e/f@v1.0.0/k/k.go", "package k")
+
+	cb := tests.New().OsVar("GOMODCACHE", filepath.Join(root, "cache")).OsVar("GOPATH", filepath.Join(root, "gopath")).OsWd(filepath.Join(root, "a", "d"))
+
+	dir, err := packages.GetDirFromPackage(cb.Ctx(), "a.b/c/d")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a", "d"), dir)
+
+	dir, err = packages.GetDirFromPackage(cb.Ctx(), "d.e/f/k")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "cache", "d.e", "f@v1.0.0", "k"), dir)
+
+	dir, err = packages.GetDirFromPackage(cb.Ctx(), "g.h/i/j")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "i", "j"), dir)
+
+	_, err = packages.GetDirFromPackage(cb.Ctx(), "d.e/f/l")
+	_, ok := err.(packages.NotFoundError)
+	assert.True(t, ok)
+
+	dir, err = packages.GetDirFromEmptyPackage(cb.Ctx(), "a.b/c/m")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "a", "m"), dir)
+
+	path, err := packages.GetPackageFromDir(cb.Ctx(), filepath.Join(root, "a", "d"))
+	require.NoError(t, err)
+	assert.Equal(t, "a.b/c/d", path)
+
+	path, err = packages.GetPackageFromDir(cb.Ctx(), filepath.Join(root, "i", "j"))
+	require.NoError(t, err)
+	assert.Equal(t, "g.h/i/j", path)
+
+	m, found, err := packages.FindModule(cb.Ctx(), filepath.Join(root, "a", "d"))
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "a.b/c", m.Path)
+
+	_, found, err = packages.FindModule(cb.Ctx(), filepath.Join(root, "cache"))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	write("b/go.mod", "require")
+	_, _, err = packages.FindModule(cb.Ctx(), filepath.Join(root, "b"))
+	assert.IsError(t, err, "PYIOTMGWAE")
+
+	// The go.mod file is read with the vos in the context.
+	cb.OsFile(filepath.Join(root, "l", "go.mod"), "module m.n/o\n")
+	m, found, err = packages.FindModule(cb.Ctx(), filepath.Join(root, "l", "p"))
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "m.n/o", m.Path)
+	assert.Equal(t, filepath.Join(root, "l"), m.Dir)
+
+	// The package dir is found with the vos in the context.
+	cb.OsWd(filepath.Join(root, "l")).OsFile(filepath.Join(root, "l", "q", "r.yaml"), "")
+	dir, err = packages.GetDirFromPackage(cb.Ctx(), "m.n/o/q")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "l", "q"), dir)
+
+	cb.OsVar("GO111MODULE", "off")
+	_, found, err = packages.FindModule(cb.Ctx(), filepath.Join(root, "a", "d"))
+	require.NoError(t, err)
+	assert.False(t, found)
+}
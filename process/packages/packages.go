@@ -4,22 +4,71 @@ package packages // import "kego.io/process/packages"
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/davelondon/gopackages"
+	"github.com/davelondon/kerr"
 	"kego.io/context/vosctx"
 )
 
+// NotFoundError is returned by GetDirFromPackage when the package directory can't be found.
+type NotFoundError struct {
+	kerr.Struct
+	Path string
+}
+
+// GetDirFromPackage returns the directory of a package. If the current directory is in a Go
+// module, the package is resolved with go.mod, including replace directives and the vendor
+// directory. Packages that aren't provided by the module are found in GOPATH.
 func GetDirFromPackage(ctx context.Context, packagePath string) (string, error) {
+	m, found, err := currentModule(ctx)
+	if err != nil {
+		return "", kerr.Wrap("GTMCEHYRBQ", err)
+	}
 	vos := vosctx.FromContext(ctx)
-	return gopackages.GetDirFromPackage(vos.Environ(), vos.Getenv("GOPATH"), packagePath)
+	if found {
+		if dir, ok := m.PackageDir(ctx, packagePath); ok {
+			if _, err := vos.Stat(dir); err == nil {
+				return dir, nil
+			}
+		}
+	}
+	dir, err := gopackages.GetDirFromPackage(vos.Environ(), vos.Getenv("GOPATH"), packagePath)
+	if err != nil {
+		if _, ok := kerr.Source(err).(gopackages.NotFoundError); ok {
+			return "", NotFoundError{Struct: kerr.New("QSJTXLAVNE", "Package %s not found", packagePath), Path: packagePath}
+		}
+		return "", kerr.Wrap("FEYXNQHPSC", err)
+	}
+	return dir, nil
 }
 
 func GetDirFromEmptyPackage(ctx context.Context, path string) (string, error) {
+	m, found, err := currentModule(ctx)
+	if err != nil {
+		return "", kerr.Wrap("BOQLCKUMUH", err)
+	}
+	if found {
+		if rest, ok := trimModule(path, m.Path); ok {
+			return filepath.Join(m.Dir, rest), nil
+		}
+	}
 	vos := vosctx.FromContext(ctx)
 	return gopackages.GetDirFromEmptyPackage(vos.Getenv("GOPATH"), path)
 }
 
+// GetPackageFromDir returns the path of the package in dir. If dir is in a Go module, the path is
+// relative to the module path, otherwise dir must be in GOPATH.
 func GetPackageFromDir(ctx context.Context, dir string) (string, error) {
+	m, found, err := FindModule(ctx, dir)
+	if err != nil {
+		return "", kerr.Wrap("CVXHKBPAQM", err)
+	}
+	if found {
+		if path, ok := m.PackagePath(dir); ok {
+			return path, nil
+		}
+	}
 	vos := vosctx.FromContext(ctx)
 	return gopackages.GetPackageFromDir(vos.Getenv("GOPATH"), dir)
 }
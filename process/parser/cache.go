@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"github.com/surge/cityhash"
+	"kego.io/context/envctx"
+	"kego.io/json"
+)
+
+const parseCacheFile = ".localke/parse.json"
+
+// parseCacheVersion should be incremented when the format of the cache changes, so old caches
+// are ignored.
+const parseCacheVersion = 4
+
+const (
+	kindType    = "type"
+	kindPackage = "package"
+	kindGlobal  = "global"
+)
+
+// parseCache records what was found in each data file of a package when it was last parsed, so
+// files that haven't changed aren't unmarshaled again. It's stored in .localke/parse.json in the
+// package dir. The files are keyed by the hash of their contents, so a file that is renamed or
+// moved keeps its entry. Imports, Aliases, Include and Exclude are the env the entries were found
+// with: the types and globals are resolved with the aliases, so they're only valid for the same
+// env.
+type parseCache struct {
+	Version int                    `json:"version"`
+	Path    string                 `json:"path"`
+	Imports map[string]uint64      `json:"imports"`
+	Aliases map[string]string      `json:"aliases"`
+	Include []string               `json:"include"`
+	Exclude []string               `json:"exclude"`
+	Files   map[string]*cacheEntry `json:"files"`
+	// saved is the json that was loaded, so the file isn't written if nothing has changed.
+	saved []byte
+}
+
+type cacheEntry struct {
+	Kind string `json:"kind"`
+	// Id is the name of the type or global.
+	Id string `json:"id,omitempty"`
+	// Type is the type of a global.
+	Type string `json:"type,omitempty"`
+	// Aliases, Recursive, Include and Exclude are only set for a package file in the root of the
+	// package dir.
+	Aliases   map[string]string `json:"aliases,omitempty"`
+	Recursive bool              `json:"recursive,omitempty"`
+	Include   []string          `json:"include,omitempty"`
+	Exclude   []string          `json:"exclude,omitempty"`
+}
+
+// loadCache returns the parse cache of the package. If the cache is missing or can't be read,
+// an empty cache is returned.
+func loadCache(dir string, path string) *parseCache {
+	c := &parseCache{Version: parseCacheVersion, Path: path, Files: map[string]*cacheEntry{}}
+	b, err := ioutil.ReadFile(filepath.Join(dir, parseCacheFile))
+	if err != nil {
+		return c
+	}
+	loaded := &parseCache{}
+	if err := json.UnmarshalPlain(b, loaded); err != nil {
+		return c
+	}
+	if loaded.Version != parseCacheVersion || loaded.Path != path || loaded.Files == nil {
+		return c
+	}
+	loaded.saved = b
+	return loaded
+}
+
+// setEnv records the env the entries are found with. If it's not the env of the cached entries,
+// the type and global entries are removed, so the files are unmarshaled again. The package
+// entries are kept, because the package object doesn't depend on the env.
+func (c *parseCache) setEnv(imports map[string]uint64, env *envctx.Env) {
+	aliases := map[string]string{}
+	for name, path := range env.Aliases {
+		aliases[name] = path
+	}
+	include := append([]string{}, env.Include...)
+	exclude := append([]string{}, env.Exclude...)
+	if !reflect.DeepEqual(imports, c.Imports) || !reflect.DeepEqual(aliases, c.Aliases) || !reflect.DeepEqual(include, c.Include) || !reflect.DeepEqual(exclude, c.Exclude) {
+		for key, e := range c.Files {
+			if e.Kind != kindPackage {
+				delete(c.Files, key)
+			}
+		}
+	}
+	c.Imports = imports
+	c.Aliases = aliases
+	c.Include = include
+	c.Exclude = exclude
+}
+
+// get returns the entry for the file contents. c may be nil, in which case nothing is found.
+func (c *parseCache) get(key string) (*cacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	e, ok := c.Files[key]
+	return e, ok
+}
+
+// save writes the cache to the package dir. Errors are ignored, because the cache is only an
+// optimisation and the package dir may be read only (e.g. in the module cache).
+func (c *parseCache) save(dir string) {
+	b, err := json.MarshalPlain(c)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return
+	}
+	if bytes.Equal(b, c.saved) {
+		return
+	}
+	file := filepath.Join(dir, parseCacheFile)
+	if err := os.MkdirAll(filepath.Dir(file), 0777); err != nil {
+		return
+	}
+	if err := ioutil.WriteFile(file, b, 0666); err != nil {
+		// ke: {"block": {"notest": true}}
+		return
+	}
+	c.saved = b
+}
+
+// fileHash returns the hash of the contents of a data file.
+func fileHash(b []byte) uint64 {
+	return cityhash.CityHash64(b, uint32(len(b)))
+}
+
+func fileKey(hash uint64) string {
+	return strconv.FormatUint(hash, 10)
+}
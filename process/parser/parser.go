@@ -5,15 +5,14 @@ package parser // import "kego.io/process/parser"
 import (
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
 
-	"github.com/surge/cityhash"
-
 	"path/filepath"
+	"sync"
 
 	"context"
 
-	"github.com/davelondon/gopackages"
 	"github.com/davelondon/kerr"
 	"kego.io/context/cmdctx"
 	"kego.io/context/envctx"
@@ -21,109 +20,245 @@ import (
 	"kego.io/context/vosctx"
 	"kego.io/json"
 	"kego.io/ke"
+	"kego.io/process/dag"
 	"kego.io/process/packages"
 	"kego.io/process/scanner"
 	"kego.io/system"
 )
 
+// Parse parses the types in the package and the packages it imports. The packages are found
+// first, one at a time, so go get is never run concurrently and circular imports are found.
+// Then the packages are parsed concurrently, with up to cmd.Workers at a time, each one after
+// the packages it imports. If more than one package fails, the errors are returned together.
 func Parse(ctx context.Context, path string) (*sysctx.SysPackageInfo, error) {
-	return parse(ctx, path, []string{})
+
+	scache := sysctx.FromContext(ctx)
+	cmd := cmdctx.FromContext(ctx)
+
+	if _, found := scache.Get("kego.io/json"); !found {
+		system.RegisterJsonTypes(ctx)
+	}
+
+	g := dag.New()
+	envs := map[string]*envctx.Env{}
+
+	// The errors from scan and Run aren't wrapped, so the first error id shows where in the
+	// package the error was found.
+	if err := scan(ctx, path, []string{}, g, envs); err != nil {
+		return nil, err
+	}
+
+	parse := func(path string) error {
+		return parsePackage(ctx, envs[path])
+	}
+	done := func(path string) {
+		cmd.Printf("Parsing %s... OK.\n", path)
+	}
+	if err := g.Run(cmd.Workers, parse, done); err != nil {
+		return nil, err
+	}
+
+	pcache, _ := scache.Get(path)
+	return pcache, nil
 }
-func parse(ctx context.Context, path string, queue []string) (*sysctx.SysPackageInfo, error) {
+
+// scan finds the env of the package and the packages it imports that aren't in the sys ctx, and
+// adds them to the graph. The queue holds the packages that import this one, so circular
+// imports are found.
+func scan(ctx context.Context, path string, queue []string, g *dag.Graph, envs map[string]*envctx.Env) error {
 
 	scache := sysctx.FromContext(ctx)
 	cmd := cmdctx.FromContext(ctx)
 
 	for _, q := range queue {
 		if q == path {
-			return nil, kerr.New("SCSCFJPPHD", "Circular import %v -> %v", queue, path)
+			return kerr.New("SCSCFJPPHD", "Circular import %v -> %v", queue, path)
 		}
 	}
 
-	if _, found := scache.Get("kego.io/json"); !found {
-		system.RegisterJsonTypes(ctx)
-	}
-
-	hash := &PackageHasher{Path: path, Aliases: map[string]string{}, Types: map[string]uint64{}}
-
-	importPackage := func(importPath string, importAlias string) error {
-		if _, found := scache.Get(importPath); !found {
-			_, err := parse(ctx, importPath, append(queue, path))
-			if err != nil {
-				return kerr.Wrap("RIARRSCMVE", err)
-			}
+	importPackage := func(importPath string) error {
+		if _, found := scache.Get(importPath); found {
+			return nil
+		}
+		if _, found := envs[importPath]; found {
+			return nil
+		}
+		if err := scan(ctx, importPath, append(queue, path), g, envs); err != nil {
+			return kerr.Wrap("RIARRSCMVE", err)
 		}
-		hash.Aliases[importAlias] = importPath
 		return nil
 	}
 
 	packageDirectoryExists := true
 	_, err := packages.GetDirFromPackage(ctx, path)
 	if err != nil {
-		_, ok := kerr.Source(err).(gopackages.NotFoundError)
+		_, ok := kerr.Source(err).(packages.NotFoundError)
 		if ok {
 			packageDirectoryExists = false
 		}
 	}
 	if !packageDirectoryExists || cmd.Update {
 		if err := GoGet(ctx, path); err != nil {
-			return nil, kerr.Wrap("SBALWXUPKN", err)
+			return kerr.Wrap("SBALWXUPKN", err)
 		}
 	}
 
 	env, err := ScanForEnv(ctx, path)
 	if err != nil {
-		return nil, kerr.Wrap("GJRHNGGWFD", err)
+		return kerr.Wrap("GJRHNGGWFD", err)
 	}
 
+	deps := []string{}
+
 	// Always scan the system package first if we don't have it already
 	if path != "kego.io/system" {
-		if err := importPackage("kego.io/system", "system"); err != nil {
-			return nil, kerr.Wrap("ORRCDNUPOX", err)
+		if err := importPackage("kego.io/system"); err != nil {
+			return kerr.Wrap("ORRCDNUPOX", err)
 		}
+		deps = append(deps, "kego.io/system")
 	}
 
-	for aliasName, aliasPath := range env.Aliases {
+	names := []string{}
+	for aliasName := range env.Aliases {
+		names = append(names, aliasName)
+	}
+	sort.Strings(names)
+	for _, aliasName := range names {
+		aliasPath := env.Aliases[aliasName]
 		if aliasPath == "kego.io/system" || aliasName == "system" {
-			return nil, kerr.New("EWMLNJDXKC", "Illegal import %s", aliasName)
+			return kerr.New("EWMLNJDXKC", "Illegal import %s", aliasName)
 		}
-		if err := importPackage(aliasPath, aliasName); err != nil {
-			return nil, kerr.Wrap("NOVMGYKHHI", err)
+		if err := importPackage(aliasPath); err != nil {
+			return kerr.Wrap("NOVMGYKHHI", err)
 		}
+		deps = append(deps, aliasPath)
 	}
 
-	pcache := scache.SetEnv(env)
+	envs[path] = env
+	g.Add(path, deps...)
+
+	return nil
+}
+
+// parsePackage parses the types in a package. The packages it imports must already be in the
+// sys ctx. Files that are in the parse cache aren't unmarshaled, and type files are only
+// unmarshaled when the type is first used. If the hash of an imported package, the aliases or the
+// include and exclude patterns have changed since the cache was written, the type and global
+// files are all unmarshaled again.
+func parsePackage(ctx context.Context, env *envctx.Env) error {
 
-	cmd.Printf("Parsing %s...", path)
+	scache := sysctx.FromContext(ctx)
 
-	if err := scanForTypes(ctx, env, pcache, hash); err != nil {
-		return nil, kerr.Wrap("VFUNPHUFHD", err)
+	hash := &PackageHasher{Path: env.Path, Aliases: map[string]string{}, Types: map[string]uint64{}}
+	if env.Path != "kego.io/system" {
+		hash.Aliases["system"] = "kego.io/system"
+	}
+	for aliasName, aliasPath := range env.Aliases {
+		hash.Aliases[aliasName] = aliasPath
 	}
 
-	cmd.Println(" OK.")
+	// The system package has no alias in the env, but it's always imported, so its hash is added
+	// explicitly.
+	importPaths := []string{}
+	if env.Path != "kego.io/system" {
+		importPaths = append(importPaths, "kego.io/system")
+	}
+	for _, aliasPath := range env.Aliases {
+		importPaths = append(importPaths, aliasPath)
+	}
+	imports := map[string]uint64{}
+	for _, importPath := range importPaths {
+		if pi, ok := scache.Get(importPath); ok {
+			imports[importPath] = pi.Hash
+		}
+	}
+	cache := loadCache(env.Dir, env.Path)
+	cache.setEnv(imports, env)
+
+	pcache := scache.SetEnv(env)
+
+	if err := scanForTypes(ctx, env, pcache, hash, cache); err != nil {
+		return kerr.Wrap("VFUNPHUFHD", err)
+	}
 
 	h, err := hash.Hash()
 	if err != nil {
-		return nil, kerr.Wrap("MIODRYNEJQ", err)
+		return kerr.Wrap("MIODRYNEJQ", err)
 	}
 	env.Hash = h
 
-	return pcache, nil
+	cache.save(env.Dir)
+
+	return nil
 }
 
+// GoGet downloads the package. If the current directory is in a Go module, the module that
+// provides the package must already be required, and it's downloaded to the module cache with go
+// mod download, so go.mod isn't changed. With -u, go get -u updates the module in go.mod, and in
+// vendor mode go mod vendor updates the vendor directory. Outside a module, go get -d downloads the
+// package to GOPATH.
 func GoGet(ctx context.Context, path string) error {
 	vos := vosctx.FromContext(ctx)
 	cmd := cmdctx.FromContext(ctx)
-	cmd.Print("Running go get -d ")
-	args := []string{"get", "-d"}
+
+	wd, err := vos.Getwd()
+	if err != nil {
+		return kerr.Wrap("XKXNUUBEQK", err)
+	}
+	m, modules, err := packages.FindModule(ctx, wd)
+	if err != nil {
+		return kerr.Wrap("MJGFTEWNUS", err)
+	}
+
+	if !modules {
+		args := []string{"get", "-d"}
+		if cmd.Update {
+			args = append(args, "-u")
+		}
+		if err := runGo(ctx, "", append(args, path)...); err != nil {
+			return kerr.Wrap("HGSXOWBNEK", err)
+		}
+		return nil
+	}
+
+	if m.Local(path) {
+		// Packages in the main module or a replacement directory can't be downloaded.
+		return nil
+	}
 	if cmd.Update {
-		cmd.Print("-u ")
-		args = append(args, "-u")
+		if err := runGo(ctx, m.Dir, "get", "-u", path); err != nil {
+			return kerr.Wrap("RDHXWOQMAT", err)
+		}
+		if m.Vendor(ctx) {
+			if err := runGo(ctx, m.Dir, "mod", "vendor"); err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("TVAKOPGQJW", err)
+			}
+		}
+		return nil
+	}
+	if m.Vendor(ctx) {
+		return kerr.New("JCWPKYTQEB", "Package %s not found in the vendor directory. Run go mod vendor to add it.", path)
+	}
+	modulePath, _, ok := m.Provider(path)
+	if !ok {
+		return kerr.New("VYMLGSXQEA", "Package %s isn't provided by a module required in %s. Run go get %s to add it.", path, filepath.Join(m.Dir, "go.mod"), path)
 	}
-	cmd.Print(path, "...")
-	args = append(args, path)
+	if err := runGo(ctx, m.Dir, "mod", "download", modulePath); err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("UFDMOXCLWS", err)
+	}
+	return nil
+}
+
+// runGo runs the go command in dir, or in the current directory if dir is empty.
+func runGo(ctx context.Context, dir string, args ...string) error {
+	vos := vosctx.FromContext(ctx)
+	cmd := cmdctx.FromContext(ctx)
+	cmd.Print("Running go ", strings.Join(args, " "), "...")
 	exe := exec.Command("go", args...)
 	exe.Env = vos.Environ()
+	exe.Dir = dir
 	if combined, err := exe.CombinedOutput(); err != nil {
 		if !strings.Contains(string(combined), "no buildable Go source files") {
 			return kerr.New("NIKCKQAKUI", "%s: %s", err.Error(), combined)
@@ -142,7 +277,7 @@ func ScanForEnv(ctx context.Context, path string) (env *envctx.Env, err error) {
 
 	env = &envctx.Env{Path: path, Aliases: map[string]string{}, Recursive: false, Dir: dir}
 
-	pkg, err := scanForPackage(ctx, env)
+	pkg, err := scanForPackage(ctx, env, loadCache(dir, path))
 	if err != nil {
 		return nil, kerr.Wrap("DTAEUSHJTQ", err)
 	}
@@ -150,6 +285,8 @@ func ScanForEnv(ctx context.Context, path string) (env *envctx.Env, err error) {
 	if pkg != nil {
 		env.Aliases = pkg.Aliases
 		env.Recursive = pkg.Recursive
+		env.Include = pkg.Include
+		env.Exclude = pkg.Exclude
 	}
 	return env, nil
 }
@@ -159,18 +296,41 @@ type objectStub struct {
 	Type *system.Reference `json:"type"`
 }
 
-func scanForTypes(ctx context.Context, env *envctx.Env, cache *sysctx.SysPackageInfo, hash *PackageHasher) error {
+// scanForTypes finds the types, globals and package object in the package. If cache is
+// not nil, the files it holds aren't unmarshaled, and its entries are replaced by the files that
+// were found.
+func scanForTypes(ctx context.Context, env *envctx.Env, pcache *sysctx.SysPackageInfo, hash *PackageHasher, cache *parseCache) error {
 
 	// While we're scanning for types, we should use a custom unpacking env, because the env from
 	// the context is the one of the local package.
 
-	files := scanner.ScanDirToFiles(ctx, env.Dir, env.Recursive)
+	files := scanner.ScanPackageToFiles(ctx, env)
 	bytes := scanner.ScanFilesToBytes(ctx, files)
 	localContext := envctx.NewContext(ctx, env)
+	found := map[string]*cacheEntry{}
 	for b := range bytes {
 		if b.Err != nil {
 			return kerr.Wrap("JACKALTIGG", b.Err)
 		}
+		relativeFile, err := b.Name(env.Dir)
+		if err != nil {
+			return kerr.Wrap("AWYRJSCYQS", err)
+		}
+		key := fileKey(fileHash(b.Bytes))
+
+		if e, ok := cache.get(key); ok {
+			switch e.Kind {
+			case kindType:
+				setLazyType(ctx, env, relativeFile, e.Id, b.Bytes, pcache, hash)
+			case kindPackage:
+				pcache.PackageBytes = b.Bytes
+				pcache.PackageFilename = relativeFile
+			default:
+				pcache.Globals.Set(e.Id, relativeFile, e.Type)
+			}
+			found[key] = e
+			continue
+		}
 
 		o := &objectStub{}
 		if err := ke.UnmarshalUntyped(localContext, b.Bytes, o); err != nil {
@@ -183,46 +343,89 @@ func scanForTypes(ctx context.Context, env *envctx.Env, cache *sysctx.SysPackage
 			// we tolerate missing ID only for system:package
 			return kerr.New("DLLMKTDYFW", "%s has no id", b.File)
 		}
-		relativeFile, err := filepath.Rel(env.Dir, b.File)
-		if err != nil {
-			return kerr.Wrap("AWYRJSCYQS", err)
-		}
 		switch *o.Type {
 		case *system.NewReference("kego.io/system", "type"):
-			if err := ProcessTypeFileBytes(ctx, env, relativeFile, b.Bytes, cache, hash); err != nil {
+			if err := ProcessTypeFileBytes(ctx, env, relativeFile, b.Bytes, pcache, hash); err != nil {
 				return kerr.Wrap("IVEFDDSKHE", err)
 			}
+			found[key] = &cacheEntry{Kind: kindType, Id: o.Id.Name}
 		case *system.NewReference("kego.io/system", "package"):
-			cache.PackageBytes = b.Bytes
-			cache.PackageFilename = relativeFile
+			pcache.PackageBytes = b.Bytes
+			pcache.PackageFilename = relativeFile
+			if filepath.Dir(relativeFile) == "." {
+				// Only the package object in the root of the package dir sets the aliases.
+				found[key] = &cacheEntry{Kind: kindPackage, Aliases: env.Aliases, Recursive: env.Recursive, Include: env.Include, Exclude: env.Exclude}
+			}
 		default:
-
-			cache.Globals.Set(o.Id.Name, relativeFile)
+			pcache.Globals.Set(o.Id.Name, relativeFile, o.Type.Value())
+			found[key] = &cacheEntry{Kind: kindGlobal, Id: o.Id.Name, Type: o.Type.Value()}
 		}
 
 	}
+	if cache != nil {
+		cache.Files = found
+	}
 	return nil
 }
 
 func ProcessTypeFileBytes(ctx context.Context, env *envctx.Env, filename string, bytes []byte, cache *sysctx.SysPackageInfo, hash *PackageHasher) error {
-	var object interface{}
-	err := json.Unmarshal(envctx.NewContext(ctx, env), bytes, &object)
+	t, rule, err := loadType(ctx, env, bytes)
 	if err != nil {
-		return kerr.Wrap("NLRRVIDVWM", err)
-	}
-	t, ok := object.(*system.Type)
-	if !ok {
-		return kerr.New("IVIFIOFGVK", "Should be *system.Type")
+		return kerr.Wrap("RQBLWTNHKE", err)
 	}
 	if hash != nil {
-		hash.Types[t.Id.Name] = cityhash.CityHash64(bytes, uint32(len(bytes)))
+		hash.Types[t.Id.Name] = fileHash(bytes)
 	}
 	cache.Types.Set(t.Id.Name, filename, t)
 	cache.Files.Set(t.Id.Name, filename, bytes)
+	cache.Types.Set(rule.Id.Name, filename, rule)
+	return nil
+}
+
+// setLazyType adds a type file that was found in the parse cache. The file is unmarshaled the
+// first time the type or its rule is used.
+func setLazyType(ctx context.Context, env *envctx.Env, filename string, id string, bytes []byte, cache *sysctx.SysPackageInfo, hash *PackageHasher) {
+	var once sync.Once
+	var t, rule *system.Type
+	var err error
+	load := func() {
+		t, rule, err = loadType(ctx, env, bytes)
+	}
+	if hash != nil {
+		hash.Types[id] = fileHash(bytes)
+	}
+	cache.Types.SetLazy(id, filename, func() (interface{}, error) {
+		once.Do(load)
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	})
+	cache.Files.Set(id, filename, bytes)
+	cache.Types.SetLazy(fmt.Sprint("@", id), filename, func() (interface{}, error) {
+		once.Do(load)
+		if err != nil {
+			return nil, err
+		}
+		return rule, nil
+	})
+}
+
+// loadType unmarshals a type file, and returns the type and its rule. If the type has no rule, a
+// default rule is created.
+func loadType(ctx context.Context, env *envctx.Env, bytes []byte) (t *system.Type, rule *system.Type, err error) {
+	var object interface{}
+	if err := json.Unmarshal(envctx.NewContext(ctx, env), bytes, &object); err != nil {
+		return nil, nil, kerr.Wrap("NLRRVIDVWM", err)
+	}
+	t, ok := object.(*system.Type)
+	if !ok {
+		return nil, nil, kerr.New("IVIFIOFGVK", "Should be *system.Type")
+	}
+	id := system.NewReference(t.Id.Package, fmt.Sprint("@", t.Id.Name))
 	if t.Rule != nil {
-		id := system.NewReference(t.Id.Package, fmt.Sprint("@", t.Id.Name))
 		if t.Rule.Id != nil && *t.Rule.Id != *id {
-			return kerr.New("JKARKEDTIW", "Incorrect id for %v - it should be %v", t.Rule.Id.String(), id.String())
+			return nil, nil, kerr.New("JKARKEDTIW", "Incorrect id for %v - it should be %v", t.Rule.Id.String(), id.String())
 		}
 		t.Rule.Id = id
 
@@ -234,30 +437,27 @@ func ProcessTypeFileBytes(ctx context.Context, env *envctx.Env, filename string,
 			}
 		}
 		if !found {
-			return kerr.New("LMALEMKFDI", "%s does not embed system:rule", id.String())
+			return nil, nil, kerr.New("LMALEMKFDI", "%s does not embed system:rule", id.String())
 		}
-
-		cache.Types.Set(id.Name, filename, t.Rule)
-	} else {
-		// If the rule is missing, automatically create a default.
-		id := system.NewReference(t.Id.Package, fmt.Sprint("@", t.Id.Name))
-		rule := &system.Type{
-			Object: &system.Object{
-				Description: fmt.Sprintf("Automatically created basic rule for %s", t.Id.Name),
-				Type:        system.NewReference("kego.io/system", "type"),
-				Id:          id,
-			},
-			Embed:     []*system.Reference{system.NewReference("kego.io/system", "rule")},
-			Native:    system.NewString("object"),
-			Interface: false,
-		}
-		cache.Types.Set(id.Name, filename, rule)
+		return t, t.Rule, nil
 	}
-
-	return nil
+	// If the rule is missing, automatically create a default.
+	rule = &system.Type{
+		Object: &system.Object{
+			Description: fmt.Sprintf("Automatically created basic rule for %s", t.Id.Name),
+			Type:        system.NewReference("kego.io/system", "type"),
+			Id:          id,
+		},
+		Embed:     []*system.Reference{system.NewReference("kego.io/system", "rule")},
+		Native:    system.NewString("object"),
+		Interface: false,
+	}
+	return t, rule, nil
 }
 
-func scanForPackage(ctx context.Context, env *envctx.Env) (*system.Package, error) {
+// scanForPackage finds the package object in the root of the package dir. Files that are in
+// the parse cache aren't unmarshaled.
+func scanForPackage(ctx context.Context, env *envctx.Env, cache *parseCache) (*system.Package, error) {
 	localContext := envctx.NewContext(ctx, env)
 	files := scanner.ScanDirToFiles(ctx, env.Dir, false)
 	bytes := scanner.ScanFilesToBytes(ctx, files)
@@ -265,6 +465,12 @@ func scanForPackage(ctx context.Context, env *envctx.Env) (*system.Package, erro
 		if b.Err != nil {
 			return nil, kerr.Wrap("GATNNQKNHY", b.Err, b.File)
 		}
+		if e, ok := cache.get(fileKey(fileHash(b.Bytes))); ok {
+			if e.Kind == kindPackage {
+				return &system.Package{Aliases: e.Aliases, Recursive: e.Recursive, Include: e.Include, Exclude: e.Exclude}, nil
+			}
+			continue
+		}
 		o := &objectStub{}
 		if err := ke.UnmarshalUntyped(localContext, b.Bytes, o); err != nil {
 			switch kerr.Source(err).(type) {
@@ -6,9 +6,11 @@ import (
 	"io/ioutil"
 	"path/filepath"
 
+	"github.com/davelondon/kerr"
 	"github.com/davelondon/ktest/assert"
 	"github.com/davelondon/ktest/require"
 	"kego.io/context/sysctx"
+	"kego.io/process/dag"
 	"kego.io/process/generate"
 	"kego.io/system"
 	"kego.io/tests"
@@ -129,7 +131,7 @@ func TestNoTypeError(t *testing.T) {
 	assert.IsError(t, err, "GJRHNGGWFD")
 	assert.HasError(t, err, "MSNIGTIDIO")
 
-	err = scanForTypes(cb.Ctx(), cb.Env(), nil, &PackageHasher{})
+	err = scanForTypes(cb.Ctx(), cb.Env(), nil, &PackageHasher{}, nil)
 	assert.IsError(t, err, "NUKWIHYFMQ")
 
 }
@@ -148,10 +150,34 @@ func TestGoGet(t *testing.T) {
 
 	cb.CmdUpdate(true)
 	err = GoGet(cb.Ctx(), pathA)
-	assert.IsError(t, err, "NIKCKQAKUI")
+	assert.IsError(t, err, "HGSXOWBNEK")
+	assert.HasError(t, err, "NIKCKQAKUI")
 
 }
 
+func TestGoGet_modules(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "a")
+	cb := tests.New().Cmd().OsVar("GOFLAGS", "").OsWd(filepath.Join(dir, "b")).OsFile(filepath.Join(dir, "go.mod"), "module a.b/c\n\nrequire d.e/f v1.0.0\n")
+
+	// Packages in the main module are never downloaded.
+	err := GoGet(cb.Ctx(), "a.b/c/b")
+	assert.NoError(t, err)
+
+	// go.mod isn't changed, so a package must be provided by a required module.
+	err = GoGet(cb.Ctx(), "g.h/i")
+	assert.IsError(t, err, "VYMLGSXQEA")
+
+	cb.OsFile(filepath.Join(dir, "vendor", "modules.txt"), "")
+	err = GoGet(cb.Ctx(), "d.e/f/g")
+	assert.IsError(t, err, "JCWPKYTQEB")
+
+	// With -u, go get -u is run in the module dir, which doesn't exist here.
+	cb.CmdUpdate(true)
+	err = GoGet(cb.Ctx(), "d.e/f/g")
+	assert.IsError(t, err, "RDHXWOQMAT")
+	assert.HasError(t, err, "NIKCKQAKUI")
+}
+
 func TestImport(t *testing.T) {
 
 	cb := tests.New().TempGopath(true)
@@ -186,6 +212,43 @@ func TestImport(t *testing.T) {
 
 }
 
+func TestImportErrors(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	files := map[string]string{
+		"a.json": `{
+			"type": "system:type",
+			"id": "a",
+			"rule": {"type": "system:type"}
+		}`,
+	}
+	pathA, _ := cb.TempPackage("a", files)
+	pathB, _ := cb.TempPackage("b", files)
+	pathC, dirC := cb.TempPackage("c", map[string]string{
+		"package.json": `{
+			"type": "system:package",
+			"aliases": {
+				"a": "` + pathA + `",
+				"b": "` + pathB + `"
+			}
+		}`,
+	})
+	cb.Path(pathC).Dir(dirC).Cmd().Sempty().Jsystem()
+
+	// Both imports fail, so the errors are returned together, and c isn't parsed.
+	_, err := Parse(cb.Ctx(), pathC)
+	assert.IsError(t, err, "PXGLWDKQEU")
+	errors := kerr.Source(err).(dag.Errors).Errors
+	assert.Equal(t, 2, len(errors))
+	assert.HasError(t, errors[pathA], "LMALEMKFDI")
+	assert.HasError(t, errors[pathB], "LMALEMKFDI")
+	_, found := sysctx.FromContext(cb.Ctx()).Get(pathC)
+	assert.False(t, found)
+
+}
+
 func TestImportSystem(t *testing.T) {
 
 	cb := tests.New().TempGopath(true)
@@ -319,7 +382,7 @@ func TestParseRule(t *testing.T) {
 	assert.NoError(t, err)
 
 	scache := sysctx.FromContext(cb.Ctx())
-	i, ok := scache.GetType(path, "b")
+	i, ok, _ := scache.GetType(path, "b")
 	assert.True(t, ok)
 	ty, ok := i.(*system.Type)
 	assert.True(t, ok)
@@ -351,7 +414,7 @@ func TestParse1(t *testing.T) {
 	assert.NoError(t, err)
 
 	scache := sysctx.FromContext(cb.Ctx())
-	i, ok := scache.GetType(path, "b")
+	i, ok, _ := scache.GetType(path, "b")
 	assert.True(t, ok)
 	ty, ok := i.(*system.Type)
 	assert.True(t, ok)
@@ -406,3 +469,167 @@ func TestParse(t *testing.T) {
 	assert.NoError(t, err)
 
 }
+
+func TestCache(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathB, dirB := cb.TempPackage("b", map[string]string{
+		"b.yaml": "type: system:type\nid: b\n",
+	})
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    b: " + pathB + "\n",
+		"a.yaml":       "type: system:type\nid: a\nfields:\n    b:\n        type: b:@b\n",
+		"g.yaml":       "type: a\nid: g\n",
+	})
+	cb.Path(pathA).Dir(dirA).Cmd().Sempty().Jsystem()
+
+	parse := func() *sysctx.SysPackageInfo {
+		pi, err := Parse(sysctx.NewContext(cb.Ctx()), pathA)
+		require.NoError(t, err)
+		return pi
+	}
+	rename := func(kind string, id string) {
+		cache := loadCache(dirA, pathA)
+		for _, e := range cache.Files {
+			if e.Kind == kind {
+				e.Id = id
+			}
+		}
+		cache.save(dirA)
+	}
+
+	pi := parse()
+	cache := loadCache(dirA, pathA)
+	assert.Equal(t, 3, len(cache.Files))
+	assert.Equal(t, 2, len(cache.Imports))
+	_, ok := cache.Imports["kego.io/system"]
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"b": pathB}, pi.Aliases)
+
+	// The cache is used on the next run, so the files aren't unmarshaled and the ids come from
+	// the cache.
+	rename(kindGlobal, "cached")
+	rename(kindType, "cached")
+	hash := pi.Hash
+	pi = parse()
+	_, ok = pi.Globals.Get("cached")
+	assert.True(t, ok)
+	ti, ok := pi.Types.Get("cached")
+	require.True(t, ok)
+	assert.Equal(t, "a", ti.Type.(*system.Type).Id.Name)
+	ti, ok = pi.Types.Get("@cached")
+	require.True(t, ok)
+	assert.Equal(t, "@a", ti.Type.(*system.Type).Id.Name)
+	assert.NotEqual(t, hash, pi.Hash)
+	assert.Equal(t, map[string]string{"b": pathB}, pi.Aliases)
+
+	// A changed file isn't in the cache.
+	cb.TempFile("g.yaml", "type: a\nid: g\ndescription: changed\n")
+	pi = parse()
+	_, ok = pi.Globals.Get("g")
+	assert.True(t, ok)
+	_, ok = pi.Globals.Get("cached")
+	assert.False(t, ok)
+
+	// If an imported package changes, the types are unmarshaled again.
+	_, ok = pi.Types.Get("cached")
+	assert.True(t, ok)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirB, "b.yaml"), []byte("type: system:type\nid: b\ndescription: changed\n"), 0777))
+	pi = parse()
+	_, ok = pi.Types.Get("a")
+	assert.True(t, ok)
+	_, ok = pi.Types.Get("cached")
+	assert.False(t, ok)
+	assert.Equal(t, hash, pi.Hash)
+
+	// A cache from an old version is ignored.
+	rename(kindType, "cached")
+	cache = loadCache(dirA, pathA)
+	cache.Version = 0
+	cache.save(dirA)
+	pi = parse()
+	_, ok = pi.Types.Get("a")
+	assert.True(t, ok)
+
+	// A type that fails to load isn't found.
+	cache = loadCache(dirA, pathA)
+	for _, e := range cache.Files {
+		if e.Kind == kindGlobal {
+			e.Kind = kindType
+			e.Id = "broken"
+		}
+	}
+	cache.save(dirA)
+	pi = parse()
+	_, ok = pi.Types.Get("broken")
+	assert.False(t, ok)
+	_, found, err := pi.Types.GetErr("broken")
+	assert.True(t, found)
+	assert.IsError(t, err, "WGYPNTSMKO")
+	assert.HasError(t, err, "NLRRVIDVWM")
+
+	// If an alias is changed to another package, the types are unmarshaled again.
+	pathC, _ := cb.TempPackage("c", map[string]string{
+		"b.yaml": "type: system:type\nid: b\n",
+	})
+	cb.Path(pathA).Dir(dirA)
+	rename(kindType, "cached")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirA, "package.yaml"), []byte("type: system:package\naliases:\n    b: "+pathC+"\n"), 0777))
+	pi = parse()
+	_, ok = pi.Types.Get("a")
+	assert.True(t, ok)
+	_, ok = pi.Types.Get("cached")
+	assert.False(t, ok)
+	cache = loadCache(dirA, pathA)
+	_, ok = cache.Imports[pathC]
+	assert.True(t, ok)
+	_, ok = cache.Imports[pathB]
+	assert.False(t, ok)
+
+	// If the include or exclude patterns change, the globals are unmarshaled again.
+	rename(kindGlobal, "cached")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirA, "package.yaml"), []byte("type: system:package\naliases:\n    b: "+pathC+"\nexclude: [x.yaml]\n"), 0777))
+	pi = parse()
+	_, ok = pi.Globals.Get("g")
+	assert.True(t, ok)
+	_, ok = pi.Globals.Get("cached")
+	assert.False(t, ok)
+
+	// If an alias is renamed, the globals are unmarshaled again, so a global that still uses the
+	// old alias is an error.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirA, "h.yaml"), []byte("type: b:b\nid: h\n"), 0777))
+	parse()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirA, "package.yaml"), []byte("type: system:package\naliases:\n    c: "+pathC+"\nexclude: [x.yaml]\n"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirA, "a.yaml"), []byte("type: system:type\nid: a\nfields:\n    b:\n        type: c:@b\n"), 0777))
+	_, err = Parse(sysctx.NewContext(cb.Ctx()), pathA)
+	assert.HasError(t, err, "HCYGNBDFFA")
+}
+
+func TestMultipleObjects(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"types.yaml": "type: system:type\nid: a\n---\ntype: system:type\nid: b\n",
+		"g.json":     `[{"type": "a", "id": "c"}, {"type": "b", "id": "d"}]`,
+	})
+	cb.Path(pathA).Dir(dirA).Cmd().Sempty().Jsystem()
+
+	pi, err := Parse(cb.Ctx(), pathA)
+	require.NoError(t, err)
+
+	ti, ok := pi.Types.Get("b")
+	require.True(t, ok)
+	assert.Equal(t, "types.yaml:1", ti.File)
+	assert.Equal(t, "b", ti.Type.(*system.Type).Id.Name)
+	fi, ok := pi.Files.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, "types.yaml:0", fi.File)
+	assert.Equal(t, `{"id":"a","type":"system:type"}`, string(fi.Bytes))
+	gi, ok := pi.Globals.Get("d")
+	require.True(t, ok)
+	assert.Equal(t, "g.json:1", gi.File)
+}
@@ -0,0 +1,165 @@
+package query // import "kego.io/process/query"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/json"
+	"kego.io/process/format"
+	"kego.io/process/scanner"
+	"kego.io/process/validate/selectors"
+	"kego.io/system/node"
+)
+
+type Format string
+
+const (
+	FORMAT_PLAIN Format = "plain"
+	FORMAT_JSON  Format = "json"
+	FORMAT_YAML  Format = "yaml"
+)
+
+// Formats lists the formats accepted by Write.
+var Formats = []Format{FORMAT_PLAIN, FORMAT_JSON, FORMAT_YAML}
+
+// Match is a node that matched the selector, and the file it was found in, relative to the
+// package directory.
+type Match struct {
+	File string
+	Node *node.Node
+}
+
+// Position returns the location of the matched node.
+func (m Match) Position() json.Position {
+	p := m.Node.Position
+	p.File = m.File
+	return p
+}
+
+// Query loads every global in the package as nodes, and evaluates the selector against each of
+// them. The matches are returned in the order the files were scanned. Fields that are missing
+// from the data are not matched.
+func Query(ctx context.Context, selector string) ([]Match, error) {
+
+	env := envctx.FromContext(ctx)
+
+	matches := []Match{}
+
+	files := scanner.ScanPackageToFiles(ctx, env)
+	bytes := scanner.ScanFilesToBytes(ctx, files)
+	for c := range bytes {
+		if c.Err != nil {
+			return nil, kerr.Wrap("MLBOTXVEKA", c.Err)
+		}
+		file, err := filepath.Rel(env.Dir, c.File)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("CFYWHNKRXT", err)
+		}
+		n, err := node.UnmarshalPositions(ctx, c.Bytes, c.Positions)
+		if err != nil {
+			return nil, kerr.Wrap("UPKSVIEHAL", err)
+		}
+		p, err := selectors.CreateParser(ctx, n)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("JSEDBHQWAC", err)
+		}
+		nodes, err := p.GetNodes(selector)
+		if err != nil {
+			return nil, kerr.Wrap("XPGOIUTWMY", err)
+		}
+		for _, match := range nodes {
+			if match.Missing {
+				// Fields that are missing from the data have a node, but they aren't
+				// useful matches.
+				continue
+			}
+			matches = append(matches, Match{File: file, Node: match})
+		}
+	}
+
+	return matches, nil
+}
+
+// Write writes the matches in the requested format. In plain format each match is written on one
+// line as "file:line:col: path: value", where native values are written as they are and other
+// values as compact json. The json and yaml formats write a list of matches with the file,
+// position, path and value of each.
+func Write(ctx context.Context, w io.Writer, f Format, matches []Match) error {
+	switch f {
+	case FORMAT_PLAIN, "":
+		return writePlain(ctx, w, matches)
+	case FORMAT_JSON, FORMAT_YAML:
+		return writeStructured(ctx, w, f, matches)
+	}
+	return kerr.New("RDUXQWNCVO", "Unknown query format %s", f)
+}
+
+func writePlain(ctx context.Context, w io.Writer, matches []Match) error {
+	for _, m := range matches {
+		v, err := format.Value(ctx, m.Node)
+		if err != nil {
+			return kerr.Wrap("WFSOJKRAQT", err)
+		}
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case float64, bool:
+			s = fmt.Sprint(v)
+		default:
+			b, err := format.MarshalJson(v, "")
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("DKTWUQBMHN", err)
+			}
+			s = string(b)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", m.Position(), m.Node.Path(), s); err != nil {
+			return kerr.Wrap("NUQCFHAJEX", err)
+		}
+	}
+	return nil
+}
+
+func writeStructured(ctx context.Context, w io.Writer, f Format, matches []Match) error {
+	out := []interface{}{}
+	for _, m := range matches {
+		v, err := format.Value(ctx, m.Node)
+		if err != nil {
+			return kerr.Wrap("LEHMYCTSUV", err)
+		}
+		p := m.Position()
+		out = append(out, format.Object{
+			{Key: "file", Value: m.File},
+			{Key: "line", Value: float64(p.Line)},
+			{Key: "column", Value: float64(p.Column)},
+			{Key: "path", Value: m.Node.Path()},
+			{Key: "value", Value: v},
+		})
+	}
+	var b []byte
+	var err error
+	if f == FORMAT_JSON {
+		if b, err = format.MarshalJson(out, "\t"); err == nil {
+			b = append(b, '\n')
+		}
+	} else {
+		b, err = format.MarshalYaml(out)
+	}
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("GQKXMFNOEY", err)
+	}
+	if _, err := w.Write(b); err != nil {
+		return kerr.Wrap("TIWCHRLVXP", err)
+	}
+	return nil
+}
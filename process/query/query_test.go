@@ -0,0 +1,99 @@
+package query
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestQuery(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				b:
+					type: system:@string
+					optional: true
+				c:
+					type: system:@array
+					items:
+						type: system:@number
+					optional: true
+		`,
+		"d.json": `{
+			"type": "a",
+			"id": "d",
+			"b": "foo",
+			"c": [1, 2]
+		}`,
+		"e.yaml": `
+			type: a
+			id: e
+			b: bar
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	matches, err := Query(cb.Ctx(), "{a} > .b")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(matches))
+	assert.Equal(t, "d.json", matches[0].File)
+	assert.Equal(t, "d/b", matches[0].Node.Path())
+	assert.Equal(t, "e.yaml", matches[1].File)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(cb.Ctx(), out, FORMAT_PLAIN, matches))
+	assert.Equal(t, "d.json:4:9: d/b: foo\ne.yaml:4:16: e/b: bar\n", out.String())
+
+	matches, err = Query(cb.Ctx(), "{a} > .c")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(matches))
+
+	out = &bytes.Buffer{}
+	require.NoError(t, Write(cb.Ctx(), out, FORMAT_PLAIN, matches))
+	assert.Equal(t, "d.json:5:9: d/c: [1,2]\n", out.String())
+
+	out = &bytes.Buffer{}
+	require.NoError(t, Write(cb.Ctx(), out, FORMAT_JSON, matches))
+	assert.Equal(t, `[
+	{
+		"file": "d.json",
+		"line": 5,
+		"column": 9,
+		"path": "d/c",
+		"value": [
+			1,
+			2
+		]
+	}
+]
+`, out.String())
+
+	out = &bytes.Buffer{}
+	require.NoError(t, Write(cb.Ctx(), out, FORMAT_YAML, matches))
+	assert.Equal(t, "- file: d.json\n  line: 5\n  column: 9\n  path: d/c\n  value:\n    - 1\n    - 2\n", out.String())
+
+	out = &bytes.Buffer{}
+	require.NoError(t, Write(cb.Ctx(), out, FORMAT_PLAIN, nil))
+	assert.Equal(t, "", out.String())
+
+	err = Write(cb.Ctx(), out, "foo", nil)
+	assert.IsError(t, err, "RDUXQWNCVO")
+
+	_, err = Query(cb.Ctx(), "(")
+	assert.IsError(t, err, "XPGOIUTWMY")
+
+	cb.TempFile("f.json", `{"type": "a", "id": "f", "g": 1}`)
+	_, err = Query(cb.Ctx(), ".b")
+	assert.IsError(t, err, "UPKSVIEHAL")
+}
@@ -0,0 +1,47 @@
+package process
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/process/rename"
+)
+
+// Rename renames a type or global and changes every reference to it in the package, its
+// dependencies and the other packages that alias it. The files that were changed are returned,
+// sorted, with their new names, and the paths of the packages they're in.
+func Rename(ctx context.Context, target, name string) (changed []string, packages []string, err error) {
+	result, err := rename.Rename(ctx, target, name)
+	if err != nil {
+		return nil, nil, kerr.Wrap("HEJSPQWNVA", err)
+	}
+	changed = []string{}
+	for file, b := range result.Files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, nil, kerr.Wrap("CWGKFXLUMI", err)
+		}
+		dest := file
+		if moved, ok := result.Moves[file]; ok {
+			dest = moved
+		}
+		if err := ioutil.WriteFile(dest, b, info.Mode()); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, nil, kerr.Wrap("IBAPYTNQRO", err)
+		}
+		if dest != file {
+			if err := os.Remove(file); err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, nil, kerr.Wrap("MVQSEGDXKU", err)
+			}
+		}
+		changed = append(changed, dest)
+	}
+	sort.Strings(changed)
+	return changed, result.Packages, nil
+}
@@ -0,0 +1,249 @@
+package rename // import "kego.io/process/rename"
+
+// ke: {"package": {"complete": true}}
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/process/format"
+	"kego.io/process/packages"
+	"kego.io/process/parser"
+	"kego.io/process/scanner"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+// Result holds the new contents of each file that refers to the renamed type or global, keyed
+// by file name. If the file that defines the type or global is named after it, Moves maps the
+// file name to the new file name. Packages holds the paths of the packages with changed files,
+// sorted.
+type Result struct {
+	Files    map[string][]byte
+	Moves    map[string]string
+	Packages []string
+}
+
+// Rename renames a type or global, and changes every reference to it. The target is a reference
+// in the form used in the local package, e.g. alias:name, and name is the new name, in the same
+// package. The package that defines the target and every package that aliases it are scanned,
+// so type fields, @rule types, embed entries, reference values and the id itself are all
+// changed, whichever form of the reference each file uses. The packages that alias it are the
+// ones in the sys context, and the ones found by dependents, so outside a Go module rename
+// should be run from the top-level package. The changed files are returned in canonical form,
+// with their comments, and nothing is written.
+func Rename(ctx context.Context, target, name string) (*Result, error) {
+
+	from, err := system.NewReferenceFromString(ctx, target)
+	if err != nil {
+		return nil, kerr.Wrap("OMRGJCTWVH", err)
+	}
+	if from.Package == "kego.io/system" || from.Package == "kego.io/json" {
+		return nil, kerr.New("XHQDYWPSGE", "Can't rename %s: system types can't be renamed", from.Value())
+	}
+	if name == "" || strings.ContainsAny(name, ":@/") {
+		return nil, kerr.New("FNEUWAMRTB", "Invalid name %s", name)
+	}
+
+	scache := sysctx.FromContext(ctx)
+	pi, ok := scache.Get(from.Package)
+	if !ok {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.New("VTGXKQCDOL", "Package %s not found", from.Package)
+	}
+
+	var file string
+	t, isType, err := pi.Types.GetErr(from.Name)
+	if err != nil {
+		return nil, kerr.Wrap("FWQNJOCYVI", err)
+	}
+	if isType {
+		file = t.File
+	} else if g, ok := pi.Globals.Get(from.Name); ok {
+		file = g.File
+	} else {
+		return nil, kerr.New("PLWSRHYIJN", "%s not found", from.Value())
+	}
+	_, typeExists, err := pi.Types.GetErr(name)
+	if err != nil {
+		return nil, kerr.Wrap("PAXLTHRDMG", err)
+	}
+	_, globalExists := pi.Globals.Get(name)
+	if typeExists || globalExists {
+		return nil, kerr.New("KCMAQJUEXB", "%s:%s already exists", from.Package, name)
+	}
+
+	renames := map[system.Reference]system.Reference{
+		*from: *system.NewReference(from.Package, name),
+	}
+	if isType {
+		renames[from.ChangeToRule()] = system.NewReference(from.Package, name).ChangeToRule()
+	}
+
+	if err := dependents(ctx, from.Package); err != nil {
+		return nil, kerr.Wrap("QOYDMWCTHR", err)
+	}
+
+	result := &Result{Files: map[string][]byte{}, Moves: map[string]string{}}
+
+	for _, path := range scache.Keys() {
+		p, _ := scache.Get(path)
+		if !refers(p.Path, p.Aliases, from.Package) {
+			continue
+		}
+		if err := renamePackage(envctx.NewContext(ctx, p.Env), renames, result); err != nil {
+			return nil, kerr.Wrap("UYBOKHQDAS", err)
+		}
+	}
+
+	// A file that holds several objects isn't named after one of them, so it isn't moved.
+	if _, index := scanner.SplitDocumentName(file); index < 0 && strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) == from.Name {
+		old := filepath.Join(pi.Dir, file)
+		moved := filepath.Join(filepath.Dir(old), name+filepath.Ext(file))
+		if _, err := os.Stat(moved); err == nil {
+			return nil, kerr.New("GDNIFBWQAT", "Can't rename %s to %s: the file already exists", old, moved)
+		}
+		result.Moves[old] = moved
+	}
+
+	sort.Strings(result.Packages)
+	return result, nil
+}
+
+// dependents parses the packages that alias the package p and aren't in the sys context, so
+// they can be changed with it. They're found in the dirs of the Go module of the local package,
+// or outside a module in the dirs under the local package. Dirs that start with . or _, vendor
+// dirs and dirs whose data files can't be scanned are skipped.
+func dependents(ctx context.Context, p string) error {
+	env := envctx.FromContext(ctx)
+	scache := sysctx.FromContext(ctx)
+	root := env.Dir
+	m, found, err := packages.FindModule(ctx, env.Dir)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("VNSAWEXBLQ", err)
+	}
+	if found {
+		root = m.Dir
+	}
+	dirs := []string{}
+	walk := func(dir string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if name := info.Name(); dir != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor") {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, dir)
+		return nil
+	}
+	if err := filepath.Walk(root, walk); err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("JHKTMUNXOB", err)
+	}
+	for _, dir := range dirs {
+		path, err := packages.GetPackageFromDir(ctx, dir)
+		if err != nil {
+			continue
+		}
+		if _, ok := scache.Get(path); ok {
+			continue
+		}
+		penv, err := parser.ScanForEnv(ctx, path)
+		if err != nil {
+			continue
+		}
+		if !refers(path, penv.Aliases, p) {
+			continue
+		}
+		if _, err := parser.Parse(ctx, path); err != nil {
+			return kerr.Wrap("DRHXUOGKWA", err)
+		}
+	}
+	return nil
+}
+
+// refers is true if the package at path is p, or imports it with one of its aliases.
+func refers(path string, aliases map[string]string, p string) bool {
+	if path == p {
+		return true
+	}
+	for _, a := range aliases {
+		if a == p {
+			return true
+		}
+	}
+	return false
+}
+
+// renamePackage changes the references in the data files of the package in the context.
+func renamePackage(ctx context.Context, renames map[system.Reference]system.Reference, result *Result) error {
+	env := envctx.FromContext(ctx)
+	files := scanner.ScanPackageToFiles(ctx, env)
+	bytes := scanner.ScanFilesToBytes(ctx, files)
+
+	// The objects in each file are kept, so a file that holds several objects is written with
+	// all of them when one of them changes.
+	objects := map[string][]*node.Node{}
+	multi := map[string]bool{}
+	changedFiles := map[string]bool{}
+
+	for c := range bytes {
+		if c.Err != nil {
+			return kerr.Wrap("NMPTIOCUSX", c.Err)
+		}
+		n, err := node.Unmarshal(ctx, c.Bytes)
+		if err != nil {
+			return kerr.Wrap("BEWQSLAHGN", err)
+		}
+		objects[c.File] = append(objects[c.File], n)
+		multi[c.File] = c.Multi
+		changed := false
+		for _, child := range n.Flatten(true) {
+			r, ok := child.Value.(*system.Reference)
+			if !ok || r == nil || child.Null {
+				continue
+			}
+			to, ok := renames[*r]
+			if !ok {
+				continue
+			}
+			s, err := to.ValueContext(ctx)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("WJHXCQFOEV", err)
+			}
+			if err := child.SetValueString(ctx, s); err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("SRAOMQYEDK", err)
+			}
+			changed = true
+		}
+		if changed {
+			changedFiles[c.File] = true
+		}
+	}
+	if len(changedFiles) > 0 {
+		result.Packages = append(result.Packages, env.Path)
+	}
+	for file := range changedFiles {
+		original, err := ioutil.ReadFile(file)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return kerr.Wrap("YTMCLDUGVI", err)
+		}
+		b, err := format.Objects(ctx, file, original, objects[file], multi[file])
+		if err != nil {
+			return kerr.Wrap("KPGWXAYRMF", err)
+		}
+		result.Files[file] = b
+	}
+	return nil
+}
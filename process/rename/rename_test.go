@@ -0,0 +1,180 @@
+package rename
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestRename(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"photo.yaml": `
+			type: system:type
+			id: photo
+			fields:
+				url:
+					type: system:@string
+					optional: true
+		`,
+		"gallery.yaml": `
+			type: system:type
+			id: gallery
+			basic: true
+			embed: [photo]
+			fields:
+				cover:
+					type: "@photo"
+					optional: true
+				photos:
+					type: system:@array
+					items:
+						type: "@photo"
+					optional: true
+		`,
+		"link.yaml": `
+			type: system:type
+			id: link
+			fields:
+				to:
+					type: system:@reference
+					optional: true
+		`,
+		"p.yaml": "# p is a photo\ntype: photo\nid: p\nurl: foo # the url\n",
+		"l.yaml": `
+			type: link
+			id: l
+			to: photo
+		`,
+	})
+
+	pathB, dirB := cb.TempPackage("b", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    x: " + pathA + "\n",
+		"c.yaml": `
+			type: x:gallery
+			id: c
+			url: bar
+		`,
+		"d.json": `{"type": "x:photo", "id": "d"}`,
+		"e.yaml": "type: x:link\nid: e\nto: " + pathA + ":photo\n",
+		"f.yaml": "type: x:link\nid: f\nto: x:p\n",
+		"g.yaml": "type: x:link\nid: g\nto: x:p\n---\ntype: x:photo\nid: h\n",
+	})
+
+	cb.Path(pathB).Dir(dirB).Alias("x", pathA).Cmd().Jauto().Sauto(parser.Parse)
+
+	result, err := Rename(cb.Ctx(), "x:photo", "picture")
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, len(result.Files))
+	// All the objects in a file that holds several objects are written.
+	assert.Equal(t, "type: x:link\nid: g\nto: x:p\n---\ntype: x:picture\nid: h\n", string(result.Files[filepath.Join(dirB, "g.yaml")]))
+	assert.Equal(t, "type: system:type\nid: picture\nfields:\n    url:\n        type: system:@string\n        optional: true\n", string(result.Files[filepath.Join(dirA, "photo.yaml")]))
+	assert.Equal(t, `type: system:type
+id: gallery
+basic: true
+embed:
+    - picture
+fields:
+    cover:
+        type: '@picture'
+        optional: true
+    photos:
+        type: system:@array
+        items:
+            type: '@picture'
+        optional: true
+`, string(result.Files[filepath.Join(dirA, "gallery.yaml")]))
+	// The comments are kept.
+	assert.Equal(t, "# p is a photo\ntype: picture\nid: p\nurl: foo # the url\n", string(result.Files[filepath.Join(dirA, "p.yaml")]))
+	assert.Equal(t, "type: link\nid: l\nto: picture\n", string(result.Files[filepath.Join(dirA, "l.yaml")]))
+	assert.Equal(t, "{\n\t\"type\": \"x:picture\",\n\t\"id\": \"d\"\n}\n", string(result.Files[filepath.Join(dirB, "d.json")]))
+	// The full package path is changed to the alias.
+	assert.Equal(t, "type: x:link\nid: e\nto: x:picture\n", string(result.Files[filepath.Join(dirB, "e.yaml")]))
+	assert.Equal(t, map[string]string{filepath.Join(dirA, "photo.yaml"): filepath.Join(dirA, "picture.yaml")}, result.Moves)
+
+	result, err = Rename(cb.Ctx(), "x:p", "q")
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(result.Files))
+	assert.Equal(t, "type: x:link\nid: g\nto: x:q\n---\ntype: x:photo\nid: h\n", string(result.Files[filepath.Join(dirB, "g.yaml")]))
+	assert.Equal(t, "type: x:link\nid: f\nto: x:q\n", string(result.Files[filepath.Join(dirB, "f.yaml")]))
+	assert.Equal(t, map[string]string{filepath.Join(dirA, "p.yaml"): filepath.Join(dirA, "q.yaml")}, result.Moves)
+
+	// A global in a file that holds several objects isn't moved.
+	result, err = Rename(cb.Ctx(), "h", "i")
+	require.NoError(t, err)
+	assert.Equal(t, "type: x:link\nid: g\nto: x:p\n---\ntype: x:photo\nid: i\n", string(result.Files[filepath.Join(dirB, "g.yaml")]))
+	assert.Equal(t, 0, len(result.Moves))
+
+	_, err = Rename(cb.Ctx(), "y:photo", "picture")
+	assert.IsError(t, err, "OMRGJCTWVH")
+
+	_, err = Rename(cb.Ctx(), "system:string", "text")
+	assert.IsError(t, err, "XHQDYWPSGE")
+
+	_, err = Rename(cb.Ctx(), "x:photo", "x:picture")
+	assert.IsError(t, err, "FNEUWAMRTB")
+
+	_, err = Rename(cb.Ctx(), "x:video", "picture")
+	assert.IsError(t, err, "PLWSRHYIJN")
+
+	_, err = Rename(cb.Ctx(), "x:photo", "gallery")
+	assert.IsError(t, err, "KCMAQJUEXB")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirA, "picture.yaml"), []byte("type: photo\nid: h\n"), 0666))
+	_, err = Rename(cb.Ctx(), "x:photo", "picture")
+	assert.IsError(t, err, "GDNIFBWQAT")
+
+	cb.TempFile("g.json", `{"type": "x:photo", "id": "g", "foo": "bar"}`)
+	_, err = Rename(cb.Ctx(), "x:photo", "picture")
+	assert.HasError(t, err, "BEWQSLAHGN")
+}
+
+func TestRenameDependents(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"photo.yaml": "type: system:type\nid: photo\n",
+	})
+	pathB, dirB := cb.TempPackage("b", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    x: " + pathA + "\n",
+		"c.yaml":       "type: x:photo\nid: c\n",
+	})
+	// b doesn't import k, so k is only found by scanning the dirs under b.
+	_, dirK := cb.TempPackage("b/k", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    w: " + pathA + "\n",
+		"l.yaml":       "# l\ntype: w:photo\nid: l # the id\n",
+	})
+	cb.TempPackage("b/_m", map[string]string{
+		"package.yaml": "type: system:package\naliases:\n    w: " + pathA + "\n",
+		"n.yaml":       "type: w:photo\nid: n\n",
+	})
+	cb.TempPackage("b/o", map[string]string{
+		"p.json": "{",
+	})
+
+	cb.Path(pathB).Dir(dirB).Alias("x", pathA).Cmd().Jauto().Sauto(parser.Parse)
+
+	result, err := Rename(cb.Ctx(), "x:photo", "picture")
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(result.Files))
+	assert.Equal(t, "type: x:picture\nid: c\n", string(result.Files[filepath.Join(dirB, "c.yaml")]))
+	assert.Equal(t, "# l\ntype: w:picture\nid: l # the id\n", string(result.Files[filepath.Join(dirK, "l.yaml")]))
+	assert.Equal(t, "type: system:type\nid: picture\n", string(result.Files[filepath.Join(dirA, "photo.yaml")]))
+
+	// A comment on a merge key can't be kept, so the file isn't renamed.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dirB, "q.yaml"), []byte("type: x:photo\nid: q\n<<: {} # merged\n"), 0666))
+	_, err = Rename(cb.Ctx(), "x:photo", "picture")
+	assert.IsError(t, err, "UYBOKHQDAS")
+	assert.HasError(t, err, "FMQIUZXOVD")
+}
@@ -0,0 +1,45 @@
+package process
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestRename(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"b.yaml": "type: system:type\nid: b\n",
+		"c.yaml": "type: b\nid: c\n",
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	changed, packages, err := Rename(cb.Ctx(), "b", "d")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "c.yaml"), filepath.Join(dir, "d.yaml")}, changed)
+	assert.Equal(t, []string{path}, packages)
+
+	_, err = os.Stat(filepath.Join(dir, "b.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "d.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "type: system:type\nid: d\n", string(b))
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "c.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "type: d\nid: c\n", string(b))
+
+	_, _, err = Rename(cb.Ctx(), "e", "f")
+	assert.IsError(t, err, "HEJSPQWNVA")
+}
@@ -15,13 +15,16 @@ import (
 	"kego.io/context/cmdctx"
 	"kego.io/context/envctx"
 	"kego.io/context/wgctx"
+	"kego.io/json"
 	"kego.io/process/generate"
 	"kego.io/process/logger"
 	"kego.io/process/validate"
 )
 
 const validateCommand = ".localke/validate"
+const validateReport = ".localke/report.json"
 
+// HashError is returned by RunValidateCommand when the generated types are out of date.
 type HashError struct {
 	kerr.Struct
 }
@@ -66,7 +69,10 @@ func runValidateCommand(ctx context.Context, build bool, repeat bool) (err error
 
 	hashChanged := false
 
-	exe := exec.Command(validateCommandPath)
+	reportPath := filepath.Join(env.Dir, validateReport)
+	defer os.Remove(reportPath)
+
+	exe := exec.Command(validateCommandPath, "-r", reportPath)
 	exe.Stdout = stdout
 	exe.Stderr = stderr
 	if err = exe.Run(); err != nil {
@@ -93,7 +99,11 @@ func runValidateCommand(ctx context.Context, build bool, repeat bool) (err error
 			goto Repeat
 		case 4:
 			// Exit status 4 = validation error
-			return validate.ValidationCommandError{Struct: kerr.New("ETWHPXTUVB", strings.TrimSpace(combined.String()))}
+			entries, err := readReport(reportPath)
+			if err != nil {
+				return kerr.Wrap("OSUWJUYFGX", err)
+			}
+			return validate.ValidationCommandError{Struct: kerr.New("ETWHPXTUVB", strings.TrimSpace(combined.String())), Entries: entries}
 		default:
 			// ke: {"block": {"notest": true}}
 			goto Repeat
@@ -115,9 +125,26 @@ Repeat:
 		}
 		return nil
 	}
+	if hashChanged {
+		// The validate command has just been built, so the types in generated.go must be out of
+		// date.
+		return HashError{Struct: kerr.New("FSJQSMVVRJ", "The types in %s have changed since generated.go was created. Run ke generate first.", envctx.FromContext(ctx).Path)}
+	}
 	return kerr.Wrap("DTTHRRJSSF", err)
 }
 
+func readReport(file string) ([]validate.ReportEntry, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, kerr.Wrap("XXLPXMDUIQ", err)
+	}
+	var entries []validate.ReportEntry
+	if err := json.UnmarshalPlain(b, &entries); err != nil {
+		return nil, kerr.Wrap("BEJPYWXDFW", err)
+	}
+	return entries, nil
+}
+
 // buildValidateCommand creates a temporary folder in the package, in which the go source for the
 // local command is generated. This command is then compiled.
 func buildValidateCommand(ctx context.Context) error {
@@ -0,0 +1,294 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/davelondon/kerr"
+	yamlv3 "gopkg.in/yaml.v3"
+	"kego.io/json"
+)
+
+// Document is an object in a data file. Most files hold a single object, but a yaml file can
+// hold several documents separated by ---, and a json file can hold an array of objects.
+type Document struct {
+	Bytes     []byte
+	Positions *json.Positions
+}
+
+// DocumentName returns the name of an object in a data file. This is the file, or for a file
+// that holds several objects, the file and the index of the object, e.g. globals.yaml:2.
+func DocumentName(file string, index int, multi bool) string {
+	if !multi {
+		return file
+	}
+	return fmt.Sprintf("%s:%d", file, index)
+}
+
+// SplitDocumentName returns the file and index from a name returned by DocumentName. If the name
+// has no index, the index is -1.
+func SplitDocumentName(name string) (file string, index int) {
+	colon := strings.LastIndex(name, ":")
+	if colon < 0 {
+		return name, -1
+	}
+	i, err := strconv.Atoi(name[colon+1:])
+	if err != nil || i < 0 || !isDataFile(name[:colon]) {
+		return name, -1
+	}
+	return name[:colon], i
+}
+
+func isDataFile(file string) bool {
+	ext := filepath.Ext(file)
+	return ext == ".json" || ext == ".yaml" || ext == ".yml"
+}
+
+// ProcessFileDocuments returns the objects in a data file as json, with the source position of
+// each value. multi is true if the file holds several objects: a yaml file with more than one
+// document, or a json file with an array at the root. Empty yaml documents are skipped. Files
+// that aren't data files return nil.
+func ProcessFileDocuments(file string) (docs []Document, multi bool, err error) {
+	if !isDataFile(file) {
+		return nil, false, nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, false, kerr.Wrap("OTKWQFXMAE", err)
+	}
+	if filepath.Ext(file) == ".json" {
+		docs, err = jsonDocuments(file, data)
+	} else {
+		docs, err = yamlDocuments(file, data)
+	}
+	if err != nil {
+		return nil, false, kerr.Wrap("NVRUQWDHXO", err)
+	}
+	if docs != nil {
+		return docs, true, nil
+	}
+	b, positions, err := processFile(file, true)
+	if err != nil {
+		return nil, false, kerr.Wrap("PWMEXRBDQA", err)
+	}
+	return []Document{{Bytes: b, Positions: positions}}, false, nil
+}
+
+// ProcessDocument returns an object from a data file, by a name returned by DocumentName.
+func ProcessDocument(name string) ([]byte, *json.Positions, error) {
+	file, index := SplitDocumentName(name)
+	docs, multi, err := ProcessFileDocuments(file)
+	if err != nil {
+		return nil, nil, kerr.Wrap("GDXUVMLRKS", err)
+	}
+	if docs == nil {
+		return nil, nil, nil
+	}
+	if index < 0 {
+		if multi {
+			return nil, nil, kerr.New("UEWKQNRJMA", "%s holds several objects, so the index is needed", file)
+		}
+		index = 0
+	} else if !multi || index >= len(docs) {
+		return nil, nil, kerr.New("HYCBSTAIWL", "Object %d not found in %s", index, file)
+	}
+	return docs[index].Bytes, docs[index].Positions, nil
+}
+
+// jsonDocuments returns the objects in a json array, or nil if the root isn't an array.
+func jsonDocuments(file string, data []byte) ([]Document, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return nil, nil
+	}
+	positions, err := json.ScanPositions(file, data)
+	if err != nil {
+		return nil, kerr.Wrap("BFXKOSLJTE", err)
+	}
+	var raw []json.RawMessage
+	if err := json.UnmarshalPlain(data, &raw); err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("QDLWUPCENY", err)
+	}
+	docs := []Document{}
+	for i, r := range raw {
+		docs = append(docs, Document{Bytes: []byte(r), Positions: positions.Item(i)})
+	}
+	return docs, nil
+}
+
+// yamlDocuments returns the documents in a yaml stream, or nil if there are fewer than two. If a
+// document can't be read, the error is at the position named by DocumentName. Only an error in a
+// file without --- separators is left for processFile to report, because it reads just the first
+// document.
+func yamlDocuments(file string, data []byte) ([]Document, error) {
+	nodes, err := YamlDocuments(data)
+	if err != nil {
+		if len(nodes) == 0 && documentEnd(data, 0) == len(data) {
+			return nil, nil
+		}
+		return nil, json.NewPositionError("RMVJXAQHGS", json.Position{File: DocumentName(file, len(nodes), true)}, err)
+	}
+	if len(nodes) < 2 {
+		return nil, nil
+	}
+	docs := []Document{}
+	for i, n := range nodes {
+		j, p, err := yamlNodeToJson(file, n.Content[0])
+		if err != nil {
+			return nil, json.NewPositionError("XWTGCHKEPB", json.Position{File: DocumentName(file, i, true)}, err)
+		}
+		docs = append(docs, Document{Bytes: j, Positions: p})
+	}
+	return docs, nil
+}
+
+// YamlDocuments returns the document nodes in a yaml file, with the comments. Empty documents are
+// skipped, so the nodes are in the same order as the objects returned by ProcessFileDocuments. If
+// a document isn't valid, the nodes before it are returned with the error.
+func YamlDocuments(data []byte) ([]*yamlv3.Node, error) {
+	nodes := []*yamlv3.Node{}
+	dec := yamlv3.NewDecoder(bytes.NewReader(data))
+	for {
+		n := &yamlv3.Node{}
+		err := dec.Decode(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nodes, kerr.Wrap("KSNWOGTBXE", err)
+		}
+		if len(n.Content) == 0 || n.Content[0].Tag == "!!null" && n.Content[0].Value == "" {
+			// an empty document, e.g. after a trailing ---
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, nil
+}
+
+// ReplaceDocument returns the contents of a data file that holds several objects, with the
+// object at index replaced by the json in object. Only the bytes of the replaced object are
+// changed, so the formatting and comments of the other objects are kept. In a yaml file the
+// object is converted to yaml.
+func ReplaceDocument(file string, index int, object []byte) ([]byte, error) {
+	docs, multi, err := ProcessFileDocuments(file)
+	if err != nil {
+		return nil, kerr.Wrap("BQNOGHYWEM", err)
+	}
+	if !multi || index < 0 || index >= len(docs) {
+		return nil, kerr.New("TCVLMAUIRK", "Object %d not found in %s", index, file)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("PVKHLWQSEB", err)
+	}
+	var start, end int
+	var replacement []byte
+	if filepath.Ext(file) == ".json" {
+		// The json of each object is the raw bytes of the array item, so it ends after the
+		// length of the json.
+		start = offset(data, docs[index].Positions.Get())
+		end = start + len(docs[index].Bytes)
+		replacement = bytes.TrimSpace(object)
+	} else {
+		nodes, err := YamlDocuments(data)
+		if err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("RYFOEQMTHN", err)
+		}
+		n := nodes[index].Content[0]
+		start = offset(data, json.Position{Line: n.Line, Column: n.Column})
+		end = documentEnd(data, start)
+		if replacement, err = jsonToYaml(object); err != nil {
+			return nil, kerr.Wrap("WKHAFNXTPE", err)
+		}
+	}
+	out := &bytes.Buffer{}
+	out.Write(data[:start])
+	out.Write(replacement)
+	out.Write(data[end:])
+	return out.Bytes(), nil
+}
+
+// offset returns the byte offset of a position in data. The column of a position is counted in
+// runes.
+func offset(data []byte, p json.Position) int {
+	o := 0
+	for line := 1; line < p.Line; line++ {
+		i := bytes.IndexByte(data[o:], '\n')
+		if i < 0 {
+			// ke: {"block": {"notest": true}}
+			return len(data)
+		}
+		o += i + 1
+	}
+	for column := 1; column < p.Column && o < len(data); column++ {
+		_, size := utf8.DecodeRune(data[o:])
+		o += size
+	}
+	return o
+}
+
+// documentEnd returns the offset of the end of the yaml document that starts at start. This is
+// the start of the next line that is a --- or ... marker, or the end of the data.
+func documentEnd(data []byte, start int) int {
+	o := start
+	for {
+		i := bytes.IndexByte(data[o:], '\n')
+		if i < 0 {
+			return len(data)
+		}
+		o += i + 1
+		line := data[o:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i]
+		}
+		line = bytes.TrimRight(line, " \t\r")
+		if bytes.Equal(line, []byte("---")) || bytes.Equal(line, []byte("...")) || bytes.HasPrefix(line, []byte("--- ")) {
+			return o
+		}
+	}
+}
+
+// jsonToYaml converts json to block style yaml with the keys in the same order.
+func jsonToYaml(j []byte) ([]byte, error) {
+	// The json is compacted, because tabs can't be used for indentation in yaml.
+	compact := &bytes.Buffer{}
+	if err := json.Compact(compact, j); err != nil {
+		return nil, kerr.Wrap("SMTRCYFGXA", err)
+	}
+	n := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(compact.Bytes(), n); err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("DCRBMVLXTU", err)
+	}
+	blockStyle(n)
+	buf := &bytes.Buffer{}
+	enc := yamlv3.NewEncoder(buf)
+	enc.SetIndent(4)
+	if err := enc.Encode(n); err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("QOXJWIHYGE", err)
+	}
+	if err := enc.Close(); err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("GNTVUAKJSR", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// blockStyle clears the flow and quoting styles that the nodes have when they are parsed from
+// json, so the yaml is written in block style and strings are only quoted where needed.
+func blockStyle(n *yamlv3.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
@@ -0,0 +1,182 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/tests"
+)
+
+func TestDocumentName(t *testing.T) {
+	assert.Equal(t, "a.yaml", DocumentName("a.yaml", 0, false))
+	assert.Equal(t, "b/a.yaml:2", DocumentName("b/a.yaml", 2, true))
+
+	tests := []struct {
+		name  string
+		file  string
+		index int
+	}{
+		{"a.yaml", "a.yaml", -1},
+		{"b/a.yaml:2", "b/a.yaml", 2},
+		{"a.json:0", "a.json", 0},
+		{"a.txt:1", "a.txt:1", -1},
+		{"a.yaml:b", "a.yaml:b", -1},
+		{"a.yaml:-1", "a.yaml:-1", -1},
+		{"a:1.json", "a:1.json", -1},
+	}
+	for _, test := range tests {
+		file, index := SplitDocumentName(test.name)
+		assert.Equal(t, test.file, file, test.name)
+		assert.Equal(t, test.index, index, test.name)
+	}
+}
+
+func TestProcessFileDocuments(t *testing.T) {
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+	_, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": "type: a\nid: a\n---\n---\ntype: a\nid: b\nc: [d]\n",
+		"b.yaml": "---\ntype: a\nid: c\n",
+		"c.json": "[\n\t{\"type\": \"a\", \"id\": \"d\"},\n\t{\n\t\t\"type\": \"a\",\n\t\t\"id\": \"e\"\n\t}\n]\n",
+		"d.json": "[{\"type\": \"a\"",
+		"e.txt":  "foo",
+		"f.yaml": "type: [\n---\nid: b\n",
+		"h.yaml": "type: a\nid: a\n---\ntype: [\n",
+		"i.yaml": "type: a\nid: a\n---\n? [a]\n: b\n",
+		"j.yaml": "type: [\n",
+	})
+
+	docs, multi, err := ProcessFileDocuments(filepath.Join(dir, "a.yaml"))
+	require.NoError(t, err)
+	assert.True(t, multi)
+	require.Equal(t, 2, len(docs))
+	assert.Equal(t, `{"id":"a","type":"a"}`, string(docs[0].Bytes))
+	assert.Equal(t, `{"c":["d"],"id":"b","type":"a"}`, string(docs[1].Bytes))
+	assert.Equal(t, 1, docs[0].Positions.Line)
+	assert.Equal(t, 5, docs[1].Positions.Line)
+	assert.Equal(t, 7, docs[1].Positions.Key("c").Item(0).Line)
+	assert.Equal(t, 5, docs[1].Positions.Key("c").Item(0).Column)
+
+	docs, multi, err = ProcessFileDocuments(filepath.Join(dir, "b.yaml"))
+	require.NoError(t, err)
+	assert.False(t, multi)
+	require.Equal(t, 1, len(docs))
+	assert.Equal(t, `{"id":"c","type":"a"}`, string(docs[0].Bytes))
+
+	docs, multi, err = ProcessFileDocuments(filepath.Join(dir, "c.json"))
+	require.NoError(t, err)
+	assert.True(t, multi)
+	require.Equal(t, 2, len(docs))
+	assert.Equal(t, `{"type": "a", "id": "d"}`, string(docs[0].Bytes))
+	assert.Equal(t, 2, docs[0].Positions.Line)
+	assert.Equal(t, 5, docs[1].Positions.Key("id").Line)
+
+	_, _, err = ProcessFileDocuments(filepath.Join(dir, "d.json"))
+	assert.IsError(t, err, "NVRUQWDHXO")
+	assert.HasError(t, err, "BFXKOSLJTE")
+
+	docs, _, err = ProcessFileDocuments(filepath.Join(dir, "e.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, docs)
+
+	// an error in any document of a stream is returned with the index of the document
+	_, _, err = ProcessFileDocuments(filepath.Join(dir, "f.yaml"))
+	assert.IsError(t, err, "NVRUQWDHXO")
+	assert.HasError(t, err, "RMVJXAQHGS")
+	assert.Contains(t, err.Error(), "f.yaml:0: ")
+
+	_, _, err = ProcessFileDocuments(filepath.Join(dir, "h.yaml"))
+	assert.HasError(t, err, "RMVJXAQHGS")
+	assert.Contains(t, err.Error(), "h.yaml:1: ")
+
+	_, _, err = ProcessFileDocuments(filepath.Join(dir, "i.yaml"))
+	assert.HasError(t, err, "XWTGCHKEPB")
+	assert.Contains(t, err.Error(), "i.yaml:1: ")
+
+	// a file without separators is a single object
+	_, _, err = ProcessFileDocuments(filepath.Join(dir, "j.yaml"))
+	assert.IsError(t, err, "PWMEXRBDQA")
+
+	_, _, err = ProcessFileDocuments(filepath.Join(dir, "g.yaml"))
+	assert.IsError(t, err, "OTKWQFXMAE")
+}
+
+func TestProcessDocument(t *testing.T) {
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+	_, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": "type: a\nid: a\n---\ntype: a\nid: b\n",
+		"b.json": `{"type": "a", "id": "c"}`,
+		"c.txt":  "foo",
+	})
+
+	b, p, err := ProcessDocument(filepath.Join(dir, "a.yaml:1"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"b","type":"a"}`, string(b))
+	assert.Equal(t, 4, p.Line)
+
+	b, _, err = ProcessDocument(filepath.Join(dir, "b.json"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"type": "a", "id": "c"}`, string(b))
+
+	b, _, err = ProcessDocument(filepath.Join(dir, "c.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, b)
+
+	_, _, err = ProcessDocument(filepath.Join(dir, "a.yaml"))
+	assert.IsError(t, err, "UEWKQNRJMA")
+
+	_, _, err = ProcessDocument(filepath.Join(dir, "a.yaml:2"))
+	assert.IsError(t, err, "HYCBSTAIWL")
+
+	_, _, err = ProcessDocument(filepath.Join(dir, "b.json:0"))
+	assert.IsError(t, err, "HYCBSTAIWL")
+
+	_, _, err = ProcessDocument(filepath.Join(dir, "d.json"))
+	assert.IsError(t, err, "GDXUVMLRKS")
+}
+
+func TestReplaceDocument(t *testing.T) {
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+	_, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": "# a\nid: a # a\ntype: a\n---\n# b\ntype: a\nid: b\n\n# end of b\n---\n# c\nid: c\ntype: a\n",
+		"b.json": "[\n\t{\"type\": \"a\", \"id\": \"c\"},\n\t{\"type\": \"a\", \"id\": \"d\"}\n]",
+		"c.json": `{"type": "a", "id": "e"}`,
+	})
+
+	// Only the replaced object changes, so the comments and formatting of the others are kept.
+	b, err := ReplaceDocument(filepath.Join(dir, "a.yaml"), 1, []byte("{\n\t\"type\": \"a\",\n\t\"id\": \"b\",\n\t\"c\": \"1\"\n}"))
+	require.NoError(t, err)
+	assert.Equal(t, "# a\nid: a # a\ntype: a\n---\n# b\ntype: a\nid: b\nc: \"1\"\n---\n# c\nid: c\ntype: a\n", string(b))
+
+	b, err = ReplaceDocument(filepath.Join(dir, "a.yaml"), 2, []byte(`{"type": "a", "id": "c", "d": [1, 2]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "# a\nid: a # a\ntype: a\n---\n# b\ntype: a\nid: b\n\n# end of b\n---\n# c\ntype: a\nid: c\nd:\n    - 1\n    - 2\n", string(b))
+
+	b, err = ReplaceDocument(filepath.Join(dir, "b.json"), 0, []byte(`{"type": "a", "id": "f"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "[\n\t{\"type\": \"a\", \"id\": \"f\"},\n\t{\"type\": \"a\", \"id\": \"d\"}\n]", string(b))
+
+	_, err = ReplaceDocument(filepath.Join(dir, "c.json"), 0, []byte(`{}`))
+	assert.IsError(t, err, "TCVLMAUIRK")
+
+	_, err = ReplaceDocument(filepath.Join(dir, "a.yaml"), 3, []byte(`{}`))
+	assert.IsError(t, err, "TCVLMAUIRK")
+
+	_, err = ReplaceDocument(filepath.Join(dir, "a.yaml"), 0, []byte(`{`))
+	assert.IsError(t, err, "WKHAFNXTPE")
+	assert.HasError(t, err, "SMTRCYFGXA")
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "d.json"), 0777))
+	_, err = ReplaceDocument(filepath.Join(dir, "d.json"), 0, []byte(`{}`))
+	assert.IsError(t, err, "BQNOGHYWEM")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "e.json"), []byte("[]"), 0777))
+	_, err = ReplaceDocument(filepath.Join(dir, "e.json"), 0, []byte(`{}`))
+	assert.IsError(t, err, "TCVLMAUIRK")
+}
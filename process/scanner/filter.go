@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/davelondon/kerr"
+)
+
+// IgnoreFile holds patterns of the files and directories in a package dir that aren't data
+// files, e.g. fixtures or node_modules. It uses the same syntax as .gitignore.
+const IgnoreFile = ".keignore"
+
+// Filter selects the data files in a package dir.
+type Filter struct {
+	ignore  []*pattern
+	include []*pattern
+}
+
+type pattern struct {
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+// NewFilter returns the filter for the package dir. The patterns in the .keignore file in dir
+// are followed by the exclude patterns, so an exclude pattern can override the .keignore file.
+// If there are include patterns, only files that match one of them, or are in a directory that
+// matches one, are data files.
+func NewFilter(dir string, include []string, exclude []string) (*Filter, error) {
+	f := &Filter{}
+	b, err := ioutil.ReadFile(filepath.Join(dir, IgnoreFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, kerr.Wrap("LQJBWOXDVN", err)
+	}
+	lines := []string{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	for _, line := range append(lines, exclude...) {
+		p, err := compilePattern(line)
+		if err != nil {
+			return nil, kerr.Wrap("UHXQEBMKSA", err)
+		}
+		if p != nil {
+			f.ignore = append(f.ignore, p)
+		}
+	}
+	for _, line := range include {
+		p, err := compilePattern(line)
+		if err != nil {
+			return nil, kerr.Wrap("NCYTWDGOEK", err)
+		}
+		if p != nil {
+			f.include = append(f.include, p)
+		}
+	}
+	return f, nil
+}
+
+// compilePattern compiles a line of a .keignore file. It returns nil for blank lines and
+// comments.
+func compilePattern(line string) (*pattern, error) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil, nil
+	}
+	p := &pattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	// A pattern with a slash is relative to the package dir, otherwise it matches at any depth.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := ""
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			expr += "(.*/)?"
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			expr += "(/.*)?"
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			expr += ".*"
+			i++
+		case c == '*':
+			expr += "[^/]*"
+		case c == '?':
+			expr += "[^/]"
+		case c == '[':
+			end := strings.Index(line[i:], "]")
+			if end < 0 {
+				expr += regexp.QuoteMeta(line[i:])
+				i = len(line)
+				break
+			}
+			class := line[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr += "[" + class + "]"
+			i += end
+		case c == '\\' && i+1 < len(line):
+			expr += regexp.QuoteMeta(line[i+1 : i+2])
+			i++
+		default:
+			expr += regexp.QuoteMeta(string(c))
+		}
+	}
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, kerr.Wrap("QBSGPHVNYM", err)
+	}
+	p.re = re
+	return p, nil
+}
+
+func (p *pattern) match(rel string, dir bool) bool {
+	if p.dirOnly && !dir {
+		return false
+	}
+	return p.re.MatchString(rel)
+}
+
+// Ignored is true if the file or directory, relative to the package dir and with forward
+// slashes, isn't scanned. The patterns are checked in order and the last match wins, so a
+// negated pattern can include a file again. The directories a file is in aren't checked,
+// because ScanDirToFiles doesn't walk into an ignored directory.
+func (f *Filter) Ignored(rel string, dir bool) bool {
+	if f == nil {
+		return false
+	}
+	ignored := false
+	for _, p := range f.ignore {
+		if p.match(rel, dir) {
+			ignored = !p.negate
+		}
+	}
+	if ignored || dir || len(f.include) == 0 {
+		return ignored
+	}
+	for path, isDir := rel, false; path != "."; path, isDir = filepath.ToSlash(filepath.Dir(path)), true {
+		for _, p := range f.include {
+			if !p.negate && p.match(path, isDir) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,113 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/context/envctx"
+	"kego.io/tests"
+)
+
+func TestFilter(t *testing.T) {
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+	_, dir := cb.TempPackage("a", map[string]string{
+		".keignore": "# comment\n\nnode_modules/\n/fixtures\n*.ci.json\n!keep.ci.json\ndocs/**/*.json\n\\#hash.json\nf?o.json\n[ab].json\n",
+	})
+
+	f, err := NewFilter(dir, nil, []string{"!fixtures", "bar/*.json"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		rel     string
+		dir     bool
+		ignored bool
+	}{
+		{"a.json", false, true},
+		{"c.json", false, false},
+		{"node_modules", true, true},
+		{"b/node_modules", true, true},
+		{"node_modules", false, false},
+		{"fixtures", true, false},
+		{"b/fixtures", true, false},
+		{"x.ci.json", false, true},
+		{"b/x.ci.json", false, true},
+		{"keep.ci.json", false, false},
+		{"docs/a/b/c.json", false, true},
+		{"docs/c.json", false, true},
+		{"docs/c.yaml", false, false},
+		{"#hash.json", false, true},
+		{"foo.json", false, true},
+		{"fooo.json", false, false},
+		{"bar/c.json", false, true},
+		{"b/bar/c.json", false, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.ignored, f.Ignored(test.rel, test.dir), test.rel)
+	}
+
+	f, err = NewFilter(dir, []string{"data", "*.yaml", "!c.json"}, nil)
+	require.NoError(t, err)
+	assert.False(t, f.Ignored("data/c.json", false))
+	assert.False(t, f.Ignored("b/c.yaml", false))
+	assert.True(t, f.Ignored("c.json", false))
+	assert.False(t, f.Ignored("b", true))
+	assert.True(t, f.Ignored("data/a.json", false))
+
+	var nilFilter *Filter
+	assert.False(t, nilFilter.Ignored("a.json", false))
+
+	_, err = NewFilter(dir, nil, []string{"[z-a]"})
+	assert.IsError(t, err, "UHXQEBMKSA")
+	assert.HasError(t, err, "QBSGPHVNYM")
+
+	_, err = NewFilter(dir, []string{"[z-a]"}, nil)
+	assert.IsError(t, err, "NCYTWDGOEK")
+
+	f, err = NewFilter(dir, nil, []string{"[a"})
+	require.NoError(t, err)
+	assert.True(t, f.Ignored("[a", false))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, ".keignore")))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".keignore"), 0777))
+	_, err = NewFilter(dir, nil, nil)
+	assert.IsError(t, err, "LQJBWOXDVN")
+
+	for f := range ScanDirToFiles(cb.Ctx(), dir, false) {
+		assert.IsError(t, f.Err, "EKSPXWBMRA")
+	}
+}
+
+func TestScanPackageToFiles(t *testing.T) {
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+	_, dir := cb.TempPackage("a", map[string]string{
+		".keignore": "fixtures/\n",
+		"a.json":    "{}",
+		"b.json":    "{}",
+		"c.yaml":    "a: b",
+	})
+	for _, name := range []string{"fixtures/d.json", "node_modules/e/f.json", "data/g.json", "data/fixtures/h.json", "data/node_modules.json"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0777))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("{}"), 0777))
+	}
+
+	scan := func(env *envctx.Env) []string {
+		out := []string{}
+		for f := range ScanPackageToFiles(cb.Ctx(), env) {
+			require.NoError(t, f.Err)
+			rel, err := filepath.Rel(dir, f.File)
+			require.NoError(t, err)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{".keignore", "a.json", "b.json", "c.yaml", "data/g.json", "data/node_modules.json", "node_modules/e/f.json"}, scan(&envctx.Env{Dir: dir, Recursive: true}))
+	assert.Equal(t, []string{"a.json", "data/g.json"}, scan(&envctx.Env{Dir: dir, Recursive: true, Include: []string{"data", "a.json"}, Exclude: []string{"node_modules*"}}))
+	assert.Equal(t, []string{"a.json", "b.json"}, scan(&envctx.Env{Dir: dir, Include: []string{"*.json"}}))
+}
@@ -7,11 +7,11 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/ghodss/yaml"
-
 	"context"
 
 	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/json"
 )
 
 type File struct {
@@ -19,14 +19,46 @@ type File struct {
 	Err  error
 }
 
+// ScanDirToFiles returns the files in dir, and in its sub-directories if recursive is true. The
+// files and directories in the .keignore file in dir are skipped.
 func ScanDirToFiles(ctx context.Context, dir string, recursive bool) chan File {
+	return scanDir(ctx, dir, recursive, nil, nil)
+}
+
+// ScanPackageToFiles returns the files in the package dir of the env, and also applies the
+// include and exclude patterns of the package.
+func ScanPackageToFiles(ctx context.Context, env *envctx.Env) chan File {
+	return scanDir(ctx, env.Dir, env.Recursive, env.Include, env.Exclude)
+}
+
+func scanDir(ctx context.Context, dir string, recursive bool, include []string, exclude []string) chan File {
 
 	out := make(chan File)
 
+	var filter *Filter
+
 	process := func(file string, info os.FileInfo, err error) error {
 		if err != nil {
 			return kerr.Wrap("XAAMWOOVFJ", err)
 		}
+		if recursive && info.IsDir() && info.Name() == ".localke" {
+			// ke writes its own files (e.g. the validation report and the parse cache) in .localke, so
+			// we skip it.
+			return filepath.SkipDir
+		}
+		if file != dir {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return kerr.Wrap("JWMCRDUKPA", err)
+			}
+			if filter.Ignored(filepath.ToSlash(rel), info.IsDir()) {
+				if recursive && info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
 		if !info.Mode().IsRegular() {
 			return nil
 		}
@@ -42,6 +74,13 @@ func ScanDirToFiles(ctx context.Context, dir string, recursive bool) chan File {
 
 		defer close(out)
 
+		f, err := NewFilter(dir, include, exclude)
+		if err != nil {
+			out <- File{"", kerr.Wrap("EKSPXWBMRA", err)}
+			return
+		}
+		filter = f
+
 		if recursive {
 			if err := filepath.Walk(dir, process); err != nil {
 				out <- File{"", kerr.Wrap("RNHDOWOSSA", err)}
@@ -66,12 +105,30 @@ func ScanDirToFiles(ctx context.Context, dir string, recursive bool) chan File {
 	return out
 }
 
+// Content is an object in a data file. A file that holds several objects (see
+// ProcessFileDocuments) is returned as a Content for each object, in order.
 type Content struct {
 	File  string
 	Bytes []byte
+	// Positions is the source position of each value in the file. It's nil if the file isn't
+	// valid json, in which case the error is returned when the bytes are unmarshaled.
+	Positions *json.Positions
+	// Index is the index of the object in the file, and Multi is true if the file holds several
+	// objects.
+	Index int
+	Multi bool
 	Err   error
 }
 
+// Name returns the name of the object relative to dir, as returned by DocumentName.
+func (c Content) Name(dir string) (string, error) {
+	rel, err := filepath.Rel(dir, c.File)
+	if err != nil {
+		return "", kerr.Wrap("FRDWKAQVNC", err)
+	}
+	return DocumentName(rel, c.Index, c.Multi), nil
+}
+
 // ScanFiles takes a chanel of files
 func ScanFilesToBytes(ctx context.Context, in chan File) chan Content {
 
@@ -88,16 +145,19 @@ func ScanFilesToBytes(ctx context.Context, in chan File) chan Content {
 					return
 				}
 				if value.Err != nil {
-					out <- Content{"", nil, kerr.Wrap("PQUCOUYLJE", value.Err)}
+					out <- Content{Err: kerr.Wrap("PQUCOUYLJE", value.Err)}
 					return
 				}
-				bytes, err := ProcessFile(value.File)
-				// process returns Bytes == nil for non json files, so we should skip them
-				if bytes != nil || err != nil {
-					out <- Content{value.File, bytes, err}
+				docs, multi, err := ProcessFileDocuments(value.File)
+				if err != nil {
+					out <- Content{File: value.File, Err: err}
+				}
+				// docs is nil for non json files, so they're skipped
+				for i, d := range docs {
+					out <- Content{File: value.File, Bytes: d.Bytes, Positions: d.Positions, Index: i, Multi: multi}
 				}
 			case <-ctx.Done():
-				out <- Content{"", nil, kerr.Wrap("AFBJCTFOKX", ctx.Err())}
+				out <- Content{Err: kerr.Wrap("AFBJCTFOKX", ctx.Err())}
 				return
 			}
 		}
@@ -109,27 +169,54 @@ func ScanFilesToBytes(ctx context.Context, in chan File) chan Content {
 }
 
 func ProcessFile(file string) ([]byte, error) {
+	bytes, _, err := processFile(file, false)
+	if err != nil {
+		return nil, kerr.Wrap("DXBPTQQVUJ", err)
+	}
+	return bytes, nil
+}
+
+// ProcessFilePositions is like ProcessFile, but also returns the source position of each value.
+// For yaml files the positions are in the yaml source, not in the converted json.
+func ProcessFilePositions(file string) ([]byte, *json.Positions, error) {
+	bytes, positions, err := processFile(file, true)
+	if err != nil {
+		return nil, nil, kerr.Wrap("WRQXHTCFMV", err)
+	}
+	return bytes, positions, nil
+}
+
+func processFile(file string, scanPositions bool) ([]byte, *json.Positions, error) {
 
 	ext := filepath.Ext(file)
 	isYaml := ext == ".yaml" || ext == ".yml"
 	isJson := ext == ".json"
 
 	if !isYaml && !isJson {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	bytes, err := ioutil.ReadFile(file)
 	if err != nil {
-		return nil, kerr.Wrap("NMWROTKPLJ", err)
+		return nil, nil, kerr.Wrap("NMWROTKPLJ", err)
 	}
 
+	var positions *json.Positions
+
 	if isYaml {
-		j, err := yaml.YAMLToJSON(bytes)
+		j, p, err := yamlToJson(file, bytes)
 		if err != nil {
-			return nil, kerr.Wrap("FAFJCYESRH", err)
+			return nil, nil, kerr.Wrap("FAFJCYESRH", err)
+		}
+		if scanPositions {
+			positions = p
 		}
 		bytes = j
+	} else if scanPositions {
+		// Invalid json is reported with its position when it's unmarshaled, so we ignore the
+		// error here.
+		positions, _ = json.ScanPositions(file, bytes)
 	}
 
-	return bytes, nil
+	return bytes, positions, nil
 }
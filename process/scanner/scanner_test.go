@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 
 	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/json"
 	"kego.io/tests"
 )
 
@@ -96,6 +98,10 @@ func TestScanDirToFiles(t *testing.T) {
 	// put another file in it
 	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "d", "e.json"), []byte("f"), 0777))
 
+	// files in .localke are skipped
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, ".localke"), 0777))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".localke", "report.json"), []byte("g"), 0777))
+
 	ch := ScanDirToFiles(cb.Ctx(), dir, false)
 	out := []File{}
 	for f := range ch {
@@ -116,3 +122,73 @@ func TestScanDirToFiles(t *testing.T) {
 	assert.Equal(t, filepath.Join(dir, "d", "e.json"), out[1].File)
 
 }
+
+func TestProcessFilePositions(t *testing.T) {
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+	_, dir := cb.TempPackage("a", map[string]string{
+		"a.json": "{\n\t\"b\": [\"c\"]\n}",
+		"b.yaml": "d: e\nf:\n  - g\n  - h: i\nj: &k\n  l: m\nn: *k\no:\n  <<: *k\n  p: q\n1: r\n\"y\": s\n",
+		"c.yaml": "",
+		"d.json": "{",
+		"e.yaml": "a: [",
+		"g.yaml": "a: .inf\n",
+		"h.yaml": "? [a]\n: b\n",
+		"i.yaml": "a:\n  <<: b\n",
+		"j.yaml": "a: yes\nb: Off\nc: \"no\"\nd: !!str on\ne: !!bool y\nf: 0o17\ng: 017\non: h\n",
+	})
+
+	b, p, err := ProcessFilePositions(filepath.Join(dir, "a.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n\t\"b\": [\"c\"]\n}", string(b))
+	assert.Equal(t, json.Position{File: filepath.Join(dir, "a.json"), Line: 2, Column: 8}, p.Key("b").Item(0).Get())
+
+	b, p, err = ProcessFilePositions(filepath.Join(dir, "b.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"1":"r","d":"e","f":["g",{"h":"i"}],"false":{"l":"m"},"j":{"l":"m"},"o":{"l":"m","p":"q"},"y":"s"}`, string(b))
+	file := filepath.Join(dir, "b.yaml")
+	assert.Equal(t, json.Position{File: file, Line: 1, Column: 1}, p.Get())
+	assert.Equal(t, json.Position{File: file, Line: 1, Column: 4}, p.Key("d").Get())
+	assert.Equal(t, json.Position{File: file, Line: 3, Column: 3}, p.Key("f").Get())
+	assert.Equal(t, json.Position{File: file, Line: 4, Column: 8}, p.Key("f").Item(1).Key("h").Get())
+	// n is resolved to false by the yaml 1.1 rules
+	assert.Equal(t, json.Position{File: file, Line: 7, Column: 4}, p.Key("false").Get())
+	assert.Equal(t, json.Position{File: file, Line: 6, Column: 6}, p.Key("false").Key("l").Get())
+	assert.Equal(t, json.Position{File: file, Line: 6, Column: 6}, p.Key("o").Key("l").Get())
+	assert.Equal(t, json.Position{File: file, Line: 10, Column: 6}, p.Key("o").Key("p").Get())
+	assert.Equal(t, json.Position{File: file, Line: 11, Column: 4}, p.Key("1").Get())
+	assert.Equal(t, json.Position{File: file, Line: 12, Column: 6}, p.Key("y").Get())
+
+	b, p, err = ProcessFilePositions(filepath.Join(dir, "c.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "null", string(b))
+	assert.Equal(t, json.Position{File: filepath.Join(dir, "c.yaml"), Line: 1, Column: 1}, p.Get())
+
+	// Invalid json is reported when it's unmarshaled
+	b, p, err = ProcessFilePositions(filepath.Join(dir, "d.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "{", string(b))
+	assert.Nil(t, p)
+
+	_, _, err = ProcessFilePositions(filepath.Join(dir, "e.yaml"))
+	assert.HasError(t, err, "FAFJCYESRH")
+
+	_, _, err = ProcessFilePositions(filepath.Join(dir, "g.yaml"))
+	assert.HasError(t, err, "OCJRPHXVDA")
+
+	_, _, err = ProcessFilePositions(filepath.Join(dir, "h.yaml"))
+	assert.HasError(t, err, "JXQWPVEBRI")
+
+	_, _, err = ProcessFilePositions(filepath.Join(dir, "i.yaml"))
+	assert.HasError(t, err, "QHFDTAUWNC")
+
+	// scalars are resolved with the yaml 1.1 rules
+	b, _, err = ProcessFilePositions(filepath.Join(dir, "j.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":true,"b":false,"c":"no","d":"on","e":true,"f":"0o17","g":15,"true":"h"}`, string(b))
+
+	b, p, err = ProcessFilePositions(filepath.Join(dir, "f.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, b)
+	assert.Nil(t, p)
+}
@@ -0,0 +1,195 @@
+package scanner
+
+import (
+	"math"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	yaml "gopkg.in/yaml.v3"
+	"kego.io/json"
+)
+
+// yamlToJson converts a yaml file to json, and returns the position of every value in the yaml
+// source. The json and the positions are built from the same yaml node tree, so the tree of
+// positions has the same shape as the converted json.
+func yamlToJson(file string, data []byte) ([]byte, *json.Positions, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, kerr.Wrap("QKEYCPRMXN", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		// An empty file is converted to null.
+		return []byte("null"), &json.Positions{Position: json.Position{File: file, Line: 1, Column: 1}}, nil
+	}
+	b, p, err := yamlNodeToJson(file, doc.Content[0])
+	if err != nil {
+		return nil, nil, kerr.Wrap("GKIXFUSXWB", err)
+	}
+	return b, p, nil
+}
+
+// yamlNodeToJson converts a yaml node to json, and returns the position of every value.
+func yamlNodeToJson(file string, n *yaml.Node) ([]byte, *json.Positions, error) {
+	v, p, err := yamlValue(file, n)
+	if err != nil {
+		return nil, nil, kerr.Wrap("RWOHJMPUAV", err)
+	}
+	b, err := json.MarshalPlain(v)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, nil, kerr.Wrap("YCSQNWTLEB", err)
+	}
+	return b, p, nil
+}
+
+// yamlValue returns the value of a yaml node in the form it's unmarshaled from json, and the
+// position of every value.
+func yamlValue(file string, n *yaml.Node) (interface{}, *json.Positions, error) {
+	p := &json.Positions{Position: json.Position{File: file, Line: n.Line, Column: n.Column}}
+	// An alias is reported at the position it's used, but the values inside it are reported at
+	// the position of the anchor.
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.SequenceNode:
+		a := []interface{}{}
+		p.Array = []*json.Positions{}
+		for _, item := range n.Content {
+			v, ip, err := yamlValue(file, item)
+			if err != nil {
+				return nil, nil, kerr.Wrap("NKAYFMRXBW", err)
+			}
+			a = append(a, v)
+			p.Array = append(p.Array, ip)
+		}
+		return a, p, nil
+	case yaml.MappingNode:
+		m := map[string]interface{}{}
+		p.Map = map[string]*json.Positions{}
+		var merges []*yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Tag == "!!merge" {
+				merges = append(merges, value)
+				continue
+			}
+			k, err := yamlKey(key)
+			if err != nil {
+				return nil, nil, kerr.Wrap("TSDMQBEVLG", err)
+			}
+			v, vp, err := yamlValue(file, value)
+			if err != nil {
+				return nil, nil, kerr.Wrap("HUWPBXQAOC", err)
+			}
+			m[k] = v
+			p.Map[k] = vp
+		}
+		// Keys from merged mappings don't override keys in the mapping itself, and keys from an
+		// earlier mapping in a merged sequence override keys from a later one.
+		for _, merge := range merges {
+			for _, mn := range yamlMerged(merge) {
+				v, mp, err := yamlValue(file, mn)
+				if err != nil {
+					return nil, nil, kerr.Wrap("EGVKXRJONA", err)
+				}
+				mv, ok := v.(map[string]interface{})
+				if !ok {
+					return nil, nil, kerr.New("QHFDTAUWNC", "%s: a merged value must be a mapping", mp.Position)
+				}
+				for k := range mv {
+					if _, ok := m[k]; !ok {
+						m[k] = mv[k]
+						p.Map[k] = mp.Map[k]
+					}
+				}
+			}
+		}
+		return m, p, nil
+	case yaml.ScalarNode:
+		v, err := yamlScalar(n)
+		if err != nil {
+			return nil, nil, kerr.Wrap("LBIVSEPKYC", err)
+		}
+		return v, p, nil
+	}
+	// ke: {"block": {"notest": true}}
+	return nil, nil, kerr.New("MDXGCFWTOH", "%s: unsupported yaml node", p.Position)
+}
+
+// yamlMerged returns the mappings referenced by a merge key, which may be a single mapping or a
+// sequence of mappings.
+func yamlMerged(n *yaml.Node) []*yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.SequenceNode {
+		out := []*yaml.Node{}
+		for _, item := range n.Content {
+			out = append(out, yamlMerged(item)...)
+		}
+		return out
+	}
+	return []*yaml.Node{n}
+}
+
+// yaml11Bools are the booleans of the yaml 1.1 rules. The yaml 1.2 rules only resolve true and
+// false, so the rest are resolved here.
+var yaml11Bools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false,
+	"off": false, "Off": false, "OFF": false,
+}
+
+// yamlScalar returns the value of a scalar. The tags are resolved with the yaml 1.1 rules, in the
+// same way as the yaml package the editor uses to write files, so yes, no, on, off, y and n are
+// booleans and 0o isn't an octal prefix. Timestamps and binary values are kept as strings.
+func yamlScalar(n *yaml.Node) (interface{}, error) {
+	plain := n.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0
+	tag := n.ShortTag()
+	if b, ok := yaml11Bools[n.Value]; ok && (tag == "!!bool" || (tag == "!!str" && plain)) {
+		return b, nil
+	}
+	if tag == "!!int" && plain && strings.HasPrefix(strings.Replace(n.Value, "_", "", -1), "0o") {
+		return n.Value, nil
+	}
+	switch tag {
+	case "!!null":
+		return nil, nil
+	case "!!bool", "!!int", "!!float":
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, kerr.Wrap("WFNSKOTIEA", err)
+		}
+		if f, ok := v.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			return nil, kerr.New("OCJRPHXVDA", "%d:%d: %s can't be converted to json", n.Line, n.Column, n.Value)
+		}
+		return v, nil
+	}
+	return n.Value, nil
+}
+
+// yamlKey returns the key as it appears in the converted json. A key that isn't a string, e.g.
+// 1 or true, is converted to its json form.
+func yamlKey(key *yaml.Node) (string, error) {
+	for key.Kind == yaml.AliasNode && key.Alias != nil {
+		key = key.Alias
+	}
+	if key.Kind != yaml.ScalarNode {
+		return "", kerr.New("JXQWPVEBRI", "%d:%d: a key must be a scalar", key.Line, key.Column)
+	}
+	v, err := yamlScalar(key)
+	if err != nil {
+		return "", kerr.Wrap("UKMTYGLSDE", err)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.MarshalPlain(v)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return "", kerr.Wrap("NBHXCVRFQA", err)
+	}
+	return string(b), nil
+}
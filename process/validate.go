@@ -0,0 +1,143 @@
+package process
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/cmdctx"
+	"kego.io/context/envctx"
+	"kego.io/context/jsonctx"
+	"kego.io/context/sysctx"
+	"kego.io/process/validate"
+)
+
+// Validate validates the data files in the package. The types are read from the sys ctx, so the
+// validate command doesn't need to be built. Types that haven't been compiled into this binary are
+// represented dynamically by node.Node, so only the built-in rules are enforced. If the package or
+// one of its aliased packages has custom Go Enforce or Validate methods, or the compiled types are
+// out of date, we fall back to RunValidateCommand. The errors are returned in the same way as
+// RunValidateCommand.
+func Validate(ctx context.Context) error {
+	return validatePackage(ctx, validate.NewValidator(), nil)
+}
+
+// validatePackage is like Validate, but the files are validated with v, so if the package has
+// been validated by v before, only the changed files are validated. The keys of changed are the
+// full paths of the files, and if changed is nil every file is validated.
+func validatePackage(ctx context.Context, v *validate.Validator, changed map[string]bool) error {
+	env := envctx.FromContext(ctx)
+	cmd := cmdctx.FromContext(ctx)
+
+	fallback, err := needsValidateCommand(ctx, "kego.io/system", map[string]bool{})
+	if err != nil {
+		return kerr.Wrap("QHXBMWTFJR", err)
+	}
+	if !fallback {
+		if fallback, err = needsValidateCommand(ctx, env.Path, map[string]bool{}); err != nil {
+			return kerr.Wrap("GNRXIXKTYC", err)
+		}
+	}
+	if fallback {
+		if err := RunValidateCommand(ctx); err != nil {
+			return kerr.Wrap("CSGOYGNVCH", err)
+		}
+		return nil
+	}
+
+	cmd.Print("Validating... ")
+
+	errors, err := v.Validate(ctx, changed)
+	if err != nil {
+		return kerr.Wrap("VNDHBWRTUS", err)
+	}
+	if len(errors) > 0 {
+		lines := []string{}
+		for _, e := range errors {
+			lines = append(lines, fmt.Sprintf("%s: %s: %s", e.Position(), e.Source.Path(), e.Description))
+		}
+		description := strings.Join(lines, "\n")
+		// The validate command writes the errors to the log, so we do the same.
+		cmd.Println()
+		cmd.Println(description)
+		return validate.ValidationCommandError{Struct: kerr.New("BYDHDFFIEN", description), Entries: validate.NewReportEntries(errors)}
+	}
+	cmd.Println("OK.")
+	return nil
+}
+
+// needsValidateCommand is true if the types in the package or its aliased packages can't be
+// validated in this binary. If the package has been compiled into this binary, its Go types must
+// be up to date. If it hasn't, it must not have custom Go Enforce or Validate methods.
+func needsValidateCommand(ctx context.Context, path string, done map[string]bool) (bool, error) {
+	if done[path] {
+		return false, nil
+	}
+	done[path] = true
+
+	spi, ok := sysctx.FromContext(ctx).Get(path)
+	if !ok {
+		return false, kerr.New("JKLYRODNVO", "%s not found in sys ctx", path)
+	}
+
+	for _, aliasPath := range spi.Aliases {
+		needs, err := needsValidateCommand(ctx, aliasPath, done)
+		if err != nil {
+			return false, kerr.Wrap("PQNHDMMHEL", err)
+		}
+		if needs {
+			return true, nil
+		}
+	}
+
+	if jpi, ok := jsonctx.FromContext(ctx).Packages.Get(path); ok {
+		// The validate command will report that the types have changed.
+		return jpi.Hash != spi.Hash, nil
+	}
+
+	custom, err := hasCustomValidation(spi.Dir)
+	if err != nil {
+		return false, kerr.Wrap("XUDQMFGHBL", err)
+	}
+	return custom, nil
+}
+
+// hasCustomValidation is true if any of the Go files in dir (apart from generated.go and tests)
+// declare an Enforce or Validate method.
+func hasCustomValidation(dir string) (bool, error) {
+	if dir == "" {
+		return false, nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, kerr.Wrap("EHVOXNIQJD", err)
+	}
+	fset := token.NewFileSet()
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || filepath.Ext(name) != ".go" || name == "generated.go" || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return false, kerr.Wrap("RNVSTMWDXH", err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			if fn.Name.Name == "Enforce" || fn.Name.Name == "Validate" {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -3,7 +3,9 @@ package command // import "kego.io/process/validate/command"
 // ke: {"package": {"complete": true}}
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
 	"syscall"
@@ -15,6 +17,7 @@ import (
 	"kego.io/context/jsonctx"
 	"kego.io/context/sysctx"
 	"kego.io/context/wgctx"
+	"kego.io/json"
 	"kego.io/process"
 	"kego.io/process/validate"
 )
@@ -23,13 +26,13 @@ import (
 // ke: {"func": {"notest": true}}
 func ValidateMain(path string) {
 
-	// Using process.Flags as the options means that the non-specified options are read from the
-	// command flags.
-	update := false
-	options := &process.Flags{
-		Update: &update,
-		Path:   &path,
-	}
+	options := &process.Options{Path: path}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.BoolVar(&options.Log, "l", false, "Log: print progress messages")
+	// The report flag is passed by ke, which reads the file to get the validation errors.
+	report := fs.String("r", "", "Report: write the validation errors to this file as json")
+	fs.Parse(os.Args[1:])
 
 	ctx, cancel, err := process.Initialise(context.Background(), options)
 	if err != nil {
@@ -47,12 +50,12 @@ func ValidateMain(path string) {
 	signal.Notify(interrupt, os.Interrupt)
 	signal.Notify(interrupt, syscall.SIGTERM)
 
-	exitStatus := validateMain(ctx, cancel, log, interrupt)
+	exitStatus := validateMain(ctx, cancel, log, interrupt, *report)
 
 	wgctx.WaitAndExit(ctx, exitStatus)
 
 }
-func validateMain(ctx context.Context, cancel context.CancelFunc, log func(string), interrupt chan os.Signal) int {
+func validateMain(ctx context.Context, cancel context.CancelFunc, log func(string), interrupt chan os.Signal, report string) int {
 
 	go func() {
 		<-interrupt
@@ -78,15 +81,32 @@ func validateMain(ctx context.Context, cancel context.CancelFunc, log func(strin
 		log(err.Error())
 		return 1 // Exit status 1: generic error
 	}
+	if report != "" {
+		if err := writeReport(report, errors); err != nil {
+			log(err.Error())
+			return 1 // Exit status 1: generic error
+		}
+	}
 	if len(errors) > 0 {
 		for _, e := range errors {
-			log(fmt.Sprintf("%s: %s", e.Source.Path(), e.Description))
+			log(fmt.Sprintf("%s: %s: %s", e.Position(), e.Source.Path(), e.Description))
 		}
 		return 4 // Exit status 4: validation error
 	}
 	return 0 // Exit status 0: success
 }
 
+func writeReport(file string, errors []validate.ValidationError) error {
+	b, err := json.MarshalPlain(validate.NewReportEntries(errors))
+	if err != nil {
+		return kerr.Wrap("CXIWHQKQEB", err)
+	}
+	if err := ioutil.WriteFile(file, b, 0644); err != nil {
+		return kerr.Wrap("PUQPRKRSNT", err)
+	}
+	return nil
+}
+
 func comparePackageHash(ctx context.Context, path string) (changes bool, err error) {
 
 	scache := sysctx.FromContext(ctx)
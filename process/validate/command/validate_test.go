@@ -1,12 +1,16 @@
 package command
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/davelondon/ktest/assert"
 	"kego.io/context/sysctx"
+	"kego.io/json"
 	"kego.io/process/parser"
+	"kego.io/process/validate"
 	_ "kego.io/process/validate/tests"
 	_ "kego.io/system"
 	"kego.io/tests"
@@ -32,7 +36,7 @@ func TestValidateMain(t *testing.T) {
 
 	interrupt := make(chan os.Signal, 1)
 
-	exitStatus := validateMain(cb.Ctx(), cancel, log, interrupt)
+	exitStatus := validateMain(cb.Ctx(), cancel, log, interrupt, "")
 	assert.Equal(t, 1, exitStatus)
 	assert.False(t, cancelled)
 	assert.Contains(t, logged, "NHXWLPHCHL")
@@ -40,7 +44,7 @@ func TestValidateMain(t *testing.T) {
 
 	cb.Jauto().Sauto(parser.Parse)
 
-	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt)
+	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt, "")
 	assert.Equal(t, 0, exitStatus)
 	assert.False(t, cancelled)
 	assert.Equal(t, logged, "")
@@ -50,7 +54,7 @@ func TestValidateMain(t *testing.T) {
 		id: a`)
 	cb.Sauto(parser.Parse)
 
-	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt)
+	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt, "")
 	assert.Equal(t, 3, exitStatus)
 	assert.False(t, cancelled)
 	assert.Equal(t, logged, "")
@@ -63,15 +67,26 @@ func TestValidateMain(t *testing.T) {
 			min-length: 2`).
 		Sauto(parser.Parse)
 
-	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt)
+	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt, "")
 	assert.Equal(t, 4, exitStatus)
 	assert.False(t, cancelled)
-	assert.Equal(t, logged, "a: MaxLength 1 must not be less than MinLength 2")
+	assert.Equal(t, logged, "a.yaml:2:13: a: MaxLength 1 must not be less than MinLength 2")
+	logged = ""
+
+	report := filepath.Join(dir, "report.json")
+	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt, report)
+	assert.Equal(t, 4, exitStatus)
+	b, err := ioutil.ReadFile(report)
+	assert.NoError(t, err)
+	var entries []validate.ReportEntry
+	assert.NoError(t, json.UnmarshalPlain(b, &entries))
+	assert.Equal(t, []validate.ReportEntry{{File: "a.yaml", Line: 2, Column: 13, Path: "a", Code: "KULDIJUYFB", Rule: "kego.io/system:@string", Message: "MaxLength 1 must not be less than MinLength 2"}}, entries)
+	os.Remove(report)
 	logged = ""
 
 	cb.RemoveTempFile("a.yaml").Sauto(parser.Parse).TempFile("b.yaml", `%`)
 
-	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt)
+	exitStatus = validateMain(cb.Ctx(), cancel, log, interrupt, "")
 	assert.Equal(t, 1, exitStatus)
 	assert.False(t, cancelled)
 	assert.Contains(t, logged, "IHSVWAUAYW")
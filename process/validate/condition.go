@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/davelondon/kerr"
+	"kego.io/process/validate/selectors"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+// checkCondition checks a condition rule against the object it's attached to. The selectors in the
+// rule are relative to the object, and the errors name the nodes that met the condition.
+func checkCondition(ctx context.Context, n *node.Node, rule *system.ConditionRule) (errors []ValidationError, err error) {
+	p, err := selectors.CreateParser(ctx, n)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return nil, kerr.Wrap("SDWCUNOSPV", err)
+	}
+
+	reason := ""
+	if rule.GetIf() != "" {
+		matches, err := p.GetNodes(rule.GetIf())
+		if err != nil {
+			return nil, kerr.Wrap("OEXPAHYCMX", err)
+		}
+		if len(matches) == 0 {
+			return nil, nil
+		}
+		paths := []string{}
+		for _, m := range matches {
+			paths = append(paths, m.Path())
+		}
+		reason = fmt.Sprintf(", because %s matches %s", strings.Join(paths, ", "), rule.GetIf())
+	}
+
+	if rule.GetThen() != "" {
+		matches, err := p.GetNodes(rule.GetThen())
+		if err != nil {
+			return nil, kerr.Wrap("IKLHJHKGBY", err)
+		}
+		if len(matches) == 0 {
+			message := fmt.Sprintf("Should match %s%s", rule.GetThen(), reason)
+			errors = append(errors, ValidationError{Struct: kerr.New("JHPDSCYYWF", message), Source: n, Rule: ruleType(rule)})
+		}
+	}
+
+	for _, r := range rule.ThenRules {
+		selector := ":root"
+		if base := r.GetRule(nil); base != nil && base.Selector != "" {
+			selector = base.Selector
+		}
+		matches, err := p.GetNodes(selector)
+		if err != nil {
+			return nil, kerr.Wrap("HURMCWFQOK", err)
+		}
+		for _, m := range matches {
+			if m.Null || m.Missing {
+				continue
+			}
+			// The other rules have nothing to enforce, and are rejected by ConditionRule.Validate.
+			switch c := r.(type) {
+			case *system.ConditionRule:
+				e, err := checkCondition(ctx, m, c)
+				if err != nil {
+					return nil, kerr.Wrap("QQREKFMHBB", err)
+				}
+				errors = append(errors, e...)
+			case system.Enforcer:
+				failed, messages, err := c.Enforce(ctx, m.Value)
+				if err != nil {
+					// ke: {"block": {"notest": true}}
+					return nil, kerr.Wrap("AJIBXVLCKA", err)
+				}
+				if failed {
+					for _, message := range messages {
+						message = fmt.Sprintf("%s%s", message, reason)
+						errors = append(errors, ValidationError{Struct: kerr.New("OLEHSBNSNY", message), Source: m, Rule: ruleType(r)})
+					}
+				}
+			}
+		}
+	}
+	return errors, nil
+}
@@ -0,0 +1,122 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestCondition(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yml": `
+			type: system:package
+		`,
+		"server.yml": `
+			type: system:type
+			id: server
+			fields:
+				protocol:
+					type: system:@string
+				port:
+					type: system:@number
+			rules:
+				-   type: system:@condition
+					if: '.protocol:expr(x = "https")'
+					then: '.port:expr(x = 443)'
+				-   type: system:@condition
+					if: '.protocol:expr(x = "http")'
+					then-rules:
+						-   type: system:@number
+							selector: .port
+							maximum: 1024
+		`,
+		"period.yml": `
+			type: system:type
+			id: period
+			fields:
+				start:
+					type: system:@number
+				end:
+					type: system:@number
+			rules:
+				-   type: system:@condition
+					then: ':root:expr(x.end > x.start)'
+		`,
+		"b.yml": `
+			type: server
+			id: b
+			protocol: https
+			port: 443
+		`,
+		"c.yml": `
+			type: server
+			id: c
+			protocol: https
+			port: 80
+		`,
+		"d.yml": `
+			type: server
+			id: d
+			protocol: http
+			port: 8080
+		`,
+		"e.yml": `
+			type: period
+			id: e
+			start: 1
+			end: 5
+		`,
+		"f.yml": `
+			type: period
+			id: f
+			start: 5
+			end: 1
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Jauto().Sauto(parser.Parse)
+
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 3, len(errors))
+
+	assert.IsError(t, errors[0], "JHPDSCYYWF")
+	assert.Equal(t, "c.yml", errors[0].File)
+	assert.Equal(t, "c", errors[0].Source.Path())
+	assert.Equal(t, `Should match .port:expr(x = 443), because c/protocol matches .protocol:expr(x = "https")`, errors[0].Description)
+	assert.Equal(t, "kego.io/system:@condition", errors[0].Rule.Value())
+
+	assert.IsError(t, errors[1], "OLEHSBNSNY")
+	assert.Equal(t, "d.yml", errors[1].File)
+	assert.Equal(t, "d/port", errors[1].Source.Path())
+	assert.Equal(t, `Maximum: value 8080 must not be greater than 1024, because d/protocol matches .protocol:expr(x = "http")`, errors[1].Description)
+	assert.Equal(t, "kego.io/system:@number", errors[1].Rule.Value())
+
+	assert.IsError(t, errors[2], "JHPDSCYYWF")
+	assert.Equal(t, "f.yml", errors[2].File)
+	assert.Equal(t, "f", errors[2].Source.Path())
+	assert.Equal(t, "Should match :root:expr(x.end > x.start)", errors[2].Description)
+
+	// A rule with nothing to enforce can't be checked in then-rules.
+	cb.TempFile("g.yml", "type: system:type\nid: g\nrules: [{type: system:@condition, then-rules: [{type: system:@unique, selector: .a}]}]\n")
+	errors, err = ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 4, len(errors))
+	assert.IsError(t, errors[3], "KULDIJUYFB")
+	assert.Equal(t, "g.yml", errors[3].File)
+	assert.Equal(t, "ThenRules: kego.io/system:@unique at 0 has nothing to enforce, so it can't be checked", errors[3].Description)
+	require.NoError(t, os.Remove(filepath.Join(dir, "g.yml")))
+
+	cb.TempFile("f.yml", "type: period\nid: f\nstart: 1\nend: 5\nrules: [{type: system:@condition, then: '%'}]\n")
+	_, err = ValidatePackage(cb.Ctx())
+	assert.HasError(t, err, "VNHSJDOLRD")
+	assert.HasError(t, err, "IKLHJHKGBY")
+}
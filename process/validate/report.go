@@ -0,0 +1,293 @@
+package validate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	"kego.io/json"
+)
+
+type ReportFormat string
+
+const (
+	REPORT_TEXT  ReportFormat = "text"
+	REPORT_JSON  ReportFormat = "json"
+	REPORT_JUNIT ReportFormat = "junit"
+	REPORT_SARIF ReportFormat = "sarif"
+)
+
+// ReportFormats lists the formats accepted by WriteReport.
+var ReportFormats = []ReportFormat{REPORT_TEXT, REPORT_JSON, REPORT_JUNIT, REPORT_SARIF}
+
+// ReportEntry is the machine readable form of a ValidationError. It is also used to pass
+// validation errors from the validate command back to ke.
+type ReportEntry struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func NewReportEntries(errors []ValidationError) []ReportEntry {
+	out := []ReportEntry{}
+	for _, e := range errors {
+		out = append(out, NewReportEntry(e))
+	}
+	return out
+}
+
+func NewReportEntry(e ValidationError) ReportEntry {
+	p := e.Position()
+	r := ReportEntry{
+		File:    e.File,
+		Line:    p.Line,
+		Column:  p.Column,
+		Code:    e.Id,
+		Message: e.Description,
+	}
+	if e.Source != nil {
+		r.Path = e.Source.Path()
+	}
+	if e.Rule != nil {
+		r.Rule = e.Rule.Value()
+	}
+	return r
+}
+
+// Position returns the location of the node that failed validation.
+func (e ReportEntry) Position() json.Position {
+	return json.Position{File: e.File, Line: e.Line, Column: e.Column}
+}
+
+// WriteReport writes the validation report for a package in the requested format. Entries are
+// sorted by file, position and path so the output is stable.
+func WriteReport(w io.Writer, format ReportFormat, path string, entries []ReportEntry) error {
+	sorted := SortReportEntries(entries)
+	switch format {
+	case REPORT_TEXT, "":
+		return writeTextReport(w, sorted)
+	case REPORT_JSON:
+		return writeJsonReport(w, path, sorted)
+	case REPORT_JUNIT:
+		return writeJunitReport(w, path, sorted)
+	case REPORT_SARIF:
+		return writeSarifReport(w, sorted)
+	}
+	return kerr.New("DLUGXVOHFE", "Unknown report format %s", format)
+}
+
+// SortReportEntries returns a copy of entries sorted by file, position and path.
+func SortReportEntries(entries []ReportEntry) []ReportEntry {
+	sorted := make([]ReportEntry, len(entries))
+	copy(sorted, entries)
+	sort.Stable(sorter.New(
+		len(sorted),
+		func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] },
+		func(i, j int) bool {
+			if sorted[i].File != sorted[j].File {
+				return sorted[i].File < sorted[j].File
+			}
+			if sorted[i].Line != sorted[j].Line {
+				return sorted[i].Line < sorted[j].Line
+			}
+			if sorted[i].Column != sorted[j].Column {
+				return sorted[i].Column < sorted[j].Column
+			}
+			return sorted[i].Path < sorted[j].Path
+		},
+	))
+	return sorted
+}
+
+func writeTextReport(w io.Writer, entries []ReportEntry) error {
+	for _, e := range entries {
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", e.Position(), e.Path, e.Message); err != nil {
+			return kerr.Wrap("OJUVFNRDHY", err)
+		}
+	}
+	return nil
+}
+
+type jsonReport struct {
+	Package string        `json:"package"`
+	Errors  []ReportEntry `json:"errors"`
+}
+
+func writeJsonReport(w io.Writer, path string, entries []ReportEntry) error {
+	b, err := json.MarshalPlainIndent(jsonReport{Package: path, Errors: entries}, "", "\t")
+	if err != nil {
+		return kerr.Wrap("YCWVMXVJIT", err)
+	}
+	if _, err := fmt.Fprintln(w, string(b)); err != nil {
+		return kerr.Wrap("QYUOMFEXRB", err)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func writeJunitReport(w io.Writer, path string, entries []ReportEntry) error {
+	suite := junitTestSuite{Name: path}
+	for _, e := range entries {
+		suite.Cases = append(suite.Cases, junitTestCase{
+			Name:      e.Path,
+			Classname: e.File,
+			Failure: &junitFailure{
+				Message: e.Message,
+				Type:    e.Rule,
+				Body:    fmt.Sprintf("%s: %s: %s (%s)", e.Position(), e.Path, e.Message, e.Code),
+			},
+		})
+	}
+	suite.Failures = len(suite.Cases)
+	if len(suite.Cases) == 0 {
+		// An empty suite is reported as a failure by some tools, so we add a single passing
+		// test case.
+		suite.Cases = append(suite.Cases, junitTestCase{Name: "validate", Classname: path})
+	}
+	suite.Tests = len(suite.Cases)
+	suites := junitTestSuites{Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}
+	b, err := xml.MarshalIndent(suites, "", "\t")
+	if err != nil {
+		return kerr.Wrap("GUMWBVMJHA", err)
+	}
+	if _, err := fmt.Fprintf(w, "%s%s\n", xml.Header, b); err != nil {
+		return kerr.Wrap("RXAFTXSSMC", err)
+	}
+	return nil
+}
+
+// The sarif types only include the parts of the SARIF 2.1.0 schema that we use.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	Id string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleId     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+func writeSarifReport(w io.Writer, entries []ReportEntry) error {
+	driver := sarifDriver{Name: "ke", InformationUri: "https://kego.io", Rules: []sarifRule{}}
+	rules := map[string]bool{}
+	results := []sarifResult{}
+	for _, e := range entries {
+		id := e.Rule
+		if id == "" {
+			id = e.Code
+		}
+		if !rules[id] {
+			rules[id] = true
+			driver.Rules = append(driver.Rules, sarifRule{Id: id})
+		}
+		physical := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{Uri: e.File}}
+		if e.Line > 0 {
+			physical.Region = &sarifRegion{StartLine: e.Line, StartColumn: e.Column}
+		}
+		results = append(results, sarifResult{
+			RuleId:  id,
+			Level:   "error",
+			Message: sarifMessage{Text: e.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: physical,
+				LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: e.Path}},
+			}},
+			Properties: map[string]string{"code": e.Code},
+		})
+	}
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+	b, err := json.MarshalPlainIndent(log, "", "\t")
+	if err != nil {
+		return kerr.Wrap("HWHHXAPJMP", err)
+	}
+	if _, err := fmt.Fprintln(w, string(b)); err != nil {
+		return kerr.Wrap("HTXFNPJHDA", err)
+	}
+	return nil
+}
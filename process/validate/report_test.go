@@ -0,0 +1,102 @@
+package validate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/json"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+func testEntries() []ReportEntry {
+	return []ReportEntry{
+		{File: "b.yaml", Path: "b/c", Code: "HLKQWDCMRN", Rule: "kego.io/system:@string", Message: "MaxLength: length of \"foo\" must not be greater than 1"},
+		{File: "a.yaml", Line: 3, Column: 5, Path: "a", Code: "KULDIJUYFB", Rule: "kego.io/system:@int", Message: "Maximum 1 must not be less than Minimum 2"},
+	}
+}
+
+func TestNewReportEntry(t *testing.T) {
+	n := node.NewNode()
+	n.Key = "c"
+	n.Parent = node.NewNode()
+	n.Position = json.Position{File: "/a/e.yaml", Line: 2, Column: 4}
+	e := ValidationError{
+		Struct: kerr.New("OYJQDNFWMC", "d"),
+		Source: n,
+		File:   "e.yaml",
+		Rule:   system.NewReference("kego.io/system", "@string"),
+	}
+	r := NewReportEntry(e)
+	assert.Equal(t, ReportEntry{File: "e.yaml", Line: 2, Column: 4, Path: "root/c", Code: "OYJQDNFWMC", Rule: "kego.io/system:@string", Message: "d"}, r)
+
+	entries := NewReportEntries(nil)
+	assert.Equal(t, 0, len(entries))
+	assert.NotNil(t, entries)
+}
+
+func TestWriteReportText(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := WriteReport(b, REPORT_TEXT, "a.b/c", testEntries())
+	require.NoError(t, err)
+	assert.Equal(t, "a.yaml:3:5: a: Maximum 1 must not be less than Minimum 2\nb.yaml: b/c: MaxLength: length of \"foo\" must not be greater than 1\n", b.String())
+}
+
+func TestWriteReportJson(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := WriteReport(b, REPORT_JSON, "a.b/c", testEntries())
+	require.NoError(t, err)
+	var r jsonReport
+	require.NoError(t, json.UnmarshalPlain(b.Bytes(), &r))
+	assert.Equal(t, "a.b/c", r.Package)
+	require.Equal(t, 2, len(r.Errors))
+	assert.Equal(t, "a.yaml", r.Errors[0].File)
+	assert.Equal(t, "b/c", r.Errors[1].Path)
+	assert.Equal(t, "HLKQWDCMRN", r.Errors[1].Code)
+	assert.Equal(t, "kego.io/system:@string", r.Errors[1].Rule)
+}
+
+func TestWriteReportJunit(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := WriteReport(b, REPORT_JUNIT, "a.b/c", testEntries())
+	require.NoError(t, err)
+	assert.Contains(t, b.String(), `<testsuites tests="2" failures="2">`)
+	assert.Contains(t, b.String(), `<testsuite name="a.b/c" tests="2" failures="2">`)
+	assert.Contains(t, b.String(), `<testcase name="b/c" classname="b.yaml">`)
+	assert.Contains(t, b.String(), `<failure message="Maximum 1 must not be less than Minimum 2" type="kego.io/system:@int">a.yaml:3:5: a: Maximum 1 must not be less than Minimum 2 (KULDIJUYFB)</failure>`)
+
+	b.Reset()
+	err = WriteReport(b, REPORT_JUNIT, "a.b/c", nil)
+	require.NoError(t, err)
+	assert.Contains(t, b.String(), `<testsuite name="a.b/c" tests="1" failures="0">`)
+	assert.Contains(t, b.String(), `<testcase name="validate" classname="a.b/c"></testcase>`)
+}
+
+func TestWriteReportSarif(t *testing.T) {
+	b := &bytes.Buffer{}
+	err := WriteReport(b, REPORT_SARIF, "a.b/c", testEntries())
+	require.NoError(t, err)
+	var l sarifLog
+	require.NoError(t, json.UnmarshalPlain(b.Bytes(), &l))
+	assert.Equal(t, "2.1.0", l.Version)
+	require.Equal(t, 1, len(l.Runs))
+	assert.Equal(t, "ke", l.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, []sarifRule{{Id: "kego.io/system:@int"}, {Id: "kego.io/system:@string"}}, l.Runs[0].Tool.Driver.Rules)
+	require.Equal(t, 2, len(l.Runs[0].Results))
+	r := l.Runs[0].Results[1]
+	assert.Equal(t, "kego.io/system:@string", r.RuleId)
+	assert.Equal(t, "error", r.Level)
+	assert.Equal(t, "b.yaml", r.Locations[0].PhysicalLocation.ArtifactLocation.Uri)
+	assert.Nil(t, r.Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "b/c", r.Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal(t, "HLKQWDCMRN", r.Properties["code"])
+	assert.Equal(t, &sarifRegion{StartLine: 3, StartColumn: 5}, l.Runs[0].Results[0].Locations[0].PhysicalLocation.Region)
+}
+
+func TestWriteReportUnknown(t *testing.T) {
+	err := WriteReport(&bytes.Buffer{}, "foo", "a.b/c", nil)
+	assert.IsError(t, err, "DLUGXVOHFE")
+}
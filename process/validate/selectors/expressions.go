@@ -216,6 +216,13 @@ func (p *Parser) parseExpression(tokens []*token, current *node.Node) exprElemen
 		} else if thisToken.typ == S_PVAR {
 			// This is for the value of the node being processed.
 			finalTokens = append(finalTokens, &exprElement{current.Value, current.JsonType})
+		} else if thisToken.typ == S_FIELD {
+			// This is for the value of a field of the node being processed.
+			if field := getField(current, thisToken.val.([]string)); field != nil {
+				finalTokens = append(finalTokens, &exprElement{field.Value, field.JsonType})
+			} else {
+				finalTokens = append(finalTokens, &exprElement{nil, json.J_NULL})
+			}
 		} else if thisToken.typ == S_STRING || thisToken.typ == S_BOOL || thisToken.typ == S_NIL || thisToken.typ == S_NUMBER {
 			// Let's copy these kinds of tokens down as expression elements.
 			var jType json.Type
@@ -38,6 +38,7 @@ const (
 	S_NIL               tokenType = "null"
 	S_KEYWORD           tokenType = "keyword"
 	S_PVAR              tokenType = "pvar"
+	S_FIELD             tokenType = "field"
 	S_EXPR              tokenType = "expr"
 	S_NUMBER            tokenType = "number"
 	S_STRING            tokenType = "string"
@@ -148,6 +149,11 @@ var expressionScanner = []scannerItem{
 		regexp.MustCompile(`^\"([^\]|\[^\"])*\"`),
 		S_STRING,
 	},
+	scannerItem{
+		// x.a.b is the value of a field of the node being processed.
+		regexp.MustCompile(`^x(\.[_a-zA-Z][_a-zA-Z0-9\-]*)+`),
+		S_FIELD,
+	},
 	scannerItem{
 		regexp.MustCompile(`^x`),
 		S_PVAR,
@@ -252,6 +258,8 @@ func getToken(typ tokenType, val string) token {
 		return token{typ, val[1 : len(val)-1]}
 	case S_STRING:
 		return token{typ, val[1 : len(val)-1]}
+	case S_FIELD:
+		return token{typ, strings.Split(val[2:], ".")}
 	case S_OPER:
 		// If the operator is padded with whitespace, we match the whole string so we must
 		// trim leading and trailing whitespace.
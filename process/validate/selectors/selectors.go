@@ -286,7 +286,11 @@ func (p *Parser) kegoProduction(value interface{}) func(*node.Node) (bool, error
 
 		// If the specified type is an interface, we should check to see if the
 		// node implements the interface.
-		if i, ok := r.GetType(p.ctx); ok && i.Interface {
+		i, ok, err := r.GetType(p.ctx)
+		if err != nil {
+			return false, kerr.Wrap("KTCEWLHXAN", err)
+		}
+		if ok && i.Interface {
 			rt, ok := i.Id.GetReflectType(p.ctx)
 			if ok {
 				if n.Type.Implements(p.ctx, rt) {
@@ -1,15 +1,13 @@
-// info:{"Path":"kego.io/process/validate/selectors/tests","Hash":10362807576256830877}
+// info:{"Path":"kego.io/process/validate/selectors/tests","Hash":2488143855908109460}
 package tests
 
 // ke: {"file": {"notest": true}}
 
 import (
-	"reflect"
-
 	"context"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for basic
@@ -496,7 +494,7 @@ func (o *Typed) GetTyped(ctx context.Context) *Typed {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/process/validate/selectors/tests", 10362807576256830877)
+	pkg := jsonctx.InitPackage("kego.io/process/validate/selectors/tests", 2488143855908109460)
 	pkg.InitType("basic", reflect.TypeOf((*Basic)(nil)), reflect.TypeOf((*BasicRule)(nil)), reflect.TypeOf((*BasicInterface)(nil)).Elem())
 	pkg.InitType("c", reflect.TypeOf((*C)(nil)), reflect.TypeOf((*CRule)(nil)), reflect.TypeOf((*CInterface)(nil)).Elem())
 	pkg.InitType("collision", reflect.TypeOf((*Collision)(nil)), reflect.TypeOf((*CollisionRule)(nil)), reflect.TypeOf((*CollisionInterface)(nil)).Elem())
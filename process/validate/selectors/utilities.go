@@ -138,6 +138,22 @@ func getFloat64(in interface{}) float64 {
 
 }
 
+// getField returns the descendant of the node found by following the path of field names, or nil
+// if it doesn't exist.
+func getField(n *node.Node, path []string) *node.Node {
+	for _, name := range path {
+		if n.JsonType != json.J_OBJECT && n.JsonType != json.J_MAP {
+			return nil
+		}
+		child, ok := n.Map[name]
+		if !ok || child.Null || child.Missing {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
 func getInt32(in interface{}) int32 {
 	value := int32(getFloat64(in))
 	if value == -1 {
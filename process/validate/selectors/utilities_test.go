@@ -62,6 +62,15 @@ func TestIsNull(t *testing.T) {
 	assert.False(t, isNull(exprElement{typ: json.J_MAP, value: 1}))
 }
 
+func TestGetField(t *testing.T) {
+	a := &node.Node{JsonType: json.J_STRING}
+	n := &node.Node{JsonType: json.J_MAP, Map: map[string]*node.Node{"a": a, "b": {Missing: true}}}
+	assert.Equal(t, a, getField(n, []string{"a"}))
+	assert.Nil(t, getField(n, []string{"b"}))
+	assert.Nil(t, getField(n, []string{"c"}))
+	assert.Nil(t, getField(n, []string{"a", "b"}))
+}
+
 func TestGetBool(t *testing.T) {
 	assert.False(t, getBool(nil))
 	assert.False(t, getBool(&node.Node{ValueBool: false}))
@@ -1,14 +1,14 @@
-// info:{"Path":"kego.io/process/validate/tests","Hash":17573435268142783549}
+// info:{"Path":"kego.io/process/validate/tests","Hash":3589977300434078030}
 package tests
 
 // ke: {"file": {"notest": true}}
 
 import (
 	"context"
-	"reflect"
-
 	"kego.io/context/jsonctx"
+	"kego.io/json"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for a
@@ -46,6 +46,18 @@ type FRule struct {
 	*system.Rule
 }
 
+// Automatically created basic rule for g
+type GRule struct {
+	*system.Object
+	*system.Rule
+}
+
+// Automatically created basic rule for h
+type HRule struct {
+	*system.Object
+	*system.Rule
+}
+
 // A is a simple type containing a string B
 type A struct {
 	*system.Object
@@ -114,12 +126,63 @@ type FInterface interface {
 func (o *F) GetF(ctx context.Context) *F {
 	return o
 }
+
+// G is an enum type
+type G string
+
+const (
+	// The colour light blue
+	GLightBlue G = "light-blue"
+	// The colour red
+	GRed G = "red"
+)
+
+func (v G) Values() []G {
+	return []G{GLightBlue, GRed}
+}
+func (v *G) Unpack(ctx context.Context, in json.Packed) error {
+	s, err := system.UnpackEnum(in, "g", "light-blue", "red")
+	if err != nil {
+		return err
+	}
+	*v = G(s)
+	return nil
+}
+func (v *G) MarshalJSON(ctx context.Context) ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	return system.MarshalEnum(string(*v), "g", "light-blue", "red")
+}
+
+type GInterface interface {
+	GetG(ctx context.Context) *G
+}
+
+func (o *G) GetG(ctx context.Context) *G {
+	return o
+}
+
+// H is a type containing a field of the enum type G
+type H struct {
+	*system.Object
+	A *G `json:"a"`
+}
+type HInterface interface {
+	GetH(ctx context.Context) *H
+}
+
+func (o *H) GetH(ctx context.Context) *H {
+	return o
+}
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/process/validate/tests", 17573435268142783549)
+	pkg := jsonctx.InitPackage("kego.io/process/validate/tests", 3589977300434078030)
 	pkg.InitType("a", reflect.TypeOf((*A)(nil)), reflect.TypeOf((*ARule)(nil)), reflect.TypeOf((*AInterface)(nil)).Elem())
 	pkg.InitType("b", reflect.TypeOf((*B)(nil)), reflect.TypeOf((*BRule)(nil)), reflect.TypeOf((*BInterface)(nil)).Elem())
 	pkg.InitType("c", reflect.TypeOf((*C)(nil)).Elem(), reflect.TypeOf((*CRule)(nil)), nil)
 	pkg.InitType("d", reflect.TypeOf((*D)(nil)), reflect.TypeOf((*DRule)(nil)), reflect.TypeOf((*DInterface)(nil)).Elem())
 	pkg.InitType("e", reflect.TypeOf((*E)(nil)), reflect.TypeOf((*ERule)(nil)), reflect.TypeOf((*EInterface)(nil)).Elem())
 	pkg.InitType("f", reflect.TypeOf((*F)(nil)), reflect.TypeOf((*FRule)(nil)), reflect.TypeOf((*FInterface)(nil)).Elem())
+	pkg.InitType("g", reflect.TypeOf((*G)(nil)), reflect.TypeOf((*GRule)(nil)), reflect.TypeOf((*GInterface)(nil)).Elem())
+	pkg.InitType("h", reflect.TypeOf((*H)(nil)), reflect.TypeOf((*HRule)(nil)), reflect.TypeOf((*HInterface)(nil)).Elem())
 }
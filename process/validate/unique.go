@@ -0,0 +1,195 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/sorter"
+	"kego.io/json"
+	"kego.io/process/format"
+	"kego.io/process/validate/selectors"
+	"kego.io/system"
+	"kego.io/system/node"
+)
+
+// uniqueIndex collects the values matched by the unique rules in a package, so values that
+// collide in different globals are found.
+type uniqueIndex struct {
+	// rules are the unique rules in the package object, which apply to every global.
+	rules   []*system.UniqueRule
+	globals []*uniqueGlobal
+	values  []*uniqueValue
+	seen    map[uniqueSeen]bool
+}
+
+type uniqueGlobal struct {
+	node *node.Node
+	file string
+}
+
+type uniqueValue struct {
+	node  *node.Node
+	file  string
+	rule  *system.UniqueRule
+	key   uniqueKey
+	value string
+}
+
+// uniqueKey identifies a set of values that must be unique. Values matched by rules with the same
+// selector and scope are in the same set, so a rule in two types can be unique across both.
+type uniqueKey struct {
+	selector string
+	scope    string
+	// group is the type of the global for the type scope, and the collection node for the
+	// parent scope.
+	group interface{}
+}
+
+type uniqueSeen struct {
+	key  uniqueKey
+	node *node.Node
+}
+
+func newUniqueIndex() *uniqueIndex {
+	return &uniqueIndex{seen: map[uniqueSeen]bool{}}
+}
+
+// add adds the values in a data file that are matched by the unique rules of its types. The
+// rules in the package object are kept, and applied to the globals in check.
+func (u *uniqueIndex) add(ctx context.Context, n *node.Node, file string) error {
+	switch v := n.Value.(type) {
+	case *system.Package:
+		for _, r := range v.Rules {
+			if rule, ok := r.(*system.UniqueRule); ok {
+				u.rules = append(u.rules, rule)
+			}
+		}
+		return nil
+	case *system.Type:
+		// Types aren't globals, and the rules in a type apply to its instances.
+		return nil
+	}
+	u.globals = append(u.globals, &uniqueGlobal{node: n, file: file})
+
+	cache := map[*node.Node][]system.RuleInterface{}
+	if err := BuildRulesNode(ctx, n, cache); err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("JITDPSCRWT", err)
+	}
+	for match, rules := range cache {
+		for _, r := range rules {
+			if rule, ok := r.(*system.UniqueRule); ok {
+				if err := u.match(ctx, rule, match, file); err != nil {
+					return kerr.Wrap("MXXDQCUYCM", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (u *uniqueIndex) match(ctx context.Context, rule *system.UniqueRule, n *node.Node, file string) error {
+	if n.Null || n.Missing {
+		return nil
+	}
+	key := uniqueKey{selector: rule.Selector, scope: rule.GetScope()}
+	switch key.scope {
+	case system.UniquePackage:
+	case system.UniqueType:
+		if root := n.Root(); root.Type != nil {
+			key.group = root.Type.Id.Value()
+		}
+	case system.UniqueParent:
+		key.group = n.Root()
+		for p := n.Parent; p != nil; p = p.Parent {
+			if p.JsonType == json.J_ARRAY || p.JsonType == json.J_MAP {
+				key.group = p
+				break
+			}
+		}
+	default:
+		// An unknown scope is reported when the rule is validated.
+		return nil
+	}
+	if u.seen[uniqueSeen{key: key, node: n}] {
+		// The node is matched by more than one rule with the same selector and scope.
+		return nil
+	}
+	u.seen[uniqueSeen{key: key, node: n}] = true
+	// The value is compared in its canonical form, built from the node tree, so it works for
+	// types that have no generated Go type.
+	v, err := format.Value(ctx, n)
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("VKNUXETWJC", err)
+	}
+	b, err := format.MarshalJson(v, "")
+	if err != nil {
+		// ke: {"block": {"notest": true}}
+		return kerr.Wrap("PKDRBXNMQW", err)
+	}
+	u.values = append(u.values, &uniqueValue{node: n, file: file, rule: rule, key: key, value: string(b)})
+	return nil
+}
+
+// check applies the rules in the package object to the globals, and returns a validation error
+// for every pair of nodes with the same value in the same set.
+func (u *uniqueIndex) check(ctx context.Context) (errors []ValidationError, err error) {
+	for _, rule := range u.rules {
+		selector := ":root"
+		if rule.Selector != "" {
+			selector = rule.Selector
+		}
+		for _, g := range u.globals {
+			p, err := selectors.CreateParser(ctx, g.node)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("HGEFGPDQRH", err)
+			}
+			matches, err := p.GetNodes(selector)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("DBNCFHGQYF", err)
+			}
+			for _, match := range matches {
+				if err := u.match(ctx, rule, match, g.file); err != nil {
+					return nil, kerr.Wrap("IWPNZMACLE", err)
+				}
+			}
+		}
+	}
+
+	// The values are sorted by position, so the first of each pair is the earlier node.
+	v := u.values
+	sort.Stable(sorter.New(
+		len(v),
+		func(i, j int) { v[i], v[j] = v[j], v[i] },
+		func(i, j int) bool {
+			if v[i].file != v[j].file {
+				return v[i].file < v[j].file
+			}
+			if v[i].node.Position.Line != v[j].node.Position.Line {
+				return v[i].node.Position.Line < v[j].node.Position.Line
+			}
+			if v[i].node.Position.Column != v[j].node.Position.Column {
+				return v[i].node.Position.Column < v[j].node.Position.Column
+			}
+			return v[i].node.Path() < v[j].node.Path()
+		},
+	))
+
+	found := map[uniqueKey]map[string][]*uniqueValue{}
+	for _, value := range v {
+		if found[value.key] == nil {
+			found[value.key] = map[string][]*uniqueValue{}
+		}
+		for _, other := range found[value.key][value.value] {
+			message := fmt.Sprintf("Value %s is not unique in the %s: %s %s has the same value", value.value, value.key.scope, other.file, other.node.Path())
+			errors = append(errors, ValidationError{Struct: kerr.New("LCGLAUXFPO", message), Source: value.node, File: value.file, Rule: ruleType(value.rule)})
+		}
+		found[value.key][value.value] = append(found[value.key][value.value], value)
+	}
+	return errors, nil
+}
@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/tests"
+)
+
+func TestUnique(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yml": `
+			type: system:package
+			rules:
+				-   type: system:@unique
+					selector: ".email"
+		`,
+		"page.yml": `
+			type: system:type
+			id: page
+			fields:
+				slug:
+					type: system:@string
+				labels:
+					type: system:@array
+					items:
+						type: system:@string
+					optional: true
+			rules:
+				-   type: system:@unique
+					selector: ".slug"
+					scope: type
+				-   type: system:@unique
+					selector: ".labels>*"
+					scope: parent
+		`,
+		"person.yml": `
+			type: system:type
+			id: person
+			fields:
+				email:
+					type: system:@string
+				slug:
+					type: system:@string
+					optional: true
+		`,
+		"b.yml": `
+			type: page
+			id: b
+			slug: x
+			labels: [c, d, c]
+		`,
+		"c.yml": `
+			type: page
+			id: c
+			slug: x
+			labels: [c]
+		`,
+		"d.yml": `
+			type: person
+			id: d
+			email: e
+			slug: x
+		`,
+		"e.yml": `
+			type: person
+			id: e
+			email: e
+		`,
+		"f.yml": `
+			type: person
+			id: f
+			email: f
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Jauto().Sauto(parser.Parse)
+
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 3, len(errors))
+	for _, e := range errors {
+		assert.IsError(t, e, "LCGLAUXFPO")
+		assert.Equal(t, "kego.io/system:@unique", e.Rule.Value())
+	}
+	assert.Equal(t, "b.yml", errors[0].File)
+	assert.Equal(t, "b/labels/2", errors[0].Source.Path())
+	assert.Equal(t, `Value "c" is not unique in the parent: b.yml b/labels/0 has the same value`, errors[0].Description)
+	assert.Equal(t, "c.yml", errors[1].File)
+	assert.Equal(t, `Value "x" is not unique in the type: b.yml b/slug has the same value`, errors[1].Description)
+	assert.Equal(t, "e.yml", errors[2].File)
+	assert.Equal(t, `Value "e" is not unique in the package: d.yml d/email has the same value`, errors[2].Description)
+
+	cb.TempFile("a.yml", "type: system:package\nrules: [{type: system:@unique, selector: \".email\", scope: foo}]\n")
+	errors, err = ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 3, len(errors))
+	assert.IsError(t, errors[0], "KULDIJUYFB")
+	assert.Equal(t, "a.yml", errors[0].File)
+	assert.Equal(t, "Scope: foo should be package, type or parent", errors[0].Description)
+}
+
+func TestUniqueDynamic(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	// The types have no generated Go types, so the objects are dynamic.
+	path, dir := cb.TempPackage("a", map[string]string{
+		"address.yml": `
+			type: system:type
+			id: address
+			fields:
+				street:
+					type: system:@string
+				labels:
+					type: system:@map
+					items:
+						type: system:@string
+					optional: true
+		`,
+		"person.yml": `
+			type: system:type
+			id: person
+			fields:
+				address:
+					type: "@address"
+			rules:
+				-   type: system:@unique
+					selector: ".address"
+		`,
+		"b.yml": `
+			type: person
+			id: b
+			address: {type: address, street: x, labels: {c: d, e: f}}
+		`,
+		"c.yml": `
+			type: person
+			id: c
+			address: {labels: {e: f, c: d}, street: x, type: address}
+		`,
+		"d.yml": `
+			type: person
+			id: d
+			address: {type: address, street: z}
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Jauto().Sauto(parser.Parse)
+
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(errors))
+	assert.IsError(t, errors[0], "LCGLAUXFPO")
+	assert.Equal(t, "c.yml", errors[0].File)
+	assert.Equal(t, `Value {"type":"address","labels":{"c":"d","e":"f"},"street":"x"} is not unique in the package: b.yml b/address has the same value`, errors[0].Description)
+}
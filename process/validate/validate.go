@@ -4,9 +4,14 @@ package validate // import "kego.io/process/validate"
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/davelondon/kerr"
 	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
 	"kego.io/json"
 	"kego.io/process/scanner"
 	"kego.io/process/validate/selectors"
@@ -15,37 +20,186 @@ import (
 )
 
 func ValidatePackage(ctx context.Context) (errors []ValidationError, err error) {
+	return NewValidator().Validate(ctx, nil)
+}
+
+// Validator validates the data files in a package. The result of each file is kept, so when the
+// package is validated again only the files that have changed are validated. The unique rules
+// compare values in different files, so they are checked every time.
+type Validator struct {
+	files map[string]*validatedFile
+}
+
+type validatedFile struct {
+	// rel is the file relative to the package dir.
+	rel    string
+	nodes  []*node.Node
+	errors []ValidationError
+}
+
+func NewValidator() *Validator {
+	return &Validator{files: map[string]*validatedFile{}}
+}
+
+// Validate validates the data files in the package. If changed is nil every file is validated,
+// otherwise only the files in changed and the files that haven't been validated before. The
+// keys of changed are the full paths of the files.
+func (v *Validator) Validate(ctx context.Context, changed map[string]bool) (errors []ValidationError, err error) {
 
 	env := envctx.FromContext(ctx)
 
-	files := scanner.ScanDirToFiles(ctx, env.Dir, env.Recursive)
-	bytes := scanner.ScanFilesToBytes(ctx, files)
-	for c := range bytes {
+	// The errors are in the order the files are scanned, however many of them are validated.
+	order := []string{}
+	stale := []string{}
+	for f := range scanner.ScanPackageToFiles(ctx, env) {
+		if f.Err != nil {
+			return nil, kerr.Wrap("MVKDHRQWXE", f.Err)
+		}
+		order = append(order, f.File)
+		if _, ok := v.files[f.File]; !ok || changed == nil || changed[f.File] {
+			stale = append(stale, f.File)
+		}
+	}
+
+	files := map[string]*validatedFile{}
+	for _, f := range order {
+		if vf, ok := v.files[f]; ok {
+			files[f] = vf
+		}
+	}
+	for _, f := range stale {
+		delete(files, f)
+	}
+
+	in := make(chan scanner.File)
+	go func() {
+		defer close(in)
+		for _, f := range stale {
+			select {
+			case in <- scanner.File{File: f}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for c := range scanner.ScanFilesToBytes(ctx, in) {
 		if c.Err != nil {
 			return nil, kerr.Wrap("IHSVWAUAYW", c.Err)
 		}
-		ve, err := validateBytes(ctx, c.Bytes)
+		vf, ok := files[c.File]
+		if !ok {
+			rel, err := filepath.Rel(env.Dir, c.File)
+			if err != nil {
+				// ke: {"block": {"notest": true}}
+				return nil, kerr.Wrap("SDWAYTDJNF", err)
+			}
+			vf = &validatedFile{rel: rel}
+			files[c.File] = vf
+		}
+		n, ve, err := validateBytes(ctx, c.Bytes, c.Positions)
 		if err != nil {
 			return nil, kerr.Wrap("KWLWXKWHLF", err)
 		}
-		if len(ve) > 0 {
-			errors = append(errors, ve...)
+		for i := range ve {
+			ve[i].File = vf.rel
+		}
+		vf.nodes = append(vf.nodes, n)
+		vf.errors = append(vf.errors, ve...)
+	}
+	v.files = files
+
+	unique := newUniqueIndex()
+	for _, f := range order {
+		vf, ok := files[f]
+		if !ok {
+			// not a data file
+			continue
+		}
+		errors = append(errors, vf.errors...)
+		for _, n := range vf.nodes {
+			if err := unique.add(ctx, n, vf.rel); err != nil {
+				return nil, kerr.Wrap("TBQZRWEOXN", err)
+			}
 		}
 	}
 
+	ve, err := unique.check(ctx)
+	if err != nil {
+		return nil, kerr.Wrap("PSJUKMFWAY", err)
+	}
+	errors = append(errors, ve...)
+
 	return
 }
 
-func validateBytes(ctx context.Context, bytes []byte) (errors []ValidationError, err error) {
-	n, err := node.Unmarshal(ctx, bytes)
+func validateBytes(ctx context.Context, bytes []byte, positions *json.Positions) (n *node.Node, errors []ValidationError, err error) {
+	n, err = node.UnmarshalPositions(ctx, bytes, positions)
 	if err != nil {
-		return nil, kerr.Wrap("QIVNOQKCQF", err)
+		return nil, nil, kerr.Wrap("QIVNOQKCQF", err)
 	}
-	errors, err = ValidateNode(ctx, n)
+	cache := map[*node.Node][]system.RuleInterface{}
+	errors, err = validateNode(ctx, n, cache)
 	if err != nil {
-		return nil, kerr.Wrap("RVKNMWKQHD", err)
+		return nil, nil, kerr.Wrap("RVKNMWKQHD", err)
 	}
-	return errors, nil
+	return n, append(errors, validateReferences(ctx, cache)...), nil
+}
+
+// validateReferences checks that the references with a global or types restriction refer to an
+// existing global, of one of the types if types is set. References without these restrictions
+// aren't checked, because they may refer to a type rather than a global. The globals are found
+// in the sys cache, which holds the package and its aliases, so this is only checked when the
+// package is validated. The cache holds the rules of the nodes, as built by validateNode.
+func validateReferences(ctx context.Context, cache map[*node.Node][]system.RuleInterface) (errors []ValidationError) {
+	for current, rules := range cache {
+		for _, rule := range rules {
+			r, ok := rule.(*system.ReferenceRule)
+			if !ok || (len(r.Types) == 0 && (r.Global == nil || !r.Global.Value())) {
+				continue
+			}
+			if message := checkReference(ctx, current, r); message != "" {
+				errors = append(errors, ValidationError{Struct: kerr.New("BMVBBZOHZZ", message), Source: current, Rule: ruleType(rule)})
+			}
+		}
+	}
+	return errors
+}
+
+// checkReference returns a message if the reference in the node doesn't refer to a global, or
+// if the rule has types, a global of one of the types.
+func checkReference(ctx context.Context, n *node.Node, rule *system.ReferenceRule) string {
+	if n.Null || n.Missing {
+		return ""
+	}
+	i, ok := n.Value.(system.ReferenceInterface)
+	if !ok || i == nil {
+		return ""
+	}
+	r := i.GetReference(ctx)
+	if r == nil || r.Name == "" {
+		return ""
+	}
+	var global *sysctx.SysGlobalInfo
+	if pi, ok := sysctx.FromContext(ctx).Get(r.Package); ok {
+		global, _ = pi.Globals.Get(r.Name)
+	}
+	if global == nil {
+		return fmt.Sprintf("Reference %s doesn't refer to a global", r.Value())
+	}
+	if len(rule.Types) == 0 {
+		return ""
+	}
+	types := []string{}
+	for _, t := range rule.Types {
+		if t == nil {
+			continue
+		}
+		if t.Value() == global.Type {
+			return ""
+		}
+		types = append(types, t.Value())
+	}
+	return fmt.Sprintf("Reference %s refers to a %s, but should refer to a %s", r.Value(), global.Type, strings.Join(types, " or "))
 }
 
 func BuildRulesNode(ctx context.Context, n *node.Node, cache map[*node.Node][]system.RuleInterface) error {
@@ -166,9 +320,32 @@ func buildRulesCollectionChildren(ctx context.Context, n *node.Node, cache map[*
 }
 
 func ValidateNode(ctx context.Context, n *node.Node) (errors []ValidationError, err error) {
+	return validateNode(ctx, n, map[*node.Node][]system.RuleInterface{})
+}
+
+// validateNode validates the node and its descendants, and adds the rules of each node to cache,
+// so they can be used again without being built.
+func validateNode(ctx context.Context, n *node.Node, cache map[*node.Node][]system.RuleInterface) (errors []ValidationError, err error) {
 
 	// First validate all the nodes
 	for _, current := range n.Flatten(true) {
+		// Values of enum types with generated Go types are checked when they're unpacked, but
+		// types without generated Go types are checked here.
+		if current.Type != nil && current.Type.IsEnum() && current.JsonType == json.J_STRING && !current.Type.IsMember(current.ValueString) {
+			message := fmt.Sprintf("%s is not a member of %s", strconv.Quote(current.ValueString), current.Type.Id.Value())
+			errors = append(errors, ValidationError{Struct: kerr.New("SFZDYHPSLI", message), Source: current, Rule: current.Type.Id})
+		}
+		// The one-of list in the rule restricts the types of interface and object fields. Fields
+		// of generated Go types are also checked when they're unpacked. The list of a collection
+		// rule restricts the items, which get it from ItemsRule, so collections are skipped.
+		if current.Rule != nil && !current.Rule.IsCollection() && current.Type != nil && !current.Null && !current.Missing && !current.Rule.Struct.PermitsType(*current.Type.Id) {
+			var oneOf []string
+			for _, ref := range current.Rule.Struct.OneOf {
+				oneOf = append(oneOf, ref.Value())
+			}
+			message := fmt.Sprintf("Type %s is not one of %s", current.Type.Id.Value(), strings.Join(oneOf, ", "))
+			errors = append(errors, ValidationError{Struct: kerr.New("XQVJYFNRAS", message), Source: current, Rule: ruleType(current.Rule.Interface)})
+		}
 		// Validate the actual object
 		if v, ok := current.Value.(system.Validator); ok {
 			failed, messages, err := v.Validate(ctx)
@@ -177,14 +354,13 @@ func ValidateNode(ctx context.Context, n *node.Node) (errors []ValidationError,
 			}
 			if failed {
 				for _, message := range messages {
-					errors = append(errors, ValidationError{Struct: kerr.New("KULDIJUYFB", message), Source: current})
+					errors = append(errors, ValidationError{Struct: kerr.New("KULDIJUYFB", message), Source: current, Rule: current.Type.Id})
 				}
 			}
 		}
 	}
 
 	// Then build a list of all nodes that have rules
-	cache := map[*node.Node][]system.RuleInterface{}
 	if err := BuildRulesNode(ctx, n, cache); err != nil {
 		return nil, kerr.Wrap("YPUHTXPGRA", err)
 	}
@@ -192,6 +368,14 @@ func ValidateNode(ctx context.Context, n *node.Node) (errors []ValidationError,
 	// Then enforce the rules
 	for current, rules := range cache {
 		for _, rule := range rules {
+			if c, ok := rule.(*system.ConditionRule); ok {
+				e, err := checkCondition(ctx, current, c)
+				if err != nil {
+					return nil, kerr.Wrap("VNHSJDOLRD", err)
+				}
+				errors = append(errors, e...)
+				continue
+			}
 			e, ok := rule.(system.Enforcer)
 			if !ok {
 				continue
@@ -202,7 +386,7 @@ func ValidateNode(ctx context.Context, n *node.Node) (errors []ValidationError,
 			}
 			if failed {
 				for _, message := range messages {
-					errors = append(errors, ValidationError{Struct: kerr.New("HLKQWDCMRN", message), Source: current})
+					errors = append(errors, ValidationError{Struct: kerr.New("HLKQWDCMRN", message), Source: current, Rule: ruleType(rule)})
 				}
 			}
 		}
@@ -212,9 +396,35 @@ func ValidateNode(ctx context.Context, n *node.Node) (errors []ValidationError,
 
 type ValidationError struct {
 	kerr.Struct
+	// Source is the node that failed validation.
 	Source *node.Node
+	// File is the data file containing the node, relative to the package directory.
+	File string
+	// Rule is the type of the rule that failed. If the value failed its own Validate method,
+	// this is the type of the value.
+	Rule *system.Reference
+}
+
+// Position returns the location of the node that failed validation. The file is relative to the
+// package directory.
+func (e ValidationError) Position() json.Position {
+	p := json.Position{File: e.File}
+	if e.Source != nil {
+		p.Line = e.Source.Position.Line
+		p.Column = e.Source.Position.Column
+	}
+	return p
 }
 
+// ValidationCommandError is returned when the validate command finds validation errors.
 type ValidationCommandError struct {
 	kerr.Struct
+	Entries []ReportEntry
+}
+
+func ruleType(rule system.RuleInterface) *system.Reference {
+	if ob, ok := rule.(system.ObjectInterface); ok && ob.GetObject(nil) != nil {
+		return ob.GetObject(nil).Type
+	}
+	return nil
 }
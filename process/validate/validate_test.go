@@ -1,8 +1,12 @@
 package validate
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"kego.io/json"
 	"kego.io/process/parser"
 	"kego.io/system/node"
 
@@ -92,6 +96,9 @@ func TestRuleHasExtraRules(t *testing.T) {
 	require.NoError(t, err)
 	assert.IsError(t, errors[0], "HLKQWDCMRN")
 	assert.Equal(t, "MinLength: length of \"foo\" must not be less than 7", errors[0].Description)
+	assert.Equal(t, "b.yml", errors[0].File)
+	assert.Equal(t, "kego.io/system:@string", errors[0].Rule.Value())
+	assert.Equal(t, "b.yml:4:16", errors[0].Position().String())
 }
 
 func TestFieldExtraRulesObject(t *testing.T) {
@@ -390,5 +397,250 @@ func TestValidate_error1(t *testing.T) {
 	// @string is invalid because minLength > maxLength
 	require.NoError(t, err)
 	assert.IsError(t, errors[0], "KULDIJUYFB")
+	assert.Equal(t, "b.json", errors[0].File)
+	assert.Equal(t, "kego.io/system:@string", errors[0].Rule.Value())
 
 }
+
+func TestValidatePackageIgnore(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		".keignore": "fixtures/\n",
+		"a.yml": `
+			type: system:package
+			recursive: true
+			exclude: ["*.ci.yml"]
+		`,
+		"b.ci.yml": "foo: bar\n",
+	})
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "fixtures"), 0777))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "fixtures", "c.json"), []byte(`{"foo": "bar"}`), 0777))
+
+	cb.Path(path).Dir(dir).Recursive(true).Jauto().Sauto(parser.Parse)
+
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(errors))
+
+	cb.Env().Exclude = nil
+	_, err = ValidatePackage(cb.Ctx())
+	assert.IsError(t, err, "KWLWXKWHLF")
+}
+
+func TestValidatePackageReferences(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathB, _ := cb.TempPackage("b", map[string]string{
+		"word.yml": `
+			type: system:type
+			id: word
+			fields:
+				text:
+					type: system:@string
+		`,
+		"c.yml": `
+			type: word
+			id: c
+			text: foo
+		`,
+	})
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yml": `
+			type: system:package
+			aliases:
+				b: ` + pathB + `
+		`,
+		"translation.yml": `
+			type: system:type
+			id: translation
+			fields:
+				word:
+					type: system:@reference
+					types: [b:word]
+					optional: true
+				words:
+					type: system:@array
+					items:
+						type: system:@reference
+						types: [b:word, translation]
+					optional: true
+				global:
+					type: system:@reference
+					global: true
+					optional: true
+				any:
+					type: system:@reference
+					optional: true
+		`,
+		"d.yml": `
+			type: translation
+			id: d
+			word: b:c
+			words: [b:c, d]
+			global: b:c
+			any: missing
+		`,
+		"e.yml": `
+			type: translation
+			id: e
+			word: d
+		`,
+		"f.yml": `
+			type: translation
+			id: f
+			words: [b:c, b:missing]
+		`,
+		"g.yml": `
+			type: translation
+			id: g
+			global: translation
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Alias("b", pathB).Jauto().Sauto(parser.Parse)
+
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 3, len(errors))
+	messages := map[string]string{}
+	paths := map[string]string{}
+	for _, e := range errors {
+		assert.IsError(t, e, "BMVBBZOHZZ")
+		messages[e.File] = e.Description
+		paths[e.File] = e.Source.Path()
+	}
+	assert.Equal(t, "Reference "+path+":d refers to a "+path+":translation, but should refer to a "+pathB+":word", messages["e.yml"])
+	assert.Equal(t, "e/word", paths["e.yml"])
+	assert.Equal(t, "Reference "+pathB+":missing doesn't refer to a global", messages["f.yml"])
+	assert.Equal(t, "f/words/1", paths["f.yml"])
+	// translation is a type, not a global.
+	assert.Equal(t, "Reference "+path+":translation doesn't refer to a global", messages["g.yml"])
+	assert.Equal(t, "g/global", paths["g.yml"])
+}
+
+func TestValidateEnum(t *testing.T) {
+	cb := tests.New().TempGopath(true).CopyToTemp("kego.io/process/validate/tests")
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yml": `
+			type: system:package
+			aliases:
+				tests: kego.io/process/validate/tests
+		`,
+		"b.yml": `
+			type: tests:h
+			id: b
+			a: light-blue
+		`,
+		"colour.yml": `
+			type: system:type
+			id: colour
+			native: string
+			members:
+				red: Red
+				green: Green
+		`,
+		"shape.yml": `
+			type: system:type
+			id: shape
+			fields:
+				colour:
+					type: "@colour"
+		`,
+		"c.yml": `
+			type: shape
+			id: c
+			colour: blue
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Alias("tests", "kego.io/process/validate/tests").Jauto().Sauto(parser.Parse)
+
+	// The colour type has no generated Go type, so the value is checked when it's validated.
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(errors))
+	assert.IsError(t, errors[0], "SFZDYHPSLI")
+	assert.Equal(t, `"blue" is not a member of `+path+`:colour`, errors[0].Description)
+
+	// The values of the tests:g enum type are checked when they're unpacked.
+	cb.TempFile("b.yml", "type: tests:h\nid: b\na: blue\n")
+	_, err = ValidatePackage(cb.Ctx())
+	assert.IsError(t, err, "KWLWXKWHLF")
+	assert.HasError(t, err, "PXCQGOIIDC")
+	pe, ok := json.AsPositionError(err)
+	require.True(t, ok)
+	assert.Equal(t, 3, pe.Position.Line)
+	assert.Contains(t, pe.Error(), `"blue" is not a member of g`)
+}
+
+func TestValidateOneOf(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yml": `
+			type: system:package
+		`,
+		"holder.yml": `
+			type: system:type
+			id: holder
+			fields:
+				first:
+					type: system:@rule
+					interface: true
+					one-of: [system:@string, system:@number]
+				others:
+					type: system:@array
+					items:
+						type: system:@rule
+						interface: true
+						one-of: [system:@string]
+					optional: true
+				named:
+					type: system:@map
+					one-of: [system:@string, system:@number]
+					items:
+						type: system:@rule
+						interface: true
+						one-of: [system:@number, system:@bool]
+					optional: true
+		`,
+		"b.yml": `
+			type: holder
+			id: b
+			first:
+				type: system:@number
+			others:
+				-   type: system:@string
+				-   type: system:@bool
+			named:
+				c:
+					type: system:@number
+				d:
+					type: system:@bool
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Jauto().Sauto(parser.Parse)
+
+	errors, err := ValidatePackage(cb.Ctx())
+	require.NoError(t, err)
+	require.Equal(t, 2, len(errors))
+	byPath := map[string]ValidationError{}
+	for _, e := range errors {
+		assert.IsError(t, e, "XQVJYFNRAS")
+		byPath[e.Source.Path()] = e
+	}
+
+	assert.Equal(t, "Type kego.io/system:@bool is not one of kego.io/system:@string", byPath["b/others/1"].Description)
+	assert.Equal(t, "kego.io/system:@rule", byPath["b/others/1"].Rule.Value())
+
+	// the items of a map are restricted by the one-of lists of both the map and the items rule
+	assert.Equal(t, "Type kego.io/system:@bool is not one of kego.io/system:@number", byPath["b/named/d"].Description)
+}
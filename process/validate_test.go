@@ -0,0 +1,122 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/davelondon/kerr"
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/process/parser"
+	"kego.io/process/validate"
+	"kego.io/tests"
+)
+
+func TestValidate(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	// There's no generated.go, so the types are validated dynamically without building the
+	// validate command.
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				b:
+					type: system:@string
+					max-length: 5
+				c:
+					type: system:@array
+					items:
+						type: "@d"
+					max-items: 1
+		`,
+		"d.yaml": `
+			type: system:type
+			id: d
+			fields:
+				e:
+					type: system:@string
+					min-length: 2
+		`,
+		"e.yaml": `
+			type: a
+			id: e
+			b: foo
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Cmd().Jauto().Sauto(parser.Parse)
+
+	err := Validate(cb.Ctx())
+	require.NoError(t, err)
+
+	cb.TempFile("f.yaml", `
+		type: a
+		id: f
+		b: tooolong
+		c:
+			-
+				type: d
+				e: g
+			-
+				type: d
+				e: hh
+	`)
+
+	cb.Sauto(parser.Parse)
+
+	err = Validate(cb.Ctx())
+	assert.IsError(t, err, "BYDHDFFIEN")
+	v, ok := kerr.Source(err).(validate.ValidationCommandError)
+	require.True(t, ok)
+	require.Equal(t, 3, len(v.Entries))
+	entries := validate.SortReportEntries(v.Entries)
+	assert.Equal(t, "f.yaml:4:12: f/b: MaxLength: length of \"tooolong\" must not be greater than 5", entries[0].Position().String()+": "+entries[0].Path+": "+entries[0].Message)
+	assert.Equal(t, "f/c", entries[1].Path)
+	assert.Equal(t, "MaxItems: length 2 should not be greater than 1", entries[1].Message)
+	assert.Equal(t, "f/c/0/e", entries[2].Path)
+	assert.Contains(t, v.Description, "f/c/0/e: MinLength")
+
+	// Custom Enforce methods need the compiled validate command
+	needs, err := needsValidateCommand(cb.Ctx(), path, map[string]bool{})
+	require.NoError(t, err)
+	assert.False(t, needs)
+
+	cb.TempFile("a.go", "package a\n\nfunc (r *ARule) Enforce() {}\n")
+
+	needs, err = needsValidateCommand(cb.Ctx(), path, map[string]bool{})
+	require.NoError(t, err)
+	assert.True(t, needs)
+}
+
+func TestHasCustomValidation(t *testing.T) {
+
+	cb := tests.New().TempGopath(false)
+	defer cb.Cleanup()
+
+	_, dir := cb.TempPackage("a", map[string]string{
+		"a.go":         "package a\n\nfunc Validate() {}\n",
+		"generated.go": "package a\n\nfunc (a *A) Validate() {}\n",
+		"a_test.go":    "package a\n\nfunc (a *A) Enforce() {}\n",
+	})
+
+	custom, err := hasCustomValidation(dir)
+	require.NoError(t, err)
+	assert.False(t, custom)
+
+	cb.TempFile("b.go", "package a\n\nfunc (a *A) Validate() {}\n")
+	custom, err = hasCustomValidation(dir)
+	require.NoError(t, err)
+	assert.True(t, custom)
+
+	cb.RemoveTempFile("b.go")
+	cb.TempFile("c.go", "package a\n\nfunc (a *A) {")
+	_, err = hasCustomValidation(dir)
+	assert.IsError(t, err, "RNVSTMWDXH")
+
+	custom, err = hasCustomValidation("")
+	require.NoError(t, err)
+	assert.False(t, custom)
+}
@@ -0,0 +1,431 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"context"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/context/vosctx"
+	"kego.io/process/packages"
+	"kego.io/process/parser"
+	"kego.io/process/scanner"
+	"kego.io/process/validate"
+)
+
+// DefaultWatchInterval is the time between checks for changed files when Options.Interval is
+// zero.
+const DefaultWatchInterval = time.Second
+
+// Watch generates and validates the package, then waits for a data file to change in the
+// package, its sub-directories (if the package is recursive) or its aliased packages, and runs
+// again. Only the packages with changed files are parsed again, and unless the types or globals
+// have changed only the changed files are validated again. After each run the validation errors
+// that are new since the previous run are written to out prefixed with "+", and errors that have
+// been fixed are prefixed with "-". Errors that stop the run (e.g. a file that can't be parsed)
+// are written to out, and the watch continues. Watch returns when ctx is cancelled.
+func Watch(ctx context.Context, options *Options, out io.Writer) error {
+
+	interval := options.Interval
+	if interval == 0 {
+		interval = DefaultWatchInterval
+	}
+
+	w := &watcher{options: options}
+	defer w.reset()
+
+	var dirs map[string]*envctx.Env
+	var previous []validate.ReportEntry
+	var changed []string
+
+	for {
+		// Take the snapshot before running, so changes during the run are picked up.
+		var before map[string]fileState
+		if dirs != nil {
+			var err error
+			if before, err = snapshot(ctx, dirs); err != nil {
+				return kerr.Wrap("RKGSCCQTVE", err)
+			}
+		}
+
+		runDirs, entries, err := w.run(ctx, changed)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if runDirs != nil {
+			dirs = runDirs
+		}
+		if dirs == nil {
+			// The first run failed before the package was parsed, so we don't know the aliased
+			// packages yet. Until it succeeds we watch everything in the package directory.
+			dir, dirErr := packageDir(ctx, options)
+			if dirErr != nil {
+				return kerr.Wrap("WXMGSPNPDO", dirErr)
+			}
+			dirs = map[string]*envctx.Env{dir: {Dir: dir, Recursive: true}}
+		}
+		if err != nil {
+			fmt.Fprintln(out, err.Error())
+		} else {
+			writeWatchDiff(out, previous, entries)
+			previous = entries
+		}
+
+		if before == nil {
+			if before, err = snapshot(ctx, dirs); err != nil {
+				return kerr.Wrap("AQCBTGHGKE", err)
+			}
+		}
+		if changed, err = waitForChange(ctx, dirs, before, interval); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return kerr.Wrap("FJOXNMUJHQ", err)
+		}
+	}
+}
+
+// watcher keeps the parsed packages and the validated files between the runs of Watch.
+type watcher struct {
+	options *Options
+	// ctx is the initialised context, or nil if the package needs to be parsed from scratch
+	// because there hasn't been a successful run yet, or the last run failed.
+	ctx       context.Context
+	cancel    context.CancelFunc
+	validator *validate.Validator
+	dirs      map[string]*envctx.Env
+}
+
+// reset discards the parsed packages, so the next run starts from scratch.
+func (w *watcher) reset() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.ctx, w.cancel, w.validator, w.dirs = nil, nil, nil, nil
+}
+
+// run parses the package, generates the types where they have changed and validates the
+// package. If a previous run succeeded, only the packages that hold the changed files are parsed
+// again, the types are only generated if they have changed, and only the changed files are
+// validated unless the types or globals have changed. It returns the directories to watch, which
+// are known as soon as the package has been parsed, and the validation errors.
+func (w *watcher) run(ctx context.Context, changed []string) (dirs map[string]*envctx.Env, entries []validate.ReportEntry, err error) {
+
+	generate, all := true, true
+	if w.ctx == nil {
+		wctx, cancel, err := Initialise(ctx, w.options)
+		if err != nil {
+			return nil, nil, kerr.Wrap("OAJBEHUFWY", err)
+		}
+		w.ctx, w.cancel, w.validator = wctx, cancel, validate.NewValidator()
+	} else {
+		if generate, all, err = w.reparse(changed); err != nil {
+			dirs := w.dirs
+			w.reset()
+			return dirs, nil, kerr.Wrap("TNWKRCLOEB", err)
+		}
+	}
+
+	env := envctx.FromContext(w.ctx)
+	dirs = map[string]*envctx.Env{}
+	watchDirs(w.ctx, env.Path, dirs)
+	w.dirs = dirs
+
+	if generate {
+		if err := GenerateAll(w.ctx, env.Path, map[string]bool{}); err != nil {
+			w.reset()
+			return dirs, nil, kerr.Wrap("CYTMHQLJGA", err)
+		}
+	}
+
+	var files map[string]bool
+	if !all {
+		files = map[string]bool{}
+		for _, file := range changed {
+			files[file] = true
+		}
+	}
+	err = validatePackage(w.ctx, w.validator, files)
+	if v, ok := kerr.Source(err).(validate.ValidationCommandError); ok {
+		return dirs, v.Entries, nil
+	}
+	if err != nil {
+		// The files after the one that failed weren't validated, so next time we start again.
+		w.validator = validate.NewValidator()
+		return dirs, nil, kerr.Wrap("YUXRMQJHBO", err)
+	}
+	return dirs, []validate.ReportEntry{}, nil
+}
+
+// reparse parses the packages that hold the changed files again. generate is true if the types
+// of one of the packages have changed. all is true if every file should be validated again,
+// because the types or globals have changed, a package object has changed the files that are
+// scanned, or a file that isn't a data file (e.g. .keignore) has changed.
+func (w *watcher) reparse(changed []string) (generate bool, all bool, err error) {
+
+	scache := sysctx.FromContext(w.ctx)
+
+	paths := []string{}
+	before := map[string]*sysctx.SysPackageInfo{}
+	for _, file := range changed {
+		switch filepath.Ext(file) {
+		case ".json", ".yaml", ".yml":
+		default:
+			all = true
+		}
+		env := w.packageOf(file)
+		if env == nil {
+			continue
+		}
+		if _, ok := before[env.Path]; ok {
+			continue
+		}
+		pi, ok := scache.Get(env.Path)
+		if !ok {
+			// ke: {"block": {"notest": true}}
+			continue
+		}
+		before[env.Path] = pi
+		paths = append(paths, env.Path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		scache.Delete(path)
+	}
+	for _, path := range paths {
+		if _, ok := scache.Get(path); ok {
+			// Already parsed because it's imported by another changed package.
+			continue
+		}
+		if _, err := parser.Parse(w.ctx, path); err != nil {
+			return false, false, kerr.Wrap("GBLSXQYRPW", err)
+		}
+	}
+
+	for _, path := range paths {
+		after, ok := scache.Get(path)
+		if !ok {
+			// ke: {"block": {"notest": true}}
+			return true, true, nil
+		}
+		b := before[path]
+		if after.Hash != b.Hash {
+			generate, all = true, true
+		}
+		if after.Recursive != b.Recursive || !reflect.DeepEqual(after.Include, b.Include) || !reflect.DeepEqual(after.Exclude, b.Exclude) {
+			all = true
+		}
+		if !sameGlobals(b.Globals, after.Globals) {
+			all = true
+		}
+	}
+
+	// The package may have been parsed again, so the env in the context is replaced.
+	env := envctx.FromContext(w.ctx)
+	if pi, ok := scache.Get(env.Path); ok {
+		w.ctx = envctx.NewContext(w.ctx, pi.Env)
+	}
+
+	return generate, all, nil
+}
+
+// packageOf returns the env of the watched package that holds the file, or nil if the file isn't
+// in a watched package. If the file is in more than one, the deepest dir is used.
+func (w *watcher) packageOf(file string) *envctx.Env {
+	var found *envctx.Env
+	for dir, env := range w.dirs {
+		rel, err := filepath.Rel(dir, file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !env.Recursive && filepath.Dir(rel) != "." {
+			continue
+		}
+		if found == nil || len(dir) > len(found.Dir) {
+			found = env
+		}
+	}
+	return found
+}
+
+func sameGlobals(a, b *sysctx.SysGlobals) bool {
+	keys := a.Keys()
+	if !reflect.DeepEqual(keys, b.Keys()) {
+		return false
+	}
+	for _, k := range keys {
+		ga, _ := a.Get(k)
+		gb, _ := b.Get(k)
+		if *ga != *gb {
+			return false
+		}
+	}
+	return true
+}
+
+// packageDir returns the directory of the package in options, or the current directory if the
+// package path is empty.
+func packageDir(ctx context.Context, options *Options) (string, error) {
+	if options.Path == "" {
+		dir, err := vosctx.FromContext(ctx).Getwd()
+		if err != nil {
+			return "", kerr.Wrap("MNEOHCVBKW", err)
+		}
+		return dir, nil
+	}
+	dir, err := packages.GetDirFromPackage(ctx, options.Path)
+	if err != nil {
+		return "", kerr.Wrap("PGBXWYRNDA", err)
+	}
+	return dir, nil
+}
+
+// watchDirs adds the directory of the package and the directories of its aliased packages to
+// dirs, with the env of each package.
+func watchDirs(ctx context.Context, path string, dirs map[string]*envctx.Env) {
+	pi, ok := sysctx.FromContext(ctx).Get(path)
+	if !ok || pi.Dir == "" {
+		return
+	}
+	if _, done := dirs[pi.Dir]; done {
+		return
+	}
+	dirs[pi.Dir] = pi.Env
+	for _, aliasPath := range pi.Aliases {
+		watchDirs(ctx, aliasPath, dirs)
+	}
+}
+
+type fileState struct {
+	modified time.Time
+	size     int64
+}
+
+// snapshot returns the modification time and size of the data files in the package dirs, and of
+// their .keignore files. The files are found in the same way as when the package is scanned, so
+// the files in .localke, and the files excluded by the .keignore file or the include and exclude
+// patterns of the package, aren't watched.
+func snapshot(ctx context.Context, dirs map[string]*envctx.Env) (map[string]fileState, error) {
+	files := map[string]fileState{}
+	add := func(file string) error {
+		info, err := os.Stat(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// The file was deleted while we were scanning the directory.
+				return nil
+			}
+			// ke: {"block": {"notest": true}}
+			return kerr.Wrap("VTCVPOXDRT", err)
+		}
+		files[file] = fileState{modified: info.ModTime(), size: info.Size()}
+		return nil
+	}
+	for dir, env := range dirs {
+		if err := add(filepath.Join(dir, scanner.IgnoreFile)); err != nil {
+			// ke: {"block": {"notest": true}}
+			return nil, kerr.Wrap("HDWQYBNMKA", err)
+		}
+		for f := range scanner.ScanPackageToFiles(ctx, env) {
+			if f.Err != nil {
+				return nil, kerr.Wrap("LIQYJEXBDX", f.Err)
+			}
+			switch filepath.Ext(f.File) {
+			case ".json", ".yaml", ".yml":
+				if err := add(f.File); err != nil {
+					// ke: {"block": {"notest": true}}
+					return nil, kerr.Wrap("OSUFGKDWCJ", err)
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// changedFiles returns the files that have been added, removed or changed between the snapshots,
+// in order.
+func changedFiles(a, b map[string]fileState) []string {
+	changed := []string{}
+	for file, state := range a {
+		if other, ok := b[file]; !ok || !other.modified.Equal(state.modified) || other.size != state.size {
+			changed = append(changed, file)
+		}
+	}
+	for file := range b {
+		if _, ok := a[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// waitForChange polls dirs until the files are different to before, or ctx is cancelled. It
+// returns the files that have changed.
+func waitForChange(ctx context.Context, dirs map[string]*envctx.Env, before map[string]fileState, interval time.Duration) ([]string, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, kerr.Wrap("JQHCDSNRVI", ctx.Err())
+		case <-ticker.C:
+			after, err := snapshot(ctx, dirs)
+			if err != nil {
+				return nil, kerr.Wrap("SBFRXLHWKU", err)
+			}
+			if changed := changedFiles(before, after); len(changed) > 0 {
+				return changed, nil
+			}
+		}
+	}
+}
+
+// writeWatchDiff writes the errors in current that aren't in previous prefixed with "+", the
+// errors in previous that aren't in current prefixed with "-", and a summary. Errors are
+// compared without their line and column, so an error doesn't show as new when a line is added
+// above it.
+func writeWatchDiff(w io.Writer, previous, current []validate.ReportEntry) {
+	key := func(e validate.ReportEntry) string {
+		return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s", e.File, e.Path, e.Code, e.Rule, e.Message)
+	}
+	before := map[string]bool{}
+	for _, e := range previous {
+		before[key(e)] = true
+	}
+	after := map[string]bool{}
+	for _, e := range current {
+		after[key(e)] = true
+	}
+	added, fixed := 0, 0
+	for _, e := range validate.SortReportEntries(current) {
+		if !before[key(e)] {
+			fmt.Fprintf(w, "+ %s: %s: %s\n", e.Position(), e.Path, e.Message)
+			added++
+		}
+	}
+	for _, e := range validate.SortReportEntries(previous) {
+		if !after[key(e)] {
+			fmt.Fprintf(w, "- %s: %s: %s\n", e.Position(), e.Path, e.Message)
+			fixed++
+		}
+	}
+	if len(current) == 0 && fixed == 0 {
+		fmt.Fprintln(w, "OK.")
+		return
+	}
+	if len(current) == 0 {
+		fmt.Fprintf(w, "OK: %d fixed.\n", fixed)
+		return
+	}
+	fmt.Fprintf(w, "%d errors: %d new, %d fixed.\n", len(current), added, fixed)
+}
@@ -0,0 +1,205 @@
+package process
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"context"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/context/envctx"
+	"kego.io/process/parser"
+	"kego.io/process/validate"
+	"kego.io/tests"
+)
+
+func TestWatchDirs(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"a.yaml": "type: system:type\nid: a",
+	})
+	pathB, dirB := cb.TempPackage("b", map[string]string{
+		"package.yaml": "type: system:package\nrecursive: true\naliases:\n  a: " + pathA,
+		"b.yaml":       "type: system:type\nid: b",
+	})
+
+	cb.Path(pathB).Dir(dirB).Jauto().Sauto(parser.Parse)
+
+	dirs := map[string]*envctx.Env{}
+	watchDirs(cb.Ctx(), pathB, dirs)
+	require.Equal(t, 2, len(dirs))
+	assert.Equal(t, pathA, dirs[dirA].Path)
+	assert.False(t, dirs[dirA].Recursive)
+	assert.Equal(t, pathB, dirs[dirB].Path)
+	assert.True(t, dirs[dirB].Recursive)
+}
+
+func TestSnapshot(t *testing.T) {
+	d, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(d)
+
+	write := func(file, contents string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(d, file)), 0777))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(d, file), []byte(contents), 0666))
+	}
+	write("a.yaml", "a")
+	write("b.go", "b")
+	write("c/d.json", "d")
+	write(".localke/report.json", "e")
+	write("g/h.json", "h")
+	write(".keignore", "g/\n")
+
+	ctx := context.Background()
+	flat := map[string]*envctx.Env{d: {Dir: d}}
+	recursive := map[string]*envctx.Env{d: {Dir: d, Recursive: true}}
+
+	s1, err := snapshot(ctx, flat)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(s1))
+	_, ok := s1[filepath.Join(d, "a.yaml")]
+	assert.True(t, ok)
+	_, ok = s1[filepath.Join(d, ".keignore")]
+	assert.True(t, ok)
+
+	s1, err = snapshot(ctx, recursive)
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(s1))
+	_, ok = s1[filepath.Join(d, "c", "d.json")]
+	assert.True(t, ok)
+
+	// changes to files that aren't data files, are in .localke or are ignored by .keignore are
+	// ignored
+	write("b.go", "bb")
+	write(".localke/report.json", "ee")
+	write("g/h.json", "hh")
+	s2, err := snapshot(ctx, recursive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, changedFiles(s1, s2))
+
+	// files excluded by the package are ignored
+	s2, err = snapshot(ctx, map[string]*envctx.Env{d: {Dir: d, Recursive: true, Exclude: []string{"c/"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(s2))
+
+	write("c/d.json", "dd")
+	s2, err = snapshot(ctx, recursive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(d, "c", "d.json")}, changedFiles(s1, s2))
+
+	write("f.yml", "f")
+	s3, err := snapshot(ctx, recursive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(d, "f.yml")}, changedFiles(s2, s3))
+
+	require.NoError(t, os.Remove(filepath.Join(d, "f.yml")))
+	s4, err := snapshot(ctx, recursive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(d, "f.yml")}, changedFiles(s3, s4))
+	assert.Equal(t, []string{}, changedFiles(s2, s4))
+
+	write(".keignore", "")
+	s5, err := snapshot(ctx, recursive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(d, ".keignore"), filepath.Join(d, "g", "h.json")}, changedFiles(s4, s5))
+
+	write(".keignore", "[z-a]")
+	_, err = snapshot(ctx, recursive)
+	assert.IsError(t, err, "LIQYJEXBDX")
+}
+
+func TestWaitForChange(t *testing.T) {
+	d, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(d)
+
+	dirs := map[string]*envctx.Env{d: {Dir: d}}
+	before, err := snapshot(context.Background(), dirs)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		ioutil.WriteFile(filepath.Join(d, "a.json"), []byte("a"), 0666)
+	}()
+	changed, err := waitForChange(context.Background(), dirs, before, time.Millisecond*5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(d, "a.json")}, changed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = waitForChange(ctx, dirs, before, time.Millisecond*5)
+	assert.IsError(t, err, "JQHCDSNRVI")
+}
+
+func TestWatcherRun(t *testing.T) {
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	pathA, dirA := cb.TempPackage("a", map[string]string{
+		"a.yaml": "type: system:type\nid: a\nfields:\n  b:\n    type: system:@string\n    max-length: 1",
+		"g.yaml": "type: a\nid: g\nb: c",
+		"h.yaml": "type: a\nid: h\nb: d",
+	})
+
+	w := &watcher{options: &Options{Path: pathA}}
+	defer w.reset()
+
+	dirs, entries, err := w.run(cb.Ctx(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+	assert.Equal(t, pathA, dirs[dirA].Path)
+
+	// Only the changed file is validated again, so the node of the other file is kept.
+	h := w.validator
+	g := filepath.Join(dirA, "g.yaml")
+	require.NoError(t, ioutil.WriteFile(g, []byte("type: a\nid: g\nb: cc"), 0666))
+	_, entries, err = w.run(cb.Ctx(), []string{g})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, "g.yaml", entries[0].File)
+	assert.True(t, h == w.validator)
+
+	// The types have changed, so every file is validated again.
+	a := filepath.Join(dirA, "a.yaml")
+	require.NoError(t, ioutil.WriteFile(a, []byte("type: system:type\nid: a\nfields:\n  b:\n    type: system:@string\n    max-length: 2"), 0666))
+	_, entries, err = w.run(cb.Ctx(), []string{a})
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(entries))
+
+	// A file that can't be parsed stops the run, and the next run starts from scratch.
+	require.NoError(t, ioutil.WriteFile(a, []byte("type: ["), 0666))
+	_, _, err = w.run(cb.Ctx(), []string{a})
+	assert.IsError(t, err, "TNWKRCLOEB")
+	assert.Nil(t, w.ctx)
+}
+
+func TestWriteWatchDiff(t *testing.T) {
+	a := validate.ReportEntry{File: "a.yaml", Line: 2, Column: 3, Path: "a", Code: "HLKQWDCMRN", Message: "b"}
+	b := validate.ReportEntry{File: "b.yaml", Line: 4, Column: 5, Path: "c", Code: "HLKQWDCMRN", Message: "d"}
+
+	w := &bytes.Buffer{}
+	writeWatchDiff(w, nil, []validate.ReportEntry{})
+	assert.Equal(t, "OK.\n", w.String())
+
+	w.Reset()
+	writeWatchDiff(w, nil, []validate.ReportEntry{b, a})
+	assert.Equal(t, "+ a.yaml:2:3: a: b\n+ b.yaml:4:5: c: d\n2 errors: 2 new, 0 fixed.\n", w.String())
+
+	// a has moved down a line, but it's the same error so it's not shown
+	moved := a
+	moved.Line = 3
+	w.Reset()
+	writeWatchDiff(w, []validate.ReportEntry{a, b}, []validate.ReportEntry{moved})
+	assert.Equal(t, "- b.yaml:4:5: c: d\n1 errors: 0 new, 1 fixed.\n", w.String())
+
+	w.Reset()
+	writeWatchDiff(w, []validate.ReportEntry{moved}, []validate.ReportEntry{})
+	assert.Equal(t, "- a.yaml:3:3: a: b\nOK: 1 fixed.\n", w.String())
+}
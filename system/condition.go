@@ -0,0 +1,45 @@
+package system
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetIf returns the if selector of the rule, or an empty string if the condition is always met.
+func (r *ConditionRule) GetIf() string {
+	if r.If == nil {
+		return ""
+	}
+	return r.If.Value()
+}
+
+// GetThen returns the then selector of the rule, or an empty string if it's not set.
+func (r *ConditionRule) GetThen() string {
+	if r.Then == nil {
+		return ""
+	}
+	return r.Then.Value()
+}
+
+func (r *ConditionRule) Validate(ctx context.Context) (fail bool, messages []string, err error) {
+	if r.GetThen() == "" && len(r.ThenRules) == 0 {
+		fail = true
+		messages = append(messages, "Then: a condition should have then or then-rules")
+	}
+	// Only rules that enforce a value, and other conditions, can be checked in then-rules.
+	for i, t := range r.ThenRules {
+		switch t.(type) {
+		case *ConditionRule, Enforcer:
+			continue
+		}
+		name := fmt.Sprintf("%T", t)
+		if o, ok := t.(ObjectInterface); ok && o.GetObject(nil) != nil && o.GetObject(nil).Type != nil {
+			name = o.GetObject(nil).Type.Value()
+		}
+		fail = true
+		messages = append(messages, fmt.Sprintf("ThenRules: %s at %d has nothing to enforce, so it can't be checked", name, i))
+	}
+	return
+}
+
+var _ Validator = (*ConditionRule)(nil)
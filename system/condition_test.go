@@ -0,0 +1,40 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/context/envctx"
+)
+
+func TestConditionRule_Validate(t *testing.T) {
+	r := &ConditionRule{}
+	assert.Equal(t, "", r.GetIf())
+	assert.Equal(t, "", r.GetThen())
+	fail, messages, err := r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, "Then: a condition should have then or then-rules", messages[0])
+
+	r = &ConditionRule{If: NewString(".a"), Then: NewString(".b")}
+	assert.Equal(t, ".a", r.GetIf())
+	assert.Equal(t, ".b", r.GetThen())
+	fail, _, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+
+	r = &ConditionRule{ThenRules: []RuleInterface{&StringRule{}, &ConditionRule{}}}
+	fail, _, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+
+	r = &ConditionRule{ThenRules: []RuleInterface{&StringRule{}, &UniqueRule{Object: &Object{Type: NewReference("kego.io/system", "@unique")}}, &BoolRule{}}}
+	fail, messages, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, []string{
+		"ThenRules: kego.io/system:@unique at 1 has nothing to enforce, so it can't be checked",
+		"ThenRules: *system.BoolRule at 2 has nothing to enforce, so it can't be checked",
+	}, messages)
+}
@@ -0,0 +1,144 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/davelondon/kerr"
+	"kego.io/context/jsonctx"
+	"kego.io/context/sysctx"
+	"kego.io/json"
+)
+
+// IsEnum is true for enum types: types with native string that list their members.
+func (t *Type) IsEnum() bool {
+	return len(t.Members) > 0 && t.Native.Value() == "string"
+}
+
+// SortedMembers returns the values of the members of an enum type, sorted.
+func (t *Type) SortedMembers() []string {
+	out := []string{}
+	for m := range t.Members {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsMember is true if value is a member of the enum type.
+func (t *Type) IsMember(value string) bool {
+	_, ok := t.Members[value]
+	return ok
+}
+
+// MemberConstant returns the name of the Go constant of a member of the enum type. ok is false if
+// the name isn't a valid Go identifier, e.g. for "in progress", "v1.0" or "a/b", because only
+// the dashes in a member are removed.
+func (t *Type) MemberConstant(member string) (name string, ok bool) {
+	var prefix string
+	if t.Object != nil && t.Id != nil {
+		prefix = GoName(t.Id.Name)
+	}
+	suffix := GoName(member)
+	for _, r := range suffix {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return prefix + suffix, false
+		}
+	}
+	return prefix + suffix, true
+}
+
+func (t *Type) Validate(ctx context.Context) (fail bool, messages []string, err error) {
+	if len(t.Members) > 0 && t.Native.Value() != "string" {
+		messages = append(messages, fmt.Sprintf("Members: only types with native string can have members, not %s", t.Native.Value()))
+	}
+	if t.IsEnum() {
+		for _, m := range t.SortedMembers() {
+			if _, ok := t.MemberConstant(m); !ok {
+				messages = append(messages, fmt.Sprintf("Members: %s can't be a Go constant: only letters, digits, _ and - are allowed", strconv.Quote(m)))
+			}
+		}
+	}
+	if t.IsEnum() && t.Object != nil && t.Id != nil {
+		messages = append(messages, t.memberCollisions(ctx)...)
+	}
+	return len(messages) > 0, messages, nil
+}
+
+// memberCollisions returns a message for each member whose Go constant has the same name as the
+// constant of another member, e.g. red and Red, or as a Go type or interface generated for a
+// type in the package.
+func (t *Type) memberCollisions(ctx context.Context) []string {
+	names := []string{t.Id.Name, jsonctx.RULE_PREFIX + t.Id.Name}
+	if scache := sysctx.FromContextOrNil(ctx); scache != nil {
+		if pi, ok := scache.Get(t.Id.Package); ok {
+			names = append(names, pi.Types.Keys()...)
+		}
+	}
+	declared := map[string]string{}
+	for _, n := range names {
+		declared[GoName(n)] = "the type " + n
+		if !strings.HasPrefix(n, jsonctx.RULE_PREFIX) {
+			declared[GoInterfaceName(n)] = "the interface of " + n
+		}
+	}
+	out := []string{}
+	constants := map[string]string{}
+	for _, m := range t.SortedMembers() {
+		c, ok := t.MemberConstant(m)
+		if !ok {
+			continue
+		}
+		if other, ok := constants[c]; ok {
+			out = append(out, fmt.Sprintf("Members: %s and %s both have the Go constant %s", strconv.Quote(other), strconv.Quote(m), c))
+			continue
+		}
+		constants[c] = m
+		if d, ok := declared[c]; ok {
+			out = append(out, fmt.Sprintf("Members: the Go constant %s of %s has the same name as %s", c, strconv.Quote(m), d))
+		}
+	}
+	return out
+}
+
+var _ Validator = (*Type)(nil)
+
+// UnpackEnum is used by the Go types generated for enum types. It returns the value in the json,
+// and an error if it isn't one of the members.
+func UnpackEnum(in json.Packed, name string, members ...string) (string, error) {
+	if in == nil || in.Type() == json.J_NULL {
+		return "", kerr.New("ZZXWISQJRI", "Called %s.Unpack with nil value", name)
+	}
+	if in.Type() == json.J_MAP {
+		in = in.Map()["value"]
+	}
+	if in.Type() != json.J_STRING {
+		return "", kerr.New("ADDJUMQCNN", "Can't unpack %s into %s", in.Type(), name)
+	}
+	if !isMember(in.String(), members) {
+		return "", kerr.New("PXCQGOIIDC", "%s is not a member of %s", strconv.Quote(in.String()), name)
+	}
+	return in.String(), nil
+}
+
+// MarshalEnum is used by the Go types generated for enum types. It returns the json of the value,
+// and an error if it isn't one of the members.
+func MarshalEnum(value string, name string, members ...string) ([]byte, error) {
+	if !isMember(value, members) {
+		return nil, kerr.New("IUDQCKEONP", "%s is not a member of %s", strconv.Quote(value), name)
+	}
+	return []byte(strconv.Quote(value)), nil
+}
+
+func isMember(value string, members []string) bool {
+	for _, m := range members {
+		if m == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
+	"kego.io/json"
+)
+
+func TestTypeMembers(t *testing.T) {
+	ty := &Type{Native: NewString("string"), Members: map[string]string{"b": "c", "a": ""}}
+	assert.True(t, ty.IsEnum())
+	assert.Equal(t, []string{"a", "b"}, ty.SortedMembers())
+	assert.True(t, ty.IsMember("a"))
+	assert.False(t, ty.IsMember("c"))
+	fail, _, err := ty.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+
+	ty = &Type{Native: NewString("string")}
+	assert.False(t, ty.IsEnum())
+
+	ty = &Type{Native: NewString("number"), Members: map[string]string{"a": ""}}
+	assert.False(t, ty.IsEnum())
+	fail, messages, err := ty.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, "Members: only types with native string can have members, not number", messages[0])
+}
+
+func TestTypeMemberCollisions(t *testing.T) {
+	ctx := sysctx.NewContext(envctx.Empty)
+	pi := sysctx.FromContext(ctx).Set("a.b/c")
+	pi.Types.Set("color-red", "color-red.json", &Type{})
+
+	ty := &Type{Object: &Object{Id: NewReference("a.b/c", "color")}, Native: NewString("string"), Members: map[string]string{
+		"Red":       "",
+		"red":       "",
+		"blue":      "",
+		"rule":      "",
+		"interface": "",
+	}}
+	fail, messages, err := ty.Validate(ctx)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, []string{
+		`Members: the Go constant ColorRed of "Red" has the same name as the type color-red`,
+		`Members: the Go constant ColorInterface of "interface" has the same name as the interface of color`,
+		`Members: "Red" and "red" both have the Go constant ColorRed`,
+		`Members: the Go constant ColorRule of "rule" has the same name as the type @color`,
+	}, messages)
+
+	ty.Members = map[string]string{"Red": "", "blue": ""}
+	fail, messages, err = ty.Validate(ctx)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, []string{`Members: the Go constant ColorRed of "Red" has the same name as the type color-red`}, messages)
+
+	fail, _, err = ty.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+}
+
+func TestTypeMemberConstant(t *testing.T) {
+	ty := &Type{Object: &Object{Id: NewReference("a.b/c", "status")}, Native: NewString("string"), Members: map[string]string{
+		"in-progress": "",
+		"in progress": "",
+		"v1.0":        "",
+		"a/b":         "",
+		"2nd":         "",
+		"état":        "",
+	}}
+	c, ok := ty.MemberConstant("in-progress")
+	assert.True(t, ok)
+	assert.Equal(t, "StatusInProgress", c)
+	c, ok = ty.MemberConstant("2nd")
+	assert.True(t, ok)
+	assert.Equal(t, "Status2nd", c)
+	_, ok = ty.MemberConstant("v1.0")
+	assert.False(t, ok)
+
+	fail, messages, err := ty.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, []string{
+		`Members: "a/b" can't be a Go constant: only letters, digits, _ and - are allowed`,
+		`Members: "in progress" can't be a Go constant: only letters, digits, _ and - are allowed`,
+		`Members: "v1.0" can't be a Go constant: only letters, digits, _ and - are allowed`,
+	}, messages)
+}
+
+func TestUnpackEnum(t *testing.T) {
+	s, err := UnpackEnum(json.Pack("a"), "c", "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, "a", s)
+
+	s, err = UnpackEnum(json.Pack(map[string]interface{}{"type": "c", "value": "b"}), "c", "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, "b", s)
+
+	_, err = UnpackEnum(nil, "c", "a")
+	assert.IsError(t, err, "ZZXWISQJRI")
+
+	_, err = UnpackEnum(json.Pack(1.0), "c", "a")
+	assert.IsError(t, err, "ADDJUMQCNN")
+
+	_, err = UnpackEnum(json.Pack("d"), "c", "a", "b")
+	assert.IsError(t, err, "PXCQGOIIDC")
+}
+
+func TestMarshalEnum(t *testing.T) {
+	b, err := MarshalEnum("a", "c", "a", "b")
+	require.NoError(t, err)
+	assert.Equal(t, `"a"`, string(b))
+
+	_, err = MarshalEnum("d", "c", "a", "b")
+	assert.IsError(t, err, "IUDQCKEONP")
+}
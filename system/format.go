@@ -0,0 +1,114 @@
+package system
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatFunc reports whether value is valid for a named string format.
+type FormatFunc func(value string) bool
+
+var formats struct {
+	sync.RWMutex
+	m map[string]FormatFunc
+}
+
+// RegisterFormat adds a named format that can be used in the format restriction of a
+// system:@string rule. Registering a name that already exists replaces it.
+func RegisterFormat(name string, f FormatFunc) {
+	formats.Lock()
+	defer formats.Unlock()
+	if formats.m == nil {
+		formats.m = map[string]FormatFunc{}
+	}
+	formats.m[name] = f
+}
+
+// GetFormat returns the format function registered with name.
+func GetFormat(name string) (FormatFunc, bool) {
+	formats.RLock()
+	defer formats.RUnlock()
+	f, ok := formats.m[name]
+	return f, ok
+}
+
+// FormatNames returns the sorted names of all registered formats.
+func FormatNames() []string {
+	formats.RLock()
+	defer formats.RUnlock()
+	out := []string{}
+	for name := range formats.m {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func init() {
+	RegisterFormat("date-time", isDateTime)
+	RegisterFormat("email", isEmail)
+	RegisterFormat("hostname", isHostname)
+	RegisterFormat("ipv4", isIpv4)
+	RegisterFormat("ipv6", isIpv6)
+	RegisterFormat("uri", isUri)
+}
+
+// isDateTime checks for an RFC 3339 date-time e.g. 2006-01-02T15:04:05Z
+func isDateTime(value string) bool {
+	_, err := time.Parse(time.RFC3339Nano, value)
+	return err == nil
+}
+
+// isEmail checks for a bare RFC 5322 address e.g. foo@bar.com - display names are not permitted.
+func isEmail(value string) bool {
+	a, err := mail.ParseAddress(value)
+	if err != nil {
+		return false
+	}
+	return a.Name == "" && a.Address == value
+}
+
+// isHostname checks for an RFC 1123 hostname.
+func isHostname(value string) bool {
+	if len(value) == 0 || len(value) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(value, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isIpv4(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+}
+
+func isIpv6(value string) bool {
+	ip := net.ParseIP(value)
+	return ip != nil && strings.Contains(value, ":")
+}
+
+// isUri checks for an absolute RFC 3986 uri e.g. http://foo.com/bar
+func isUri(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return u.IsAbs() && (u.Host != "" || u.Opaque != "" || u.Path != "")
+}
@@ -0,0 +1,76 @@
+package system
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+)
+
+func TestRegisterFormat(t *testing.T) {
+	_, ok := GetFormat("kego-test-semver")
+	assert.False(t, ok)
+
+	RegisterFormat("kego-test-semver", func(value string) bool {
+		return strings.Count(value, ".") == 2
+	})
+	f, ok := GetFormat("kego-test-semver")
+	assert.True(t, ok)
+	assert.True(t, f("1.2.3"))
+	assert.False(t, f("1.2"))
+	assert.Contains(t, FormatNames(), "kego-test-semver")
+
+	r := StringRule{Rule: &Rule{}, Format: NewString("kego-test-semver")}
+	fail, messages, err := r.Validate(nil)
+	assert.NoError(t, err)
+	assert.False(t, fail)
+	assert.Equal(t, 0, len(messages))
+
+	fail, messages, err = r.Enforce(nil, NewString("1.2"))
+	assert.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, "Format: value \"1.2\" must be a valid kego-test-semver", messages[0])
+}
+
+func TestBuiltInFormats(t *testing.T) {
+	test := func(format string, value string, expected bool) {
+		f, ok := GetFormat(format)
+		assert.True(t, ok, format)
+		assert.Equal(t, expected, f(value), "%s: %s", format, value)
+	}
+
+	test("date-time", "2006-01-02T15:04:05Z", true)
+	test("date-time", "2006-01-02T15:04:05.999+07:00", true)
+	test("date-time", "2006-01-02", false)
+	test("date-time", "a", false)
+
+	test("email", "foo@bar.com", true)
+	test("email", "Foo <foo@bar.com>", false)
+	test("email", "foo", false)
+	test("email", "", false)
+
+	test("hostname", "foo.com", true)
+	test("hostname", "foo-bar.example.com.", true)
+	test("hostname", "localhost", true)
+	test("hostname", "-foo.com", false)
+	test("hostname", "foo..com", false)
+	test("hostname", "foo_bar.com", false)
+	test("hostname", strings.Repeat("a", 64)+".com", false)
+	test("hostname", "", false)
+
+	test("ipv4", "192.168.0.1", true)
+	test("ipv4", "::1", false)
+	test("ipv4", "::ffff:192.168.0.1", false)
+	test("ipv4", "256.0.0.1", false)
+
+	test("ipv6", "::1", true)
+	test("ipv6", "2001:db8::68", true)
+	test("ipv6", "192.168.0.1", false)
+	test("ipv6", "a", false)
+
+	test("uri", "http://foo.com/bar?baz=qux", true)
+	test("uri", "mailto:foo@bar.com", true)
+	test("uri", "/foo/bar", false)
+	test("uri", "foo", false)
+	test("uri", "http://[::1", false)
+}
@@ -1,13 +1,12 @@
-// info:{"Path":"kego.io/system","Hash":10967192916392524561}
+// info:{"Path":"kego.io/system","Hash":17147125178276652795}
 package system
 
 // ke: {"file": {"notest": true}}
 
 import (
 	"context"
-	"reflect"
-
 	"kego.io/context/jsonctx"
+	"reflect"
 )
 
 // Restriction rules for arrays
@@ -32,6 +31,24 @@ type BoolRule struct {
 	Default *Bool `json:"default"`
 }
 
+// Automatically created basic rule for change-type-reference
+type ChangeTypeReferenceRule struct {
+	*Object
+	*Rule
+}
+
+// Restriction rules for conditions.
+type ConditionRule struct {
+	*Object
+	*Rule
+	// The condition is met if this selector matches a node - e.g. .protocol:expr(x = "https"). If it's omitted, the condition is always met.
+	If *String `json:"if"`
+	// When the condition is met, this selector must match a node - e.g. .port:expr(x = 443).
+	Then *String `json:"then"`
+	// When the condition is met, these rules are enforced. Their selectors are relative to the object.
+	ThenRules []RuleInterface `json:"then-rules"`
+}
+
 // Restriction rules for integers
 type IntRule struct {
 	*Object
@@ -46,6 +63,12 @@ type IntRule struct {
 	MultipleOf *Int `json:"multiple-of"`
 }
 
+// Automatically created basic rule for lint-config
+type LintConfigRule struct {
+	*Object
+	*Rule
+}
+
 // Restriction rules for maps
 type MapRule struct {
 	*Object
@@ -60,6 +83,18 @@ type MapRule struct {
 	MinItems *Int `json:"min-items"`
 }
 
+// Automatically created basic rule for migration
+type MigrationRule struct {
+	*Object
+	*Rule
+}
+
+// Automatically created basic rule for move-field
+type MoveFieldRule struct {
+	*Object
+	*Rule
+}
+
 // Restriction rules for numbers
 type NumberRule struct {
 	*Object
@@ -84,6 +119,12 @@ type ObjectRule struct {
 	*Rule
 }
 
+// Automatically created basic rule for operation
+type OperationRule struct {
+	*Object
+	*Rule
+}
+
 // Automatically created basic rule for package
 type PackageRule struct {
 	*Object
@@ -96,10 +137,20 @@ type ReferenceRule struct {
 	*Rule
 	// Default value of this is missing or null
 	Default *Reference `json:"default"`
+	// The value must refer to an existing global. This is checked when the package is validated.
+	Global *Bool `json:"global"`
 	// The value must match this regex
 	Pattern *String `json:"pattern"`
 	// The value must not match this regex
 	PatternNot *String `json:"pattern-not"`
+	// The value must refer to an existing global of one of these types, so global is implied. This is checked when the package is validated.
+	Types []*Reference `json:"types"`
+}
+
+// Automatically created basic rule for rename-field
+type RenameFieldRule struct {
+	*Object
+	*Rule
 }
 
 // Automatically created basic rule for rule
@@ -108,6 +159,12 @@ type RuleRule struct {
 	*Rule
 }
 
+// Automatically created basic rule for set-default
+type SetDefaultRule struct {
+	*Object
+	*Rule
+}
+
 // Restriction rules for strings
 type StringRule struct {
 	*Object
@@ -118,7 +175,7 @@ type StringRule struct {
 	Enum []string `json:"enum"`
 	// This is a string that the value must match
 	Equal *String `json:"equal"`
-	// This restricts the value to one of several built-in formats
+	// This restricts the value to one of several built-in formats (date-time, email, hostname, ipv4, ipv6, uri) or a format added with system.RegisterFormat
 	Format *String `json:"format"`
 	// The editor should render as a multi-line textbox
 	Long bool `json:"long"`
@@ -144,6 +201,20 @@ type TypeRule struct {
 	*Rule
 }
 
+// Restriction rules for unique values. The values are checked when the package is validated.
+type UniqueRule struct {
+	*Object
+	*Rule
+	// The values must be unique in this scope: package - across all the globals in the package, type - across the globals of the same type, parent - across the items of the collection that holds them.
+	Scope *String `kego:"{\"default\":{\"value\":\"package\"}}" json:"scope"`
+}
+
+// Automatically created basic rule for wrap-value
+type WrapValueRule struct {
+	*Object
+	*Rule
+}
+
 // This is the native json array data type
 type Array struct {
 	*Object
@@ -166,6 +237,34 @@ func (o *Bool) GetBool(ctx context.Context) *Bool {
 	return o
 }
 
+// Changes the type of objects of one type to another, e.g. when a type is renamed or moved to another package.
+type ChangeTypeReference struct {
+	*Object
+	// The old type.
+	From *Reference `json:"from"`
+	// The new type.
+	To *Reference `json:"to"`
+}
+type ChangeTypeReferenceInterface interface {
+	GetChangeTypeReference(ctx context.Context) *ChangeTypeReference
+}
+
+func (o *ChangeTypeReference) GetChangeTypeReference(ctx context.Context) *ChangeTypeReference {
+	return o
+}
+
+// Condition is a rule that checks other values in an object when a condition is met. Put it in the rules of a type. The selectors in the rule are relative to the object that the rule is attached to, and their expressions can refer to the fields of the node with x.field - e.g. :root:expr(x.end > x.start).
+type Condition struct {
+	*Object
+}
+type ConditionInterface interface {
+	GetCondition(ctx context.Context) *Condition
+}
+
+func (o *Condition) GetCondition(ctx context.Context) *Condition {
+	return o
+}
+
 type IntInterface interface {
 	GetInt(ctx context.Context) *Int
 }
@@ -174,6 +273,20 @@ func (o *Int) GetInt(ctx context.Context) *Int {
 	return o
 }
 
+// Lint config changes the checks run by ke lint in this package. A node can suppress the checks for itself and its descendants with the lint-ignore tag, or a single check with lint-ignore:<check>.
+type LintConfig struct {
+	*Object
+	// The severity of each check, keyed by check id. Checks that are set to off aren't run.
+	Checks map[string]*String `json:"checks"`
+}
+type LintConfigInterface interface {
+	GetLintConfig(ctx context.Context) *LintConfig
+}
+
+func (o *LintConfig) GetLintConfig(ctx context.Context) *LintConfig {
+	return o
+}
+
 // This is the native json object data type.
 type Map struct {
 	*Object
@@ -186,6 +299,40 @@ func (o *Map) GetMap(ctx context.Context) *Map {
 	return o
 }
 
+// A migration changes the data in a package to match changes to its types. Migrations are applied by ke migrate in order of id.
+type Migration struct {
+	*Object
+	// This is set by ke migrate when the migration has been applied, so it isn't applied again.
+	Applied bool `json:"applied"`
+	// The operations are applied to every global in the package, in order.
+	Operations []Operation `json:"operations"`
+}
+type MigrationInterface interface {
+	GetMigration(ctx context.Context) *Migration
+}
+
+func (o *Migration) GetMigration(ctx context.Context) *Migration {
+	return o
+}
+
+// Moves a value in objects of the target type. The from and to paths are field names separated by "/", and objects are created as needed along the to path.
+type MoveField struct {
+	*Object
+	// The path of the value to move.
+	From string `json:"from"`
+	// The type of the objects to change.
+	Target *Reference `json:"target"`
+	// The new path of the value.
+	To string `json:"to"`
+}
+type MoveFieldInterface interface {
+	GetMoveField(ctx context.Context) *MoveField
+}
+
+func (o *MoveField) GetMoveField(ctx context.Context) *MoveField {
+	return o
+}
+
 // This is the native json number data type
 type Number float64
 type NumberInterface interface {
@@ -222,6 +369,10 @@ type Package struct {
 	*Object
 	// Map of import aliases used in this package: key = alias, value = package path.
 	Aliases map[string]string `json:"aliases"`
+	// Glob patterns of files and directories, relative to the package dir, that aren't data files. The patterns are the same as in a .keignore file.
+	Exclude []string `json:"exclude"`
+	// Glob patterns of the data files, relative to the package dir. If set, files that don't match (and aren't in a matching directory) are skipped.
+	Include []string `json:"include"`
 	// Should we scan subdirectories for data files?
 	Recursive bool `json:"recursive"`
 }
@@ -241,10 +392,30 @@ func (o *Reference) GetReference(ctx context.Context) *Reference {
 	return o
 }
 
+// Renames a field in objects of the target type.
+type RenameField struct {
+	*Object
+	// The old name of the field.
+	From string `json:"from"`
+	// The type of the objects to change.
+	Target *Reference `json:"target"`
+	// The new name of the field.
+	To string `json:"to"`
+}
+type RenameFieldInterface interface {
+	GetRenameField(ctx context.Context) *RenameField
+}
+
+func (o *RenameField) GetRenameField(ctx context.Context) *RenameField {
+	return o
+}
+
 // All rules will have this embedded in them.
 type Rule struct {
 	// Use the single method getter interface for this type
 	Interface bool `json:"interface"`
+	// If this rule is an interface or object field, the value must be one of these types
+	OneOf []*Reference `json:"one-of"`
 	// If this rule is a field, this specifies that the field is optional
 	Optional bool `json:"optional"`
 	// Json selector defining what nodes this rule should be applied to.
@@ -258,6 +429,24 @@ func (o *Rule) GetRule(ctx context.Context) *Rule {
 	return o
 }
 
+// Sets a field in objects of the target type, if it is missing.
+type SetDefault struct {
+	*Object
+	// The field to set.
+	Field string `json:"field"`
+	// The type of the objects to change.
+	Target *Reference `json:"target"`
+	// The value of the field, as json.
+	Value string `json:"value"`
+}
+type SetDefaultInterface interface {
+	GetSetDefault(ctx context.Context) *SetDefault
+}
+
+func (o *SetDefault) GetSetDefault(ctx context.Context) *SetDefault {
+	return o
+}
+
 // This is the native json string data type
 type String string
 type StringInterface interface {
@@ -293,6 +482,8 @@ type Type struct {
 	Fields map[string]RuleInterface `json:"fields"`
 	// Is this type an interface?
 	Interface bool `json:"interface"`
+	// Enum types have native string and a list of members, keyed by value, with the description of each member. The generated Go type has a constant for each member.
+	Members map[string]string `json:"members"`
 	// This is the native json type that represents this type. If omitted, default is object.
 	Native *String `kego:"{\"default\":{\"value\":\"object\"}}" json:"native"`
 	// Type that defines restriction rules for this type.
@@ -305,18 +496,60 @@ type TypeInterface interface {
 func (o *Type) GetType(ctx context.Context) *Type {
 	return o
 }
+
+// Unique is a rule that the values matched by its selector must be unique. Put it in the rules of a type, or in the rules of the package to apply it to every global in the package.
+type Unique struct {
+	*Object
+}
+type UniqueInterface interface {
+	GetUnique(ctx context.Context) *Unique
+}
+
+func (o *Unique) GetUnique(ctx context.Context) *Unique {
+	return o
+}
+
+// Wraps the value of a field in objects of the target type in a new object of the wrapper type.
+type WrapValue struct {
+	*Object
+	// The field to wrap.
+	Field string `json:"field"`
+	// The field in the new object that holds the old value.
+	Key string `json:"key"`
+	// The type of the objects to change.
+	Target *Reference `json:"target"`
+	// The type of the new object.
+	Wrapper *Reference `json:"wrapper"`
+}
+type WrapValueInterface interface {
+	GetWrapValue(ctx context.Context) *WrapValue
+}
+
+func (o *WrapValue) GetWrapValue(ctx context.Context) *WrapValue {
+	return o
+}
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/system", 10967192916392524561)
+	pkg := jsonctx.InitPackage("kego.io/system", 17147125178276652795)
 	pkg.InitType("array", nil, reflect.TypeOf((*ArrayRule)(nil)), nil)
 	pkg.InitType("bool", reflect.TypeOf((*Bool)(nil)), reflect.TypeOf((*BoolRule)(nil)), reflect.TypeOf((*BoolInterface)(nil)).Elem())
+	pkg.InitType("change-type-reference", reflect.TypeOf((*ChangeTypeReference)(nil)), reflect.TypeOf((*ChangeTypeReferenceRule)(nil)), reflect.TypeOf((*ChangeTypeReferenceInterface)(nil)).Elem())
+	pkg.InitType("condition", reflect.TypeOf((*Condition)(nil)), reflect.TypeOf((*ConditionRule)(nil)), reflect.TypeOf((*ConditionInterface)(nil)).Elem())
 	pkg.InitType("int", reflect.TypeOf((*Int)(nil)), reflect.TypeOf((*IntRule)(nil)), reflect.TypeOf((*IntInterface)(nil)).Elem())
+	pkg.InitType("lint-config", reflect.TypeOf((*LintConfig)(nil)), reflect.TypeOf((*LintConfigRule)(nil)), reflect.TypeOf((*LintConfigInterface)(nil)).Elem())
 	pkg.InitType("map", nil, reflect.TypeOf((*MapRule)(nil)), nil)
+	pkg.InitType("migration", reflect.TypeOf((*Migration)(nil)), reflect.TypeOf((*MigrationRule)(nil)), reflect.TypeOf((*MigrationInterface)(nil)).Elem())
+	pkg.InitType("move-field", reflect.TypeOf((*MoveField)(nil)), reflect.TypeOf((*MoveFieldRule)(nil)), reflect.TypeOf((*MoveFieldInterface)(nil)).Elem())
 	pkg.InitType("number", reflect.TypeOf((*Number)(nil)), reflect.TypeOf((*NumberRule)(nil)), reflect.TypeOf((*NumberInterface)(nil)).Elem())
 	pkg.InitType("object", reflect.TypeOf((*Object)(nil)), reflect.TypeOf((*ObjectRule)(nil)), reflect.TypeOf((*ObjectInterface)(nil)).Elem())
+	pkg.InitType("operation", reflect.TypeOf((*Operation)(nil)).Elem(), reflect.TypeOf((*OperationRule)(nil)), nil)
 	pkg.InitType("package", reflect.TypeOf((*Package)(nil)), reflect.TypeOf((*PackageRule)(nil)), reflect.TypeOf((*PackageInterface)(nil)).Elem())
 	pkg.InitType("reference", reflect.TypeOf((*Reference)(nil)), reflect.TypeOf((*ReferenceRule)(nil)), reflect.TypeOf((*ReferenceInterface)(nil)).Elem())
+	pkg.InitType("rename-field", reflect.TypeOf((*RenameField)(nil)), reflect.TypeOf((*RenameFieldRule)(nil)), reflect.TypeOf((*RenameFieldInterface)(nil)).Elem())
 	pkg.InitType("rule", reflect.TypeOf((*Rule)(nil)), reflect.TypeOf((*RuleRule)(nil)), reflect.TypeOf((*RuleInterface)(nil)).Elem())
+	pkg.InitType("set-default", reflect.TypeOf((*SetDefault)(nil)), reflect.TypeOf((*SetDefaultRule)(nil)), reflect.TypeOf((*SetDefaultInterface)(nil)).Elem())
 	pkg.InitType("string", reflect.TypeOf((*String)(nil)), reflect.TypeOf((*StringRule)(nil)), reflect.TypeOf((*StringInterface)(nil)).Elem())
 	pkg.InitType("tags", reflect.TypeOf((*Tags)(nil)), reflect.TypeOf((*TagsRule)(nil)), reflect.TypeOf((*TagsInterface)(nil)).Elem())
 	pkg.InitType("type", reflect.TypeOf((*Type)(nil)), reflect.TypeOf((*TypeRule)(nil)), reflect.TypeOf((*TypeInterface)(nil)).Elem())
+	pkg.InitType("unique", reflect.TypeOf((*Unique)(nil)), reflect.TypeOf((*UniqueRule)(nil)), reflect.TypeOf((*UniqueInterface)(nil)).Elem())
+	pkg.InitType("wrap-value", reflect.TypeOf((*WrapValue)(nil)), reflect.TypeOf((*WrapValueRule)(nil)), reflect.TypeOf((*WrapValueInterface)(nil)).Elem())
 }
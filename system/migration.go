@@ -0,0 +1,30 @@
+package system
+
+import "fmt"
+
+// Operation is a change made to the data in a package by a migration. The operations are
+// applied by the migrate package, which works on the raw data because the data won't unmarshal
+// into the types until it has been migrated.
+type Operation interface {
+	Summary() string
+}
+
+func (o *RenameField) Summary() string {
+	return fmt.Sprintf("rename-field %s: %s to %s", o.Target, o.From, o.To)
+}
+
+func (o *MoveField) Summary() string {
+	return fmt.Sprintf("move-field %s: %s to %s", o.Target, o.From, o.To)
+}
+
+func (o *WrapValue) Summary() string {
+	return fmt.Sprintf("wrap-value %s: %s in %s", o.Target, o.Field, o.Wrapper)
+}
+
+func (o *SetDefault) Summary() string {
+	return fmt.Sprintf("set-default %s: %s to %s", o.Target, o.Field, o.Value)
+}
+
+func (o *ChangeTypeReference) Summary() string {
+	return fmt.Sprintf("change-type-reference %s to %s", o.From, o.To)
+}
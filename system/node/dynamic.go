@@ -0,0 +1,37 @@
+package node
+
+import (
+	"context"
+
+	"kego.io/system"
+)
+
+// DynamicObject is the value of a dynamic node with an object type. The type has no generated Go
+// type, so the fields are read from the children of the node.
+type DynamicObject struct {
+	Node *Node
+}
+
+// GetObject returns the system:object fields of the node, so the rules in the data are applied in
+// the same way as for a generated type.
+func (d *DynamicObject) GetObject(ctx context.Context) *system.Object {
+	ob := &system.Object{}
+	if c, ok := d.Node.Map["description"]; ok && !c.Null {
+		ob.Description = c.ValueString
+	}
+	if c, ok := d.Node.Map["id"]; ok {
+		ob.Id, _ = c.Value.(*system.Reference)
+	}
+	if c, ok := d.Node.Map["type"]; ok {
+		ob.Type, _ = c.Value.(*system.Reference)
+	}
+	if c, ok := d.Node.Map["rules"]; ok {
+		ob.Rules, _ = c.Value.([]system.RuleInterface)
+	}
+	if c, ok := d.Node.Map["tags"]; ok {
+		ob.Tags, _ = c.Value.([]string)
+	}
+	return ob
+}
+
+var _ system.ObjectInterface = (*DynamicObject)(nil)
@@ -35,17 +35,40 @@ type Node struct {
 	Rule        *system.RuleWrapper
 	Type        *system.Type
 	JsonType    json.Type
+	Position    json.Position // the location of the value in the source file, if known
+	Dynamic     bool          // Dynamic is true if the type has no generated Go type, so Value is built from the child nodes
 	hash        uint64
 }
 
 func Unmarshal(ctx context.Context, data []byte) (*Node, error) {
-	n := NewNode()
-	if err := ke.UnmarshalUntyped(ctx, data, n); err != nil {
+	n, err := UnmarshalPositions(ctx, data, nil)
+	if err != nil {
 		return nil, kerr.Wrap("QDWFKJOJPQ", err)
 	}
 	return n, nil
 }
 
+// UnmarshalPositions is like Unmarshal, but the source positions of the values are read from
+// positions. This is used when data has been converted from another format (e.g. yaml), so the
+// positions in data don't match the source file. If positions is nil, they are scanned from data.
+func UnmarshalPositions(ctx context.Context, data []byte, positions *json.Positions) (*Node, error) {
+	if positions == nil {
+		var err error
+		if positions, err = json.ScanPositions("", data); err != nil {
+			return nil, kerr.Wrap("ZHDKBSBJLF", err)
+		}
+	}
+	var i interface{}
+	if err := ke.UnmarshalUntyped(ctx, data, &i); err != nil {
+		return nil, kerr.Wrap("BOBZAAAUAW", err)
+	}
+	n := NewNode()
+	if err := n.Unpack(ctx, json.PackPositions(i, positions)); err != nil {
+		return nil, kerr.Wrap("VQFUIXKZAO", err)
+	}
+	return n, nil
+}
+
 func NewNode() *Node {
 	n := &Node{Index: -1}
 	return n
@@ -353,10 +376,18 @@ func (n *Node) resetAllValues() {
 	n.Rule = nil
 	n.Type = nil
 	n.JsonType = json.J_NULL
+	n.Dynamic = false
 }
 
 func (n *Node) initialiseValFromParent() {
 
+	if n.Parent.Dynamic {
+		// A dynamic parent has no Go value, so the child unpacks its own value in setValue.
+		n.Val = reflect.Value{}
+		n.Value = nil
+		return
+	}
+
 	v := n.Parent.Val
 	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
 		v = v.Elem()
@@ -370,6 +401,12 @@ func (n *Node) initialiseValFromParent() {
 	case json.J_ARRAY:
 		n.Val = v.Index(n.Index)
 	}
+	if !n.Val.IsValid() {
+		// The parent Go value has no field for this node (e.g. it's a system.DummyRule), so the
+		// child unpacks its own value in setValue.
+		n.Value = nil
+		return
+	}
 	n.Value = n.Val.Interface()
 }
 
@@ -384,9 +421,13 @@ func (n *Node) setValue(ctx context.Context, in json.Packed, unpack bool) error
 
 	n.Missing = false
 
+	if p, ok := in.(json.Positioned); ok && !p.Position().IsZero() {
+		n.Position = p.Position()
+	}
+
 	objectType, err := extractType(ctx, in, n.Rule)
 	if err != nil {
-		return kerr.Wrap("MKMNOOYQJY", err)
+		return n.positionError("MKMNOOYQJY", err)
 	}
 	n.Type = objectType
 
@@ -408,10 +449,15 @@ func (n *Node) setValue(ctx context.Context, in json.Packed, unpack bool) error
 	//		return kerr.New("VEPLUIJXSN", "json type is %s but object type is %s", n.JsonType, n.Type.NativeJsonType())
 	//	}
 
-	if unpack {
-		if n.Rule.Struct == nil {
+	// If the parent has no Go value for this node, we unpack it here in the same way as the root.
+	if unpack || !n.Val.IsValid() {
+		if !n.hasGoType(ctx) {
+			// The type has no generated Go type (e.g. the package hasn't been compiled into this
+			// binary), so the value is built from the child nodes below.
+			n.Dynamic = !n.Null
+		} else if n.Rule.Struct == nil {
 			if err := json.Unpack(ctx, in, &n.Value); err != nil {
-				return kerr.Wrap("CQMWGPLYIJ", err)
+				return n.positionError("CQMWGPLYIJ", err)
 			}
 		} else {
 			t, err := n.Rule.GetReflectType()
@@ -419,10 +465,12 @@ func (n *Node) setValue(ctx context.Context, in json.Packed, unpack bool) error
 				return kerr.Wrap("DQJDYPIANO", err)
 			}
 			if err := json.UnpackFragment(ctx, in, &n.Value, t); err != nil {
-				return kerr.Wrap("PEVKGFFHLL", err)
+				return n.positionError("PEVKGFFHLL", err)
 			}
 		}
-		n.setVal(reflect.ValueOf(n.Value))
+		if !n.Dynamic {
+			n.setVal(reflect.ValueOf(n.Value))
+		}
 	}
 
 	switch n.Type.NativeJsonType() {
@@ -479,9 +527,74 @@ func (n *Node) setValue(ctx context.Context, in json.Packed, unpack bool) error
 		}
 	}
 
+	if n.Dynamic {
+		n.setDynamicValue()
+	}
+
 	return nil
 }
 
+// hasGoType is false if the type of the node, or the items of a collection, have no generated Go
+// type in the json ctx.
+func (n *Node) hasGoType(ctx context.Context) bool {
+	if n.Type != nil && !n.Type.IsNativeCollection() {
+		if _, ok := n.Type.Id.GetReflectType(ctx); !ok {
+			return false
+		}
+	}
+	return n.Rule.HasReflectType()
+}
+
+// setDynamicValue builds the value of a dynamic node from its children. Objects are represented
+// by a DynamicObject that holds the node. Collections are represented by a
+// slice or map of the child values, and native values by the system native types, so the
+// built-in rules can be enforced.
+func (n *Node) setDynamicValue() {
+	var v interface{}
+	switch n.Type.NativeJsonType() {
+	case json.J_STRING:
+		v = system.NewString(n.ValueString)
+	case json.J_NUMBER:
+		v = system.NewNumber(n.ValueNumber)
+	case json.J_BOOL:
+		v = system.NewBool(n.ValueBool)
+	case json.J_ARRAY:
+		a := make([]interface{}, len(n.Array))
+		for i, child := range n.Array {
+			a[i] = child.Value
+		}
+		v = a
+	case json.J_MAP:
+		m := make(map[string]interface{}, len(n.Map))
+		for key, child := range n.Map {
+			m[key] = child.Value
+		}
+		v = m
+	default:
+		v = &DynamicObject{Node: n}
+	}
+	n.Value = v
+	n.setVal(reflect.ValueOf(v))
+}
+
+// positionError adds the source position of the node to an error, so the value can be found in
+// the file. If the position isn't known, the error is wrapped with id.
+func (n *Node) positionError(id string, err error) error {
+	if _, ok := json.AsPositionError(err); ok {
+		// The error already has a more accurate position, so we keep the original error.
+		return kerr.Wrap(id, err)
+	}
+	switch kerr.Source(err).(type) {
+	case json.UnknownPackageError, json.UnknownTypeError:
+		// Callers might want to tolerate these errors, so we keep the original error.
+		return kerr.Wrap(id, err)
+	}
+	if n.Position.IsZero() {
+		return kerr.Wrap(id, err)
+	}
+	return json.NewPositionError(id, n.Position, err)
+}
+
 func (n *Node) SetValueZero(ctx context.Context, null bool, t *system.Type) error {
 	if t != nil {
 		if err := n.setType(t); err != nil {
@@ -591,7 +704,8 @@ func (n *Node) SetIdField(ctx context.Context, id string) error {
 }
 
 func (n *Node) setVal(rv reflect.Value) {
-	if n.Parent == nil {
+	if n.Parent == nil || n.Parent.Dynamic || !n.Val.IsValid() {
+		// The value isn't part of a parent Go value.
 		n.Val = rv
 	} else if n.Parent.Type.IsNativeMap() {
 		n.Parent.Val.SetMapIndex(reflect.ValueOf(n.Key), rv)
@@ -642,10 +756,14 @@ func (n *Node) initialiseFields(ctx context.Context, in json.Packed, updateVal b
 		}
 	}
 
-	for name, _ := range valueFields {
+	for name, valueField := range valueFields {
 		_, ok := typeFields[name]
 		if !ok {
-			return kerr.New("SRANLETJRS", "Extra field %s", name)
+			err := kerr.New("SRANLETJRS", "Extra field %s", name)
+			if p, ok := valueField.(json.Positioned); ok && !p.Position().IsZero() {
+				return json.NewPositionError("UUNVEOGPAW", p.Position(), err)
+			}
+			return err
 		}
 	}
 
@@ -713,7 +831,10 @@ func extractType(ctx context.Context, in json.Packed, rule *system.RuleWrapper)
 	if err := r.Unpack(ctx, typeField); err != nil {
 		return nil, kerr.Wrap("YXHGIBXCOC", err)
 	}
-	t, ok := r.GetType(ctx)
+	t, ok, err := r.GetType(ctx)
+	if err != nil {
+		return nil, kerr.Wrap("XBMRKQHCFT", err)
+	}
 	if !ok {
 		return nil, kerr.New("IJFMJJWVCA", "Could not find type %s", r.Value())
 	}
@@ -724,7 +845,10 @@ func extractType(ctx context.Context, in json.Packed, rule *system.RuleWrapper)
 func extractFields(ctx context.Context, fields map[string]*system.Field, t *system.Type) error {
 	if !t.Basic && !t.Interface {
 		// All types apart from Basic types embed system:object
-		ob, ok := system.GetTypeFromCache(ctx, "kego.io/system", "object")
+		ob, ok, err := system.GetTypeFromCache(ctx, "kego.io/system", "object")
+		if err != nil {
+			return kerr.Wrap("TQOEXAFHYV", err)
+		}
 		if !ok {
 			return kerr.New("YRFWOTIGFT", "Type system:object not found in sys ctx")
 		}
@@ -733,7 +857,10 @@ func extractFields(ctx context.Context, fields map[string]*system.Field, t *syst
 		}
 	}
 	for _, embedRef := range t.Embed {
-		embed, ok := system.GetTypeFromCache(ctx, embedRef.Package, embedRef.Name)
+		embed, ok, err := system.GetTypeFromCache(ctx, embedRef.Package, embedRef.Name)
+		if err != nil {
+			return kerr.Wrap("HDPNYWQSOG", err)
+		}
 		if !ok {
 			return kerr.New("SLIRILCARQ", "Type %s not found in sys ctx", embedRef)
 		}
@@ -879,6 +1006,8 @@ func (n *Node) Backup() *Node {
 		Rule:        n.Rule,
 		Type:        n.Type,
 		JsonType:    n.JsonType,
+		Position:    n.Position,
+		Dynamic:     n.Dynamic,
 	}
 }
 
@@ -899,6 +1028,8 @@ func (n *Node) Restore(ctx context.Context, b *Node) {
 	n.Rule = b.Rule
 	n.Type = b.Type
 	n.JsonType = b.JsonType
+	n.Position = b.Position
+	n.Dynamic = b.Dynamic
 }
 
 func (n *Node) NativeValue() interface{} {
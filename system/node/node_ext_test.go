@@ -152,6 +152,129 @@ func TestUnmarshal(t *testing.T) {
 
 }
 
+func TestUnmarshalDynamic(t *testing.T) {
+
+	cb := tests.New().TempGopath(true)
+	defer cb.Cleanup()
+
+	// The types in this package have no generated Go types, so the nodes are dynamic.
+	path, dir := cb.TempPackage("a", map[string]string{
+		"a.yaml": `
+			type: system:type
+			id: a
+			fields:
+				b:
+					type: system:@string
+				c:
+					type: system:@array
+					items:
+						type: "@d"
+				e:
+					type: "@f"
+				g:
+					type: system:@map
+					items:
+						type: system:@number
+		`,
+		"d.yaml": `
+			type: system:type
+			id: d
+			fields:
+				h:
+					type: system:@string
+		`,
+		"f.yaml": `
+			type: system:type
+			id: f
+			native: string
+		`,
+	})
+
+	cb.Path(path).Dir(dir).Jauto().Sauto(parser.Parse)
+
+	s := `{
+	"type": "a",
+	"id": "i",
+	"b": "j",
+	"c": [{"type": "d", "h": "k"}],
+	"e": "l",
+	"g": {"m": 1}
+}`
+
+	n, err := node.Unmarshal(cb.Ctx(), []byte(s))
+	require.NoError(t, err)
+	assert.True(t, n.Dynamic)
+	d, ok := n.Value.(*node.DynamicObject)
+	require.True(t, ok)
+	assert.True(t, d.Node == n)
+	assert.Equal(t, "i", d.GetObject(nil).Id.Name)
+	assert.Equal(t, "a", d.GetObject(nil).Type.Name)
+
+	assert.False(t, n.Map["b"].Dynamic)
+	assert.Equal(t, system.NewString("j"), n.Map["b"].Value)
+
+	c := n.Map["c"]
+	assert.True(t, c.Dynamic)
+	require.Equal(t, 1, len(c.Array))
+	assert.True(t, c.Array[0].Dynamic)
+	assert.Equal(t, []interface{}{&node.DynamicObject{Node: c.Array[0]}}, c.Value)
+	assert.Equal(t, system.NewString("k"), c.Array[0].Map["h"].Value)
+
+	assert.True(t, n.Map["e"].Dynamic)
+	assert.Equal(t, system.NewString("l"), n.Map["e"].Value)
+
+	assert.False(t, n.Map["g"].Dynamic)
+	assert.Equal(t, map[string]*system.Number{"m": system.NewNumber(1)}, n.Map["g"].Value)
+
+	assert.Equal(t, "i/c/0/h", c.Array[0].Map["h"].Path())
+}
+
+func TestUnmarshalPositions(t *testing.T) {
+
+	cb := tests.Context("kego.io/tests/data").Jauto().Sauto(parser.Parse)
+
+	s := `{
+	"type": "multi",
+	"js": "a",
+	"ajs": ["b", "c"]
+}`
+
+	n, err := node.Unmarshal(cb.Ctx(), []byte(s))
+	require.NoError(t, err)
+	assert.Equal(t, json.Position{Line: 1, Column: 1}, n.Position)
+	assert.Equal(t, json.Position{Line: 3, Column: 8}, n.Map["js"].Position)
+	assert.Equal(t, json.Position{Line: 4, Column: 9}, n.Map["ajs"].Position)
+	assert.Equal(t, json.Position{Line: 4, Column: 15}, n.Map["ajs"].Array[1].Position)
+	assert.True(t, n.Map["jn"].Position.IsZero())
+
+	p := &json.Positions{
+		Position: json.Position{File: "a.yaml", Line: 1, Column: 1},
+		Map: map[string]*json.Positions{
+			"js": {Position: json.Position{File: "a.yaml", Line: 2, Column: 5}},
+		},
+	}
+	n, err = node.UnmarshalPositions(cb.Ctx(), []byte(s), p)
+	require.NoError(t, err)
+	assert.Equal(t, "a.yaml:2:5", n.Map["js"].Position.String())
+	assert.True(t, n.Map["ajs"].Position.IsZero())
+
+	_, err = node.Unmarshal(cb.Ctx(), []byte(`{
+	"type": "multi",
+	"foo": "a"
+}`))
+	assert.HasError(t, err, "UUNVEOGPAW")
+	pe, ok := json.AsPositionError(err)
+	require.True(t, ok)
+	assert.Equal(t, json.Position{Line: 3, Column: 9}, pe.Position)
+
+	_, err = node.Unmarshal(cb.Ctx(), []byte(`{
+	"type": "multi",
+	"js": 
+}`))
+	assert.HasError(t, err, "DNWJYQLKAW")
+
+}
+
 func TestNode_Unpack(t *testing.T) {
 
 	cb := tests.Context("kego.io/tests/data").Jauto().Sauto(parser.Parse)
@@ -615,11 +738,11 @@ func TestNode_SetValueZero(t *testing.T) {
 	cb, n := data.Setup(t)
 	test := func(t *testing.T, n *node.Node, m *data.Multi) {
 
-		sstring, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/system", "string")
+		sstring, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/system", "string")
 		assert.True(t, ok)
-		facea, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "facea")
+		facea, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "facea")
 		assert.True(t, ok)
-		faceb, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "faceb")
+		faceb, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "faceb")
 		assert.True(t, ok)
 
 		c1 := node.NewNode()
@@ -747,9 +870,9 @@ func TestNode_SetValueZero(t *testing.T) {
 func TestNode_SetValueZero2(t *testing.T) {
 	cb, n := data.Empty(t)
 	test := func(t *testing.T, n *node.Node, m *data.Multi) {
-		sstring, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/system", "string")
+		sstring, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/system", "string")
 		assert.True(t, ok)
-		snumber, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/system", "number")
+		snumber, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/system", "number")
 		assert.True(t, ok)
 
 		assert.NoError(t, n.Map["ss"].SetValueZero(cb.Ctx(), true, sstring))
@@ -779,7 +902,7 @@ func TestNode_SetValueZero2(t *testing.T) {
 
 func TestNode_SetValueZero3(t *testing.T) {
 	cb, n := data.Empty(t)
-	f, ok := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "face")
+	f, ok, _ := system.GetTypeFromCache(cb.Ctx(), "kego.io/tests/data", "face")
 	assert.True(t, ok)
 	err := n.SetValueZero(cb.Ctx(), true, f)
 	assert.HasError(t, err, "VHOSYBMDQL")
@@ -3,6 +3,7 @@ package node
 import "kego.io/json"
 
 var _ json.Packed = (*packed)(nil)
+var _ json.Positioned = (*packed)(nil)
 
 func Pack(n *Node) *packed {
 	return &packed{n}
@@ -53,3 +54,9 @@ func (p *packed) Map() map[string]json.Packed {
 func (p *packed) Interface() interface{} {
 	return p.n.Value
 }
+func (p *packed) Position() json.Position {
+	if p == nil || p.n == nil {
+		return json.Position{}
+	}
+	return p.n.Position
+}
@@ -28,7 +28,7 @@ func testUnpack(t *testing.T, path string) {
 
 	env := envctx.FromContext(ctx)
 
-	files := scanner.ScanDirToFiles(ctx, env.Dir, env.Recursive)
+	files := scanner.ScanPackageToFiles(ctx, env)
 	bytes := scanner.ScanFilesToBytes(ctx, files)
 	for b := range bytes {
 		_, err := node.Unmarshal(ctx, b.Bytes)
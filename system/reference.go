@@ -189,15 +189,17 @@ func (r Reference) String() string {
 	return r.Value()
 }
 
-func (r Reference) GetType(ctx context.Context) (*Type, bool) {
+// GetType returns the type that the reference refers to. If the type was found and failed to
+// load, the error is returned.
+func (r Reference) GetType(ctx context.Context) (t *Type, found bool, err error) {
 	if r.Package == "" || r.Name == "" {
-		return nil, false
+		return nil, false, nil
 	}
-	t, ok := GetTypeFromCache(ctx, r.Package, r.Name)
-	if !ok {
-		return nil, false
+	t, found, err = GetTypeFromCache(ctx, r.Package, r.Name)
+	if err != nil {
+		return nil, true, kerr.Wrap("LPHWGTXCEO", err)
 	}
-	return t, true
+	return t, found, nil
 }
 
 func (r Reference) GetReflectType(ctx context.Context) (reflect.Type, bool) {
@@ -1,6 +1,7 @@
 package system
 
 import (
+	"errors"
 	"reflect"
 	"sort"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/davelondon/ktest/assert"
 	"github.com/davelondon/ktest/require"
 	"kego.io/context/envctx"
+	"kego.io/context/sysctx"
 	"kego.io/json"
 	"kego.io/tests"
 	"kego.io/tests/unpacker"
@@ -254,17 +256,26 @@ func TestReferenceGetType(t *testing.T) {
 	ctx := tests.Context("a.b/c").Stype("d", ty).Ctx()
 
 	r := NewReference("a.b/c", "d")
-	typ, ok := r.GetType(ctx)
+	typ, ok, _ := r.GetType(ctx)
 	assert.True(t, ok)
 	assert.Equal(t, "a.b/c:d", typ.Id.Value())
 
 	r = NewReference("a.b/c", "e")
-	_, ok = r.GetType(ctx)
+	_, ok, _ = r.GetType(ctx)
 	assert.False(t, ok)
 
 	r = &Reference{}
-	_, ok = r.GetType(ctx)
+	_, ok, _ = r.GetType(ctx)
 	assert.False(t, ok)
+
+	// A type that fails to load is found, and the error is returned.
+	pi, _ := sysctx.FromContext(ctx).Get("a.b/c")
+	pi.Types.SetLazy("f", "f.json", func() (interface{}, error) { return nil, errors.New("g") })
+	r = NewReference("a.b/c", "f")
+	_, ok, err := r.GetType(ctx)
+	assert.True(t, ok)
+	assert.IsError(t, err, "LPHWGTXCEO")
+	assert.HasError(t, err, "VKEAJQBNSR")
 }
 
 func TestReferenceValue(t *testing.T) {
@@ -51,11 +51,44 @@ type RuleWrapper struct {
 	Parent    *Type
 }
 
-func (r *RuleWrapper) PermittedTypes() []*Type {
+// PermittedTypes returns the types that the value of the rule can have. If the rule lists its types
+// in one-of, only those are returned.
+func (r *RuleWrapper) PermittedTypes() ([]*Type, error) {
+	if r.Struct != nil && len(r.Struct.OneOf) > 0 {
+		out := []*Type{}
+		for _, ref := range r.Struct.OneOf {
+			t, ok, err := ref.GetType(r.Ctx)
+			if err != nil {
+				return nil, kerr.Wrap("GUJXNCWHPB", err)
+			}
+			if ok {
+				out = append(out, t)
+			}
+		}
+		return out, nil
+	}
 	if !r.Parent.Interface && !r.Struct.Interface {
-		return []*Type{r.Parent}
+		return []*Type{r.Parent}, nil
+	}
+	types, err := GetAllTypesThatImplementInterface(r.Ctx, r.Parent)
+	if err != nil {
+		return nil, kerr.Wrap("EQTBDKWVLA", err)
 	}
-	return GetAllTypesThatImplementInterface(r.Ctx, r.Parent)
+	return types, nil
+}
+
+// PermitsType is true if the rule has no one-of list, or the type is in it. The one-of list of a
+// collection rule is applied to the items by RuleWrapper.ItemsRule.
+func (r *Rule) PermitsType(typ Reference) bool {
+	if r == nil || len(r.OneOf) == 0 {
+		return true
+	}
+	for _, ref := range r.OneOf {
+		if ref != nil && ref.Value() == typ.Value() {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *RuleWrapper) ZeroValue(null bool) (reflect.Value, error) {
@@ -100,6 +133,28 @@ func (r *RuleWrapper) GetReflectType() (reflect.Type, error) {
 
 }
 
+// HasReflectType is false if GetReflectType would fail because a type has no generated Go type
+// in the json ctx (e.g. the package hasn't been compiled into this binary).
+func (r *RuleWrapper) HasReflectType() bool {
+
+	if r.Struct != nil && r.Struct.Interface {
+		_, ok := r.Parent.Id.GetReflectInterface(r.Ctx)
+		return ok
+	}
+
+	if c, ok := r.Interface.(CollectionRule); ok && c.GetItemsRule() != nil {
+		items, err := WrapRule(r.Ctx, c.GetItemsRule())
+		if err != nil {
+			// GetReflectType will return this error
+			return true
+		}
+		return items.HasReflectType()
+	}
+
+	_, ok := r.Parent.Id.GetReflectType(r.Ctx)
+	return ok
+}
+
 func WrapEmptyRule(ctx context.Context, t *Type) *RuleWrapper {
 	return &RuleWrapper{Ctx: ctx, Interface: nil, Parent: t}
 }
@@ -112,7 +167,10 @@ func WrapRule(ctx context.Context, r RuleInterface) (*RuleWrapper, error) {
 
 	// ob.GetObject(nil).Type will be a @ rule type, so we change to the type,
 	// which will be the parent
-	t, ok := ob.GetObject(nil).Type.ChangeToType().GetType(ctx)
+	t, ok, err := ob.GetObject(nil).Type.ChangeToType().GetType(ctx)
+	if err != nil {
+		return nil, kerr.Wrap("NWDPTJFYRA", err)
+	}
 	if !ok {
 		return nil, kerr.New("KYCTDXKFYR", "GetType: type %v not found", ob.GetObject(nil).Type.ChangeToType().Value())
 	}
@@ -157,6 +215,25 @@ func (r *RuleWrapper) ItemsRule() (*RuleWrapper, error) {
 	if err != nil {
 		return nil, kerr.Wrap("SDSMCXSWOF", err)
 	}
+	// The one-of list of a collection rule restricts the types of the items, so the items are
+	// restricted to the types in the lists of both rules. Struct is copied, so the items rule
+	// itself is unchanged.
+	if r.Struct != nil && len(r.Struct.OneOf) > 0 {
+		s := Rule{}
+		if w.Struct != nil {
+			s = *w.Struct
+		}
+		s.OneOf = nil
+		for _, ref := range r.Struct.OneOf {
+			if ref != nil && w.Struct.PermitsType(*ref) {
+				s.OneOf = append(s.OneOf, ref)
+			}
+		}
+		if len(s.OneOf) == 0 {
+			return nil, kerr.New("JQXWAFTKEN", "The one-of lists of %s and its items have no types in common", r.Parent.Id.Value())
+		}
+		w.Struct = &s
+	}
 	return w, nil
 }
 
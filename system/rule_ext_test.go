@@ -62,7 +62,7 @@ func TestRuleWrapper_ZeroValue(t *testing.T) {
 }
 
 func TestReflectType(t *testing.T) {
-	ctx, _, err := process.Initialise(context.Background(), process.Options{Path: "kego.io/system"})
+	ctx, _, err := process.Initialise(context.Background(), &process.Options{Path: "kego.io/system"})
 	assert.NoError(t, err)
 	checkReflectType(ctx, t, "kego.io/system", "type", "basic", "bool")
 	checkReflectType(ctx, t, "kego.io/system", "type", "embed", "[]*system.Reference")
@@ -122,6 +122,25 @@ func TestRuleWrapperItemsRule1(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, rw.Interface, itemsRule)
 
+	// The one-of list of the collection applies to the items
+	a, b, c := NewReference("a.b/c", "a"), NewReference("a.b/c", "b"), NewReference("a.b/c", "c")
+	barRule.OneOf = []*Reference{a, b}
+	rw, err = bar.ItemsRule()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Reference{a, b}, rw.Struct.OneOf)
+	assert.Nil(t, itemsRule.OneOf)
+
+	// When both rules have a list, only the types in both are permitted
+	itemsRule.OneOf = []*Reference{b, c}
+	rw, err = bar.ItemsRule()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Reference{b}, rw.Struct.OneOf)
+	assert.Equal(t, []*Reference{b, c}, itemsRule.OneOf)
+
+	itemsRule.OneOf = []*Reference{c}
+	_, err = bar.ItemsRule()
+	assert.IsError(t, err, "JQXWAFTKEN")
+
 }
 
 type fooRuleStruct struct {
@@ -227,6 +246,58 @@ func TestRuleGetReflectType(t *testing.T) {
 
 }
 
+func TestRuleHasReflectType(t *testing.T) {
+
+	cb := tests.Context("a.b/c").Jempty()
+
+	fooType := &Type{Object: &Object{Id: NewReference("a.b/c", "foo")}, Native: NewString("object")}
+	fooRule := &fooRuleStruct{Rule: &Rule{}}
+
+	foo := RuleWrapper{
+		Ctx:       cb.Ctx(),
+		Interface: fooRule,
+		Struct:    fooRule.Rule,
+		Parent:    fooType,
+	}
+
+	assert.False(t, foo.HasReflectType())
+
+	fooRule.Interface = true
+	assert.False(t, foo.HasReflectType())
+
+	cb.Jiface("foo", reflect.TypeOf((*fooIface)(nil)).Elem())
+	assert.True(t, foo.HasReflectType())
+
+	fooRule.Interface = false
+	cb.Jtype("foo", reflect.TypeOf(fooStruct{}))
+	assert.True(t, foo.HasReflectType())
+
+	barType := &Type{Object: &Object{Id: NewReference("a.b/c", "bar")}, Native: NewString("map")}
+	barRule := &barRuleStruct{
+		Object: &Object{},
+		Rule:   &Rule{},
+		Items:  &fooRuleStruct{Rule: &Rule{}, Object: &Object{Type: NewReference("a.b/c", "@foo")}},
+	}
+
+	bar := RuleWrapper{
+		Ctx:       tests.Context("a.b/c").Jempty().Stype("foo", fooType).Ctx(),
+		Interface: barRule,
+		Struct:    barRule.Rule,
+		Parent:    barType,
+	}
+
+	// The items type isn't registered
+	assert.False(t, bar.HasReflectType())
+
+	bar.Ctx = cb.Stype("foo", fooType).Ctx()
+	assert.True(t, bar.HasReflectType())
+
+	// WrapRule fails, so GetReflectType returns the error
+	bar.Ctx = tests.Context("a.b/c").Jempty().Sempty().Ctx()
+	assert.True(t, bar.HasReflectType())
+
+}
+
 func TestRuleGetDefault(t *testing.T) {
 	d := DummyRule{Default: "a"}
 	assert.Equal(t, "a", d.GetDefault())
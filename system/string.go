@@ -41,6 +41,12 @@ func (r *StringRule) Validate(ctx context.Context) (fail bool, messages []string
 			messages = append(messages, fmt.Sprintf("PatternNot: regex does not compile: %s", r.PatternNot.Value()))
 		}
 	}
+	if r.Format != nil {
+		if _, ok := GetFormat(r.Format.Value()); !ok {
+			fail = true
+			messages = append(messages, fmt.Sprintf("Format: unknown format %s, must be one of: %v", strconv.Quote(r.Format.Value()), FormatNames()))
+		}
+	}
 	return
 }
 
@@ -57,10 +63,23 @@ func (r *StringRule) Enforce(ctx context.Context, data interface{}) (fail bool,
 		return true, nil, kerr.New("SXFBXGQSEA", "String rule: value %T should be *system.String", data)
 	}
 
-	// TODO: This restricts the value to one of several built-in formats
-	// TODO: Format String
+	// This restricts the value to one of several built-in or registered formats
+	// Format String
 	if r.Format != nil {
-		panic("TODO: Format rule implementation")
+		if s == nil && !r.Optional {
+			fail = true
+			messages = append(messages, "Format: value must exist")
+		}
+		if s != nil {
+			f, ok := GetFormat(r.Format.Value())
+			if !ok {
+				fail = true
+				messages = append(messages, fmt.Sprintf("Format: unknown format %s", strconv.Quote(r.Format.Value())))
+			} else if !f(s.Value()) {
+				fail = true
+				messages = append(messages, fmt.Sprintf("Format: value %s must be a valid %s", strconv.Quote(truncate(s.Value(), 20)), r.Format.Value()))
+			}
+		}
 	}
 
 	// This is a regex to match the value to
@@ -276,6 +276,18 @@ func TestStringRule_Validate(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, fail)
 	assert.Equal(t, "MaxLength 4 must not be less than MinLength 5", messages[0])
+
+	r = &StringRule{Format: NewString("uri")}
+	fail, messages, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+	assert.Equal(t, 0, len(messages))
+
+	r = &StringRule{Format: NewString("foo")}
+	fail, messages, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Contains(t, messages[0], "Format: unknown format \"foo\", must be one of: [")
 }
 
 func TestStringRule_Enforce(t *testing.T) {
@@ -390,4 +402,32 @@ func TestStringRule_Enforce(t *testing.T) {
 	assert.Equal(t, 0, len(messages))
 	assert.False(t, fail)
 
+	r = StringRule{Rule: &Rule{Optional: false}, Format: NewString("email")}
+	fail, messages, err = r.Enforce(envctx.Empty, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Format: value must exist", messages[0])
+	assert.True(t, fail)
+
+	fail, messages, err = r.Enforce(envctx.Empty, NewString("foo@bar.com"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(messages))
+	assert.False(t, fail)
+
+	fail, messages, err = r.Enforce(envctx.Empty, NewString("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "Format: value \"foo\" must be a valid email", messages[0])
+	assert.True(t, fail)
+
+	r = StringRule{Rule: &Rule{Optional: true}, Format: NewString("email")}
+	fail, messages, err = r.Enforce(envctx.Empty, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(messages))
+	assert.False(t, fail)
+
+	r = StringRule{Rule: &Rule{Optional: false}, Format: NewString("foo")}
+	fail, messages, err = r.Enforce(envctx.Empty, NewString("a"))
+	require.NoError(t, err)
+	assert.Equal(t, "Format: unknown format \"foo\"", messages[0])
+	assert.True(t, fail)
+
 }
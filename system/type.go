@@ -12,28 +12,32 @@ import (
 	"kego.io/json"
 )
 
-func GetAllTypesThatImplementInterface(ctx context.Context, typ *Type) []*Type {
+func GetAllTypesThatImplementInterface(ctx context.Context, typ *Type) ([]*Type, error) {
 	var reflectType reflect.Type
 	if typ.Interface {
 		// The type provided is an interface type
 		rt, ok := typ.Id.GetReflectType(ctx)
 		if !ok {
-			return nil
+			return nil, nil
 		}
 		reflectType = rt
 	} else {
 		// The type provided is not an interface type, so we get it's automatic generated interface
 		rt, ok := typ.Id.GetReflectInterface(ctx)
 		if !ok {
-			return nil
+			return nil, nil
 		}
 		reflectType = rt
 	}
 
-	return GetAllTypesThatImplementReflectInterface(ctx, reflectType)
+	types, err := GetAllTypesThatImplementReflectInterface(ctx, reflectType)
+	if err != nil {
+		return nil, kerr.Wrap("OYKDBWMRTE", err)
+	}
+	return types, nil
 }
 
-func GetAllTypesThatImplementReflectInterface(ctx context.Context, reflectType reflect.Type) []*Type {
+func GetAllTypesThatImplementReflectInterface(ctx context.Context, reflectType reflect.Type) ([]*Type, error) {
 
 	if reflectType.Kind() != reflect.Interface {
 		panic(kerr.New("JUCCMVNDLR", "%v is not an interface", reflectType).Error())
@@ -50,8 +54,11 @@ func GetAllTypesThatImplementReflectInterface(ctx context.Context, reflectType r
 			continue
 		}
 		for _, typName := range pkgInfo.Types.Keys() {
-			typ, ok := pkgInfo.Types.Get(typName)
-			if !ok {
+			typ, found, err := pkgInfo.Types.GetErr(typName)
+			if err != nil {
+				return nil, kerr.Wrap("FQSXGNUBLW", err)
+			}
+			if !found {
 				// ke: {"block": {"notest": true}}
 				continue
 			}
@@ -65,20 +72,25 @@ func GetAllTypesThatImplementReflectInterface(ctx context.Context, reflectType r
 		}
 	}
 
-	return out
+	return out, nil
 }
 
-func GetTypeFromCache(ctx context.Context, path string, name string) (*Type, bool) {
+// GetTypeFromCache returns a type from the sys ctx. If the type was found and failed to load, the
+// error is returned.
+func GetTypeFromCache(ctx context.Context, path string, name string) (t *Type, found bool, err error) {
 	scache := sysctx.FromContext(ctx)
 	pcache, ok := scache.Get(path)
 	if !ok {
-		return nil, false
+		return nil, false, nil
 	}
-	t, ok := pcache.Types.Get(name)
-	if !ok {
-		return nil, false
+	ti, found, err := pcache.Types.GetErr(name)
+	if err != nil {
+		return nil, true, kerr.Wrap("VKEAJQBNSR", err)
+	}
+	if !found {
+		return nil, false, nil
 	}
-	return t.Type.(*Type), true
+	return ti.Type.(*Type), true, nil
 }
 
 func (t *Type) ZeroValue(ctx context.Context, null bool) (reflect.Value, error) {
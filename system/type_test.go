@@ -1,6 +1,7 @@
 package system
 
 import (
+	"errors"
 	"testing"
 
 	"reflect"
@@ -8,6 +9,7 @@ import (
 	"context"
 
 	"github.com/davelondon/ktest/assert"
+	"kego.io/context/sysctx"
 	"kego.io/json"
 	"kego.io/tests"
 )
@@ -185,14 +187,14 @@ func TestNativeJsonType(t *testing.T) {
 func TestGetTypeFromCache(t *testing.T) {
 	d := &Type{Object: &Object{Id: NewReference("a.b/c", "d")}}
 	cb := tests.Context("a.b/c").Stype("d", d)
-	ty, ok := GetTypeFromCache(cb.Ctx(), "a.b/c", "d")
+	ty, ok, _ := GetTypeFromCache(cb.Ctx(), "a.b/c", "d")
 	assert.True(t, ok)
 	assert.Equal(t, d, ty)
 
-	ty, ok = GetTypeFromCache(cb.Ctx(), "a.b/c", "e")
+	ty, ok, _ = GetTypeFromCache(cb.Ctx(), "a.b/c", "e")
 	assert.False(t, ok)
 
-	ty, ok = GetTypeFromCache(cb.Ctx(), "f", "g")
+	ty, ok, _ = GetTypeFromCache(cb.Ctx(), "f", "g")
 	assert.False(t, ok)
 }
 
@@ -235,31 +237,58 @@ func TestGetAllTypesThatImplementInterface(t *testing.T) {
 		JtypeIface("tfoo", reflect.TypeOf(&tFoo{}), reflect.TypeOf((*iFoo)(nil)).Elem()).Stype("tfoo", tfoo).
 		Jtype("tfoobar", reflect.TypeOf(&tFooBar{})).Stype("tfoobar", tfoobar)
 
-	types := GetAllTypesThatImplementInterface(cb.Ctx(), ibar)
+	types, _ := GetAllTypesThatImplementInterface(cb.Ctx(), ibar)
 	assert.Equal(t, 1, len(types))
 	assert.Equal(t, "tfoobar", types[0].Id.Name)
 
-	types = GetAllTypesThatImplementInterface(cb.Ctx(), tfoo)
+	types, _ = GetAllTypesThatImplementInterface(cb.Ctx(), tfoo)
 	assert.Equal(t, 2, len(types))
 	assert.Equal(t, "tfoo", types[0].Id.Name)
 	assert.Equal(t, "tfoobar", types[1].Id.Name)
 
-	types = GetAllTypesThatImplementInterface(cb.Ctx(), &Type{Object: &Object{Id: NewReference("a.b/c", "d")}})
+	types, _ = GetAllTypesThatImplementInterface(cb.Ctx(), &Type{Object: &Object{Id: NewReference("a.b/c", "d")}})
 	assert.Nil(t, types)
 
-	types = GetAllTypesThatImplementInterface(cb.Ctx(), &Type{Object: &Object{Id: NewReference("a.b/c", "d")}, Interface: true})
+	types, _ = GetAllTypesThatImplementInterface(cb.Ctx(), &Type{Object: &Object{Id: NewReference("a.b/c", "d")}, Interface: true})
 	assert.Nil(t, types)
 
 	rw := &RuleWrapper{Ctx: cb.Ctx(), Interface: nil, Parent: tfoo, Struct: &Rule{Interface: false}}
-	types = rw.PermittedTypes()
+	types, _ = rw.PermittedTypes()
 	assert.Equal(t, 1, len(types))
 	assert.Equal(t, "tfoo", types[0].Id.Name)
 
 	rw = &RuleWrapper{Ctx: cb.Ctx(), Interface: nil, Parent: tfoo, Struct: &Rule{Interface: true}}
-	types = rw.PermittedTypes()
+	types, _ = rw.PermittedTypes()
 	assert.Equal(t, 2, len(types))
 	assert.Equal(t, "tfoo", types[0].Id.Name)
 	assert.Equal(t, "tfoobar", types[1].Id.Name)
+
+	rw = &RuleWrapper{Ctx: cb.Ctx(), Interface: nil, Parent: tfoo, Struct: &Rule{Interface: true, OneOf: []*Reference{NewReference("a.b/c", "tfoobar"), NewReference("a.b/c", "d")}}}
+	types, _ = rw.PermittedTypes()
+	assert.Equal(t, 1, len(types))
+	assert.Equal(t, "tfoobar", types[0].Id.Name)
+
+	// A type that fails to load is an error.
+	pi, _ := sysctx.FromContext(cb.Ctx()).Get("a.b/c")
+	pi.Types.SetLazy("broken", "broken.json", func() (interface{}, error) { return nil, errors.New("e") })
+	_, err := GetAllTypesThatImplementInterface(cb.Ctx(), ibar)
+	assert.IsError(t, err, "OYKDBWMRTE")
+	assert.HasError(t, err, "FQSXGNUBLW")
+
+	rw = &RuleWrapper{Ctx: cb.Ctx(), Interface: nil, Parent: tfoo, Struct: &Rule{Interface: true, OneOf: []*Reference{NewReference("a.b/c", "broken")}}}
+	_, err = rw.PermittedTypes()
+	assert.IsError(t, err, "GUJXNCWHPB")
+	assert.HasError(t, err, "WGYPNTSMKO")
+}
+
+func TestRulePermitsType(t *testing.T) {
+	var r *Rule
+	assert.True(t, r.PermitsType(*NewReference("a.b/c", "d")))
+	r = &Rule{}
+	assert.True(t, r.PermitsType(*NewReference("a.b/c", "d")))
+	r = &Rule{OneOf: []*Reference{NewReference("a.b/c", "e")}}
+	assert.False(t, r.PermitsType(*NewReference("a.b/c", "d")))
+	assert.True(t, r.PermitsType(*NewReference("a.b/c", "e")))
 }
 
 type tInt int
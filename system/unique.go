@@ -0,0 +1,33 @@
+package system
+
+import (
+	"context"
+	"fmt"
+)
+
+// The scopes of a unique rule.
+const (
+	UniquePackage = "package"
+	UniqueType    = "type"
+	UniqueParent  = "parent"
+)
+
+// GetScope returns the scope of the rule, which is package if it's not set.
+func (r *UniqueRule) GetScope() string {
+	if r.Scope == nil {
+		return UniquePackage
+	}
+	return r.Scope.Value()
+}
+
+func (r *UniqueRule) Validate(ctx context.Context) (fail bool, messages []string, err error) {
+	switch r.GetScope() {
+	case UniquePackage, UniqueType, UniqueParent:
+	default:
+		fail = true
+		messages = append(messages, fmt.Sprintf("Scope: %s should be %s, %s or %s", r.GetScope(), UniquePackage, UniqueType, UniqueParent))
+	}
+	return
+}
+
+var _ Validator = (*UniqueRule)(nil)
@@ -0,0 +1,30 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/davelondon/ktest/assert"
+	"github.com/davelondon/ktest/require"
+	"kego.io/context/envctx"
+)
+
+func TestUniqueRule_Validate(t *testing.T) {
+	r := &UniqueRule{}
+	assert.Equal(t, "package", r.GetScope())
+	fail, messages, err := r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+	assert.Equal(t, 0, len(messages))
+
+	r = &UniqueRule{Scope: NewString("parent")}
+	assert.Equal(t, "parent", r.GetScope())
+	fail, _, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.False(t, fail)
+
+	r = &UniqueRule{Scope: NewString("foo")}
+	fail, messages, err = r.Validate(envctx.Empty)
+	require.NoError(t, err)
+	assert.True(t, fail)
+	assert.Equal(t, "Scope: foo should be package, type or parent", messages[0])
+}
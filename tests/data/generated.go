@@ -1,14 +1,13 @@
-// info:{"Path":"kego.io/tests/data","Hash":748389013432374446}
+// info:{"Path":"kego.io/tests/data","Hash":3793104158262638058}
 package data
 
 // ke: {"file": {"notest": true}}
 
 import (
 	"context"
-	"reflect"
-
 	"kego.io/context/jsonctx"
 	"kego.io/system"
+	"reflect"
 )
 
 // Automatically created basic rule for face
@@ -128,7 +127,7 @@ func (o *Simple) GetSimple(ctx context.Context) *Simple {
 	return o
 }
 func init() {
-	pkg := jsonctx.InitPackage("kego.io/tests/data", 748389013432374446)
+	pkg := jsonctx.InitPackage("kego.io/tests/data", 3793104158262638058)
 	pkg.InitType("face", reflect.TypeOf((*Face)(nil)).Elem(), reflect.TypeOf((*FaceRule)(nil)), nil)
 	pkg.InitType("facea", reflect.TypeOf((*Facea)(nil)), reflect.TypeOf((*FaceaRule)(nil)), reflect.TypeOf((*FaceaInterface)(nil)).Elem())
 	pkg.InitType("faceb", reflect.TypeOf((*Faceb)(nil)), reflect.TypeOf((*FacebRule)(nil)), reflect.TypeOf((*FacebInterface)(nil)).Elem())
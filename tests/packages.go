@@ -30,6 +30,9 @@ func (c *ContextBuilder) TempGopath(sys bool) *ContextBuilder {
 	os.Mkdir(filepath.Join(gopath, "src"), os.FileMode(0777))
 	c.gopathInitialized = true
 	c.OsVar("GOPATH", gopath)
+	// Packages in the temporary GOPATH must not be resolved with the go.mod of the current
+	// directory.
+	c.OsVar("GO111MODULE", "off")
 	if sys {
 		c.CopyToTemp("kego.io/system")
 	}
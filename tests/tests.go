@@ -330,6 +330,8 @@ func (c *ContextBuilder) Sauto(parseParse func(context.Context, string) (*sysctx
 		panic(err.Error())
 	}
 	env.Hash = pi.Hash
+	env.Include = pi.Include
+	env.Exclude = pi.Exclude
 	return c
 }
 
@@ -402,3 +404,16 @@ func (c *ContextBuilder) OsWd(dir string) *ContextBuilder {
 	m.WorkingDirectory = dir
 	return c
 }
+
+func (c *ContextBuilder) OsFile(filename string, contents string) *ContextBuilder {
+	vos := c.initVos()
+	m, ok := vos.(*MockOs)
+	if !ok {
+		panic("must me *MockEnv")
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	m.Files[filename] = contents
+	return c
+}
@@ -1,8 +1,11 @@
 package tests
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"kego.io/context/vosctx"
 )
@@ -12,6 +15,7 @@ var _ vosctx.Vos = (*MockOs)(nil)
 type MockOs struct {
 	EnvironmentVariables map[string]string
 	WorkingDirectory     string
+	Files                map[string]string
 }
 
 func (o *MockOs) Getenv(key string) string {
@@ -34,15 +38,62 @@ func (o *MockOs) Environ() []string {
 		return os.Environ()
 	}
 	out := []string{}
+	found := map[string]bool{}
 Outer:
 	for _, real := range os.Environ() {
 		for mockName, mockValue := range o.EnvironmentVariables {
 			if strings.HasPrefix(real, mockName+"=") {
 				out = append(out, mockName+"="+mockValue)
+				found[mockName] = true
 				continue Outer
 			}
 		}
 		out = append(out, real)
 	}
+	for mockName, mockValue := range o.EnvironmentVariables {
+		if !found[mockName] {
+			out = append(out, mockName+"="+mockValue)
+		}
+	}
 	return out
 }
+
+// ReadFile returns the contents of a mocked file, or reads the file from disk if it's not mocked.
+func (o *MockOs) ReadFile(filename string) ([]byte, error) {
+	if contents, ok := o.Files[filename]; ok {
+		return []byte(contents), nil
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// Stat describes a mocked file, or a directory that holds a mocked file. Other files are read from
+// disk.
+func (o *MockOs) Stat(name string) (os.FileInfo, error) {
+	name = filepath.Clean(name)
+	for filename := range o.Files {
+		if filename == name {
+			return &mockFileInfo{name: filepath.Base(name)}, nil
+		}
+		if strings.HasPrefix(filename, name+string(filepath.Separator)) {
+			return &mockFileInfo{name: filepath.Base(name), dir: true}, nil
+		}
+	}
+	return os.Stat(name)
+}
+
+type mockFileInfo struct {
+	name string
+	dir  bool
+}
+
+func (i *mockFileInfo) Name() string       { return i.name }
+func (i *mockFileInfo) Size() int64        { return 0 }
+func (i *mockFileInfo) ModTime() time.Time { return time.Time{} }
+func (i *mockFileInfo) IsDir() bool        { return i.dir }
+func (i *mockFileInfo) Sys() interface{}   { return nil }
+func (i *mockFileInfo) Mode() os.FileMode {
+	if i.dir {
+		return os.ModeDir | 0777
+	}
+	return 0666
+}